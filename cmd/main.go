@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -9,13 +11,15 @@ import (
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
-	"golang.org/x/image/colornames"
 	"image"
-	"image/color"
 	"math"
+	"math/rand/v2"
+	"maze/internal/pkg/gameloop"
 	"maze/internal/pkg/maze"
 	"maze/internal/pkg/opensimplex"
 	"maze/internal/pkg/raycastmap"
+	"maze/internal/pkg/render"
+	"maze/internal/pkg/wolf3d"
 	"os"
 	"strconv"
 	"time"
@@ -23,22 +27,53 @@ import (
 
 const detectWalls = true
 
+// minFrameTime is the shortest time of a frame, the rest of it is left to the UI (at most 120 frames per second)
+const minFrameTime = time.Second / 120
+
 var (
 	wolfensteinOriginalWidth  = 320
 	wolfensteinOriginalHeight = 200
 	scaleFactor               = 2
 
-	observerRadius = 0.2
-
 	showInformation  = true  // Show information about FPS, observer position and iew direction and rendering settings
 	showMap          = true  // Show an overview map of the maze with observer position centered in the middle
 	showAimLine      = false // Show aim line ("cross-hair")
 	useTextures      = true  // Value: false == "no textures", true == "show textures"
+	useSurfaces      = false // Value: false == "flat colored floor and roof", true == "textured floor and roof"
+	usePointLights   = true  // Value: false == "lamps do not light up the maze", true == "lamps are point lights"
+	useFog           = false // Value: false == "no fog", true == "fog of the level (if any), and rays stop where the fog is full"
+	useToneMapping   = 0     // Value: 0 == "off, lighting on sRGB colors", 1 == "linear light, clamped", 2 == "linear light, Reinhard", 3 == "linear light, ACES filmic"
 	useAmbientLight  = 2     // Value: 0 == "ambient light off", 1 == "full ambient light", 2 == "dark ambient light"
 	useObserverLight = 2     // Value: 0 == "observer light off", 1 == "observer light", 2 == "observer light animation"
 )
 
 func main() {
+	mapHeaderFilename := flag.String("maphead", "", "Map header file (like MAPHEAD.WL6). Uses the embedded shareware levels if not set.")
+	gameMapsFilename := flag.String("gamemaps", "", "Game maps file (like GAMEMAPS.WL6). Uses the embedded shareware levels if not set.")
+	startLevel := flag.Int("level", 0, "Level to start in")
+	vswapFilename := flag.String("vswap", "", "VSWAP file (like VSWAP.WL6) to take wall and sprite textures from. Uses the extracted shareware textures if not set.")
+	paletteFilename := flag.String("palette", "", "Game palette file (256 colors as R, G, B bytes, 6-bit VGA or 8-bit values), needed with -vswap")
+	tilesFilename := flag.String("tiles", "", "Tile definition file (JSON) mapping level codes to walls and sprites. Uses the built-in Wolfenstein 3D tiles if not set.")
+	fov := flag.Float64("fov", maze.DefaultFOV, "Horizontal field of view in degrees, for a 4:3 window. Wider windows show more to the sides.")
+	difficulty := flag.Int("difficulty", int(wolf3d.DifficultyHard), "Difficulty: 0 easy, 1 medium, 2 hard. Enemies are present in their difficulty and the harder ones.")
+	seed := flag.Uint64("seed", 0, "Seed of the random numbers of the game (reaction times, hits, damage...), 0 takes a seed from the clock. The same seed plays the same again.")
+	exposure := flag.Float64("exposure", 1.0, "Exposure of the linear light tone mapping, 1.0 is unchanged.")
+	gamma := flag.Float64("gamma", 0.0, "Display gamma of the linear light tone mapping (like 2.2), 0 uses the sRGB curve.")
+	flag.Parse()
+
+	toneMappers := []*maze.ToneMapper{
+		nil,
+		maze.NewToneMapper(maze.ToneMappingClamp, *exposure, *gamma),
+		maze.NewToneMapper(maze.ToneMappingReinhard, *exposure, *gamma),
+		maze.NewToneMapper(maze.ToneMappingACES, *exposure, *gamma),
+	}
+
+	tiles, err := loadTileTable(*tilesFilename, *vswapFilename, *paletteFilename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	application := app.New()
 	window := application.NewWindow("Maze")
 
@@ -49,18 +84,31 @@ func main() {
 	windowHeight := wolfensteinOriginalHeight * scaleFactor
 
 	// worldMap, err := raycastmap.WolfMap()
-	worldMap, err := raycastmap.NewWolfensteinMap(0)
+	worldMap, err := loadWorldMap(*mapHeaderFilename, *gameMapsFilename, tiles, *startLevel)
 	if err != nil {
-		panic(1)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *seed == 0 {
+		*seed = uint64(time.Now().UnixNano())
 	}
+	random := rand.New(rand.NewPCG(*seed, 0))
 
-	observer := &maze.Vector{X: worldMap.StartX(), Y: worldMap.StartY()}
-	viewDirectionAngle := worldMap.StartDir()
+	world := maze.NewWorld(worldMap, wolf3d.Difficulty(*difficulty))
+	world.Random = random
+	pitch := 0.0
+
+	lightMap := maze.NewLightMap(worldMap, maze.CollectLights(worldMap))
 
 	noiseGenerator := opensimplex.New(100)
 
-	movementLength := 0.2
-	turnSpeed := (math.Pi * 2.0) / (3.0 * 30.0) // one 360 turn in 2 seconds (if frame rate is 30)
+	var clock gameloop.Clock = gameloop.SystemClock{}
+
+	movementSpeed := 6.0               // Map cells per second
+	turnSpeed := (math.Pi * 2.0) / 3.0 // Radians per second, one 360 turn in 3 seconds
+	pitchStep := 0.05
+	maxPitch := 0.5 // y-shearing distorts more the further up or down you look
 
 	keyUpPressed := false
 	keyDownPressed := false
@@ -68,11 +116,23 @@ func main() {
 	keyRightPressed := false
 	keyAltLeftPressed := false
 	keyAltRightPressed := false
+	keyUsePressed := false
+	keyFirePressed := false
+	selectWeapon := maze.Weapon(-1) // Weapon to select at the next tick, -1 for none
 
 	if dc, ok := window.Canvas().(desktop.Canvas); ok {
 		dc.SetOnKeyDown(func(event *fyne.KeyEvent) {
 			if event.Name == fyne.KeyT {
 				useTextures = !useTextures
+			} else if event.Name == fyne.KeyF {
+				useSurfaces = !useSurfaces
+			} else if event.Name == fyne.KeyL {
+				usePointLights = !usePointLights
+			} else if event.Name == fyne.KeyG {
+				useFog = !useFog
+			} else if event.Name == fyne.KeyM {
+				useToneMapping++
+				useToneMapping = useToneMapping % len(toneMappers)
 			} else if event.Name == fyne.KeyA {
 				useAmbientLight++
 				useAmbientLight = useAmbientLight % 3
@@ -81,6 +141,24 @@ func main() {
 				useObserverLight = useObserverLight % 3
 			} else if event.Name == fyne.KeyEscape { // Quick quit
 				os.Exit(0)
+			} else if event.Name == fyne.KeySpace {
+				keyUsePressed = true
+			} else if event.Name == desktop.KeyControlLeft {
+				keyFirePressed = true
+			} else if event.Name == fyne.Key1 {
+				selectWeapon = maze.WeaponKnife
+			} else if event.Name == fyne.Key2 {
+				selectWeapon = maze.WeaponPistol
+			} else if event.Name == fyne.Key3 {
+				selectWeapon = maze.WeaponMachineGun
+			} else if event.Name == fyne.Key4 {
+				selectWeapon = maze.WeaponChainGun
+			} else if event.Name == fyne.KeyPageUp {
+				pitch = math.Min(pitch+pitchStep, maxPitch)
+			} else if event.Name == fyne.KeyPageDown {
+				pitch = math.Max(pitch-pitchStep, -maxPitch)
+			} else if event.Name == fyne.KeyHome {
+				pitch = 0.0
 			} else if event.Name == fyne.KeyUp {
 				keyUpPressed = true
 			} else if event.Name == fyne.KeyDown {
@@ -100,9 +178,20 @@ func main() {
 
 		dc.SetOnKeyUp(func(event *fyne.KeyEvent) {
 			if event.Name == fyne.KeyT {
+			} else if event.Name == fyne.KeyF {
+			} else if event.Name == fyne.KeyL {
+			} else if event.Name == fyne.KeyG {
+			} else if event.Name == fyne.KeyM {
 			} else if event.Name == fyne.KeyA {
 			} else if event.Name == fyne.KeyO {
 			} else if event.Name == fyne.KeyEscape {
+			} else if event.Name == fyne.KeySpace {
+			} else if event.Name == desktop.KeyControlLeft {
+				keyFirePressed = false
+			} else if event.Name == fyne.Key1 || event.Name == fyne.Key2 || event.Name == fyne.Key3 || event.Name == fyne.Key4 {
+			} else if event.Name == fyne.KeyPageUp {
+			} else if event.Name == fyne.KeyPageDown {
+			} else if event.Name == fyne.KeyHome {
 			} else if event.Name == fyne.KeyUp {
 				keyUpPressed = false
 			} else if event.Name == fyne.KeyDown {
@@ -121,8 +210,7 @@ func main() {
 		})
 	}
 
-	imageSize := image.Rect(0, 0, renderWidth, renderHeight)
-	img := image.NewRGBA(imageSize)
+	img := image.NewRGBA(image.Rect(0, 0, renderWidth, renderHeight))
 
 	rayImage := image.NewRGBA(image.Rect(0, 0, 100, 100))
 	mapCanvas := canvas.NewImageFromImage(rayImage)
@@ -141,92 +229,150 @@ func main() {
 
 	window.Resize(fyne.NewSize(float32(windowWidth), float32(windowHeight)))
 
+	renderer := render.NewRenderer(0) // One raycasting worker per CPU
+
+	loop := gameloop.New(gameloop.DefaultTickRate, clock)
+
 	go func() {
-		timestamp := time.Now()
-		for {
+		// The player before the latest tick, rendered in between the ticks
+		previousPlayer := world.Player.Creature
+
+		simulate := func(dt float64) {
+			player := world.Player
+			previousPlayer = player.Creature
+
+			if !player.IsAlive() {
+				// A dead player does not move or use anything anymore, the world goes on without the player
+				keyUsePressed, selectWeapon = false, -1
+				world.Update(dt)
+				worldMap.Doors().Update(dt, world.Occupied)
+				return
+			}
+
+			movementLength := movementSpeed * dt
 			if keyLeftPressed {
 				if keyAltLeftPressed || keyAltRightPressed {
 					// Strafe left
-					headingDirection := maze.NewDirectionVector(viewDirectionAngle + math.Pi/2.0)
-					obstacleInTheWay := isObstacleInTheWay(headingDirection, observer, worldMap, observerRadius, movementLength)
-					if !detectWalls || !obstacleInTheWay {
-						observer = observer.Add(headingDirection.Scale(movementLength))
-					}
+					headingDirection := maze.NewDirectionVector(player.Heading + math.Pi/2.0)
+					moveObserver(player, headingDirection.Scale(movementLength), worldMap)
 				} else {
 					// Turn left
-					viewDirectionAngle += turnSpeed
-
-					if viewDirectionAngle > math.Pi*2.0 {
-						viewDirectionAngle -= math.Pi * 2.0
-					}
+					player.Turn(turnSpeed * dt)
 				}
 			}
 			if keyRightPressed {
 				if keyAltLeftPressed || keyAltRightPressed {
 					// Strafe right
-					headingDirection := maze.NewDirectionVector(viewDirectionAngle - math.Pi/2.0)
-					obstacleInTheWay := isObstacleInTheWay(headingDirection, observer, worldMap, observerRadius, movementLength)
-					if !detectWalls || !obstacleInTheWay {
-						observer = observer.Add(headingDirection.Scale(movementLength))
-					}
+					headingDirection := maze.NewDirectionVector(player.Heading - math.Pi/2.0)
+					moveObserver(player, headingDirection.Scale(movementLength), worldMap)
 				} else {
 					// Turn right
-					viewDirectionAngle -= turnSpeed
-					if viewDirectionAngle < 0.0 {
-						viewDirectionAngle += math.Pi * 2.0
-					}
+					player.Turn(-turnSpeed * dt)
 				}
 			}
 			if keyUpPressed {
-				headingDirection := maze.NewDirectionVector(viewDirectionAngle)
-				obstacleInTheWay := isObstacleInTheWay(headingDirection, observer, worldMap, observerRadius, movementLength)
-				if !detectWalls || !obstacleInTheWay {
-					observer = observer.Add(headingDirection.Scale(movementLength))
-				}
+				moveObserver(player, player.Direction().Scale(movementLength), worldMap)
 			}
 			if keyDownPressed {
-				headingDirection := maze.NewDirectionVector(viewDirectionAngle).Flip()
+				moveObserver(player, player.Direction().Flip().Scale(movementLength), worldMap)
+			}
 
-				if detectWalls {
-					if !isObstacleInTheWay(headingDirection, observer, worldMap, observerRadius, movementLength) {
-						observer = observer.Add(headingDirection.Scale(movementLength))
-					}
-				} else {
-					observer = observer.Add(headingDirection.Scale(movementLength))
+			if keyUsePressed {
+				keyUsePressed = false
+
+				levelChanged, err := useInFront(player, worldMap)
+				if errors.Is(err, raycastmap.ErrGameCompleted) {
+					fmt.Println("Congratulations, all episodes are completed!")
+				} else if err != nil {
+					fmt.Println("Could not change level: " + err.Error())
+				} else if levelChanged {
+					// Go on at the start point of the new level, with the health, weapons and ammo of the player
+					world = maze.NewWorld(worldMap, wolf3d.Difficulty(*difficulty))
+					world.KeepPlayer(player)
+					world.Random = random
+					previousPlayer = world.Player.Creature
+					lightMap = maze.NewLightMap(worldMap, maze.CollectLights(worldMap))
 				}
 			}
 
+			if selectWeapon >= 0 {
+				world.Player.SelectWeapon(selectWeapon)
+				selectWeapon = -1
+			}
+			world.Player.Trigger = keyFirePressed
+
+			world.Update(dt)
+			worldMap.Doors().Update(dt, world.Occupied)
+		}
+
+		for {
+			frameStart := clock.Now()
+			alpha := loop.Frame(simulate)
+			lightMap.Update() // Light through moving doors
+
+			// Render the player in between the two latest ticks, so movement looks smooth at any frame rate
+			player := world.Player
+			renderPosition := previousPlayer.Position.Add(player.Position.Sub(&previousPlayer.Position).Scale(alpha))
+			renderHeading := interpolateAngle(previousPlayer.Heading, player.Heading, alpha)
+
 			torchFade := 0.0
 			if useObserverLight == 2 {
 				noiseSpeed := 500.0 // The higher value, the slower fluctuations in noise function
-				noisePosition := float64(uint32(time.Now().UnixMilli())) / noiseSpeed
+				noisePosition := float64(uint32(clock.Now().UnixMilli())) / noiseSpeed
 				smoothRandomValues := noiseGenerator.Eval64(noisePosition) // Value range [-1, 1]
 
 				torchFade = (1.0 - smoothRandomValues) / 2.0 // Compress value range [-1, 1] --> [0, 1]
 			}
 
 			torchLight := maze.NewColor(1.0, 1.0, 0.9).FadeTo(maze.NewColor(0.6, 0.5, 0.3), torchFade)
-			ambientLight := maze.NewColor(0.2, 0.2, 0.3)
+			ambientLight := render.AmbientLightDark
+			if useAmbientLight == 0 {
+				ambientLight = render.AmbientLightOff
+			} else if useAmbientLight == 1 {
+				ambientLight = render.AmbientLightFull
+			}
 
-			pixelColumnInfos := maze.Raycast(img.Bounds().Dx(), observer, viewDirectionAngle, worldMap)
-			clearImage(img, ambientLight, torchLight)
-			if useTextures {
-				paintImageTexturized(img, pixelColumnInfos, ambientLight, torchLight)
-			} else {
-				paintImageColorized(img, pixelColumnInfos, ambientLight, torchLight)
+			// Keep the render image aspect ratio the same as the window, so a wider window shows more instead of stretching
+			if width := renderWidthFor(window.Canvas().Size(), renderHeight); width != img.Bounds().Dx() {
+				img = image.NewRGBA(image.Rect(0, 0, width, renderHeight))
+				imgCanvas.Image = img
+			}
+
+			var pointLights *maze.LightMap
+			if usePointLights {
+				pointLights = lightMap
+			}
+
+			var fog *raycastmap.Fog
+			if useFog {
+				fog = worldMap.Fog()
 			}
 
+			camera := maze.NewCamera(*renderPosition, renderHeading)
+			camera.FOV = *fov
+			camera.Pitch = pitch
+			if fog != nil {
+				camera.MaxDistance = fog.Distance() // Nothing can be seen further away
+			}
+			renderer.Render(img, worldMap, camera, render.RenderOptions{
+				Textures:      useTextures,
+				Surfaces:      useSurfaces,
+				AmbientLight:  ambientLight,
+				ObserverLight: useObserverLight != 0,
+				TorchLight:    torchLight,
+				LightMap:      pointLights,
+				Fog:           fog,
+				ToneMapper:    toneMappers[useToneMapping],
+				AimLine:       showAimLine,
+				Sprites:       world.Sprites(),
+			})
+
 			if showMap {
-				paintMap(rayImage, observer, worldMap)
+				render.RenderMap(rayImage, worldMap, camera)
 			}
 			mapCanvas.Hidden = !showMap
 			mapCanvas.Refresh()
 
-			now := time.Now()
-			duration := now.Sub(timestamp)
-			timestamp = now
-			fps := 1000.0 / float64(duration.Milliseconds())
-
 			if showInformation {
 				textureString := "OFF"
 				if useTextures {
@@ -238,6 +384,22 @@ func main() {
 				} else if useAmbientLight == 2 {
 					ambientString = "LOW"
 				}
+				surfacesString := "OFF"
+				if useSurfaces {
+					surfacesString = "ON"
+				}
+				toneMappingString := "OFF"
+				if toneMapper := toneMappers[useToneMapping]; toneMapper != nil {
+					toneMappingString = toneMapper.ToneMapping().String()
+				}
+				fogString := "OFF"
+				if useFog {
+					fogString = "ON"
+				}
+				pointLightsString := "OFF"
+				if usePointLights {
+					pointLightsString = "ON"
+				}
 				observerLightString := "OFF"
 				if useObserverLight == 1 {
 					observerLightString = "ON"
@@ -245,234 +407,118 @@ func main() {
 					observerLightString = "ANIMATED"
 				}
 
-				fpsLabel.SetText(fmt.Sprintf("FPS: %.0f", fps))
-				posLabel.SetText(fmt.Sprintf("pos: %+v  dir: %.0f", observer, viewDirectionAngle*(180.0/math.Pi)))
-				featureLabel.SetText(fmt.Sprintf("[a] ambient light: %s    [o] observer light: %s    [t] texture: %s", ambientString, observerLightString, textureString))
+				fpsLabel.SetText(fmt.Sprintf("FPS: %.0f", loop.FPS()))
+				posLabel.SetText(fmt.Sprintf("level: %d (%s)  pos: %+v  dir: %.0f  health: %d  weapon: %s  ammo: %d", worldMap.Level(), worldMap.LevelName(), &player.Position, player.Heading*(180.0/math.Pi), player.Health, player.Weapon, player.Ammo))
+				featureLabel.SetText(fmt.Sprintf("[a] ambient light: %s    [o] observer light: %s    [t] texture: %s    [f] floor texture: %s    [l] lamps: %s    [g] fog: %s    [m] tone mapping: %s", ambientString, observerLightString, textureString, surfacesString, pointLightsString, fogString, toneMappingString))
 			}
 			informationContainer.Hidden = !showInformation
 			informationContainer.Refresh()
 
 			imgCanvas.Refresh()
-			time.Sleep(20 * time.Millisecond)
+			if rest := minFrameTime - clock.Now().Sub(frameStart); rest > 0 {
+				time.Sleep(rest) // Only the rest of the frame, slow frames are not held back
+			}
 		}
 	}()
 
 	window.ShowAndRun()
 }
 
-func isObstacleInTheWay(headingDirection *maze.Vector, observer *maze.Vector, worldMap *raycastmap.WolfensteinMap, observerRadius float64, movementLength float64) bool {
-	info := maze.RaycastRay(observer, headingDirection, worldMap)
-	dist := info.IntersectionPoint.Sub(observer).Length()
-	return info.Wall.Structure.IsObstacle() && (dist-observerRadius) < movementLength
-}
+// moveObserver moves the player, sliding along walls and obstacles in the way.
+func moveObserver(player *maze.Player, motion *maze.Vector, worldMap *raycastmap.WolfensteinMap) {
+	if !detectWalls {
+		player.Position = *player.Position.Add(motion)
+		return
+	}
 
-func paintMap(mapImage *image.RGBA, observer *maze.Vector, m raycastmap.Map) {
-	colorBorder := color.NRGBA{R: 128, G: 16, B: 16, A: 128}
+	player.Move(*motion, worldMap)
+}
 
-	w := mapImage.Bounds().Dx()
-	h := mapImage.Bounds().Dy()
-	hw := w / 2
-	hh := h / 2
+// interpolateAngle gives the angle a fraction alpha of the way from one angle to another, turning the shortest way.
+func interpolateAngle(from, to, alpha float64) float64 {
+	difference := math.Remainder(to-from, math.Pi*2.0) // Range [-Pi, Pi]
+	return from + difference*alpha
+}
 
-	// Clear with black opaque (non-transparent) color
-	for y := 0; y < h; y++ {
-		for x := 0; x < w; x++ {
-			mapImage.Set(x, y, color.RGBA{A: 255})
-		}
+// renderWidthFor gives the render image width with the same aspect ratio as a window (canvas) size.
+func renderWidthFor(windowSize fyne.Size, renderHeight int) int {
+	if windowSize.Width <= 0 || windowSize.Height <= 0 {
+		return renderHeight * wolfensteinOriginalWidth / wolfensteinOriginalHeight
 	}
 
-	xOffset := int(observer.X) - hw/2
-	yOffset := int(observer.Y) - hh/2
-
-	// Draw walls
-	for iy := 0; iy < hh; iy++ {
-		for ix := 0; ix < hw; ix++ {
-			mapx := ix + xOffset
-			mapy := iy + yOffset
-
-			c := color.Color(color.RGBA{A: 196})
-			if m.ObstacleAt(mapx, mapy) {
-				structure := m.StructureAt(mapx, mapy)
-				special := m.SpecialAt(mapx, mapy)
-				if structure != nil && structure.Texture != nil {
-					c = structure.Texture.DominantColor()
-				} else if special != nil && special.Texture != nil {
-					c = special.Texture.DominantColor()
-				}
-			}
+	return int(float32(renderHeight) * windowSize.Width / windowSize.Height)
+}
 
-			mapImage.Set(2*ix, h-2*iy, c)
-			mapImage.Set(2*ix+1, h-2*iy, c)
-			mapImage.Set(2*ix, h-(2*iy+1), c)
-			mapImage.Set(2*ix+1, h-(2*iy+1), c)
-		}
-	}
+// useInFront performs the "use" action on the map cell in front of the player, like opening a door or pressing the elevator switch.
+func useInFront(player *maze.Player, worldMap *raycastmap.WolfensteinMap) (levelChanged bool, err error) {
+	const useDistance = 1.0
 
-	// Observer (in the middle of map)
-	mapImage.Set(hw, hh, colornames.White)
-	mapImage.Set(hw+1, hh, colornames.White)
-	mapImage.Set(hw, hh+1, colornames.White)
-	mapImage.Set(hw+1, hh+1, colornames.White)
+	front := player.Position.Add(player.Direction().Scale(useDistance))
+	x, y := player.Cell()
+	return worldMap.Use(int(front.X), int(front.Y), x, y)
+}
 
-	// Draw borders
-	for x := 0; x < w; x++ {
-		mapImage.Set(x, 0, colorBorder)
-		mapImage.Set(x, h-1, colorBorder)
+// loadWorldMap loads the levels from map files, or the embedded shareware levels if no map files are given.
+func loadWorldMap(mapHeaderFilename string, gameMapsFilename string, tiles *raycastmap.TileTable, level int) (*raycastmap.WolfensteinMap, error) {
+	var levelMaps []wolf3d.LevelMap
+	var err error
+
+	switch {
+	case mapHeaderFilename == "" && gameMapsFilename == "":
+		levelMaps, err = wolf3d.Wolfenstein3DMap()
+	case mapHeaderFilename == "" || gameMapsFilename == "":
+		return nil, fmt.Errorf("both a map header file and a game maps file are needed")
+	default:
+		levelMaps, err = wolf3d.LoadGameMapsFromFiles(mapHeaderFilename, gameMapsFilename)
 	}
-	for y := 0; y < h; y++ {
-		mapImage.Set(0, y, colorBorder)
-		mapImage.Set(w-1, y, colorBorder)
+	if err != nil {
+		return nil, err
 	}
-}
-
-func paintImageTexturized(img *image.RGBA, pixelColumnInfos []maze.IntersectionInfo, ambientLight *maze.Color, torchLight *maze.Color) {
-	//w := img.Bounds().Dx()
-	h := img.Bounds().Dy()
-
-	for x, pixelColumnInfo := range pixelColumnInfos {
-		theoreticalPixelColumnHeight := int(float64(h) / pixelColumnInfo.PerpendicularDistance)
-		actualPixelColumnHeight := min(h, theoreticalPixelColumnHeight)
-
-		// Draw scaled texture pixel column
-		texture := pixelColumnInfo.Wall.Structure.Texture
-		if pixelColumnInfo.Side == 0 && pixelColumnInfo.Wall.Structure.Texture2 != nil && useObserverLight == 0 {
-			texture = pixelColumnInfo.Wall.Structure.Texture2 // Use darker texture on East-West facing wall sides of a cell
-		}
-
-		xOffset := pixelColumnInfo.WallSideIntersectionOffset
-		yLength := float64(actualPixelColumnHeight) / float64(theoreticalPixelColumnHeight)
-		yOffset := (1.0 - yLength) / 2.0
-		scaledPixelData := make([]byte, actualPixelColumnHeight*4)
-		texture.ReadScaledPixelColumn(xOffset, yOffset, yLength, scaledPixelData)
-
-		imageYStart := int(float64(h-actualPixelColumnHeight) / 2.0)
-		imgDataOffset := img.PixOffset(x, imageYStart)
-		for pixelYIndex := 0; pixelYIndex < theoreticalPixelColumnHeight; pixelYIndex++ {
-			imageDataIndex := imgDataOffset + pixelYIndex*img.Stride
-			//imageDataIndex := img.PixOffset(x, y1+pixelYIndex)
-
-			if imageDataIndex >= 0 && (imageDataIndex+3) < len(img.Pix) {
-
-				r := scaledPixelData[pixelYIndex*4+0]
-				g := scaledPixelData[pixelYIndex*4+1]
-				b := scaledPixelData[pixelYIndex*4+2]
-				pixelColor := maze.NewColorFromByte(r, g, b)
-
-				distanceAttenuation := 1.0
-				cosIntersectionAngle := 1.0
-				if useObserverLight == 0 {
-					torchLight = maze.NewColor(0.0, 0.0, 0.0)
-				} else if useObserverLight >= 1 {
-					cosIntersectionAngle = pixelColumnInfo.IntersectionCosAngle
-
-					const attenuationFalloff = 15.0 // Attenuation distance falloff distance setting
-					distance := pixelColumnInfo.IntersectionPoint.Sub(pixelColumnInfo.ObserverPoint).Length()
-					distanceAttenuation = min(1.0, max(0.0, attenuationFalloff/(distance*distance)))
-				}
-
-				if useAmbientLight == 0 {
-					ambientLight = maze.NewColor(0.0, 0.0, 0.0)
-				} else if useAmbientLight == 1 {
-					ambientLight = maze.NewColor(1.0, 1.0, 1.0)
-				}
 
-				rb, gb, bb := pixelColor.Mul(ambientLight).Add(pixelColor.Mul(torchLight).Scale(cosIntersectionAngle).Scale(distanceAttenuation)).Bytes()
-
-				img.Pix[imageDataIndex+0] = rb
-				img.Pix[imageDataIndex+1] = gb
-				img.Pix[imageDataIndex+2] = bb
-				img.Pix[imageDataIndex+3] = scaledPixelData[pixelYIndex*4+3] // A (alpha)
-
-				// Middle aim line ("cross-hair")
-				if showAimLine && x == len(pixelColumnInfos)/2 {
-					img.Pix[imageDataIndex+0] = 255
-				}
-			}
-		}
-	}
+	return raycastmap.NewWolfensteinMapFromLevelsAndTiles(levelMaps, tiles, level)
 }
 
-func paintImageColorized(img *image.RGBA, pixelColumnInfos []maze.IntersectionInfo, ambientLight *maze.Color, torchLight *maze.Color) {
-	//w := img.Bounds().Dx()
-	h := img.Bounds().Dy()
-
-	for x, pixelColumnInfo := range pixelColumnInfos {
-		lineHeight := float64(h) / pixelColumnInfo.PerpendicularDistance
-		y1 := (float64(h) - lineHeight) / 2.0
-		y2 := y1 + lineHeight
-
-		// Color index from what kind of wall plus brightness index from what side of wall
-		c := pixelColumnInfo.Wall.Structure.Texture.DominantColor()
-		if pixelColumnInfo.Side == 1 {
-			c = pixelColumnInfo.Wall.Structure.Texture2.DominantColor()
+// loadTileTable loads the tile definition file, or uses the built-in tiles if no file is given.
+// The wall and sprite textures are taken from the VSWAP file if given, otherwise from the extracted shareware textures.
+func loadTileTable(tilesFilename string, vswapFilename string, paletteFilename string) (*raycastmap.TileTable, error) {
+	var images raycastmap.ImageSource = raycastmap.EmbeddedImageSource{}
+	if vswapFilename != "" {
+		vswap, err := loadVSwap(vswapFilename, paletteFilename)
+		if err != nil {
+			return nil, err
 		}
-
-		cosIntersectionAngle := pixelColumnInfo.IntersectionCosAngle
-
-		const attenuationFalloff = 15.0 // Attenuation distance falloff distance setting
-		distance := pixelColumnInfo.IntersectionPoint.Sub(pixelColumnInfo.ObserverPoint).Length()
-		distanceAttenuation := min(1.0, max(0.0, attenuationFalloff/(distance*distance)))
-
-		if useAmbientLight == 0 {
-			ambientLight = maze.NewColor(0.0, 0.0, 0.0)
-		} else if useAmbientLight == 1 {
-			ambientLight = maze.NewColor(1.0, 1.0, 1.0)
-		}
-
-		if useObserverLight == 0 {
-			torchLight = maze.NewColor(0.0, 0.0, 0.0)
-		}
-
-		nc := maze.NewColorFromColor(c)
-		c = nc.Mul(ambientLight).Add(nc.Mul(torchLight).Scale(cosIntersectionAngle).Scale(distanceAttenuation)).RGBA()
-
-		drawVerticalLine(img, x, int(y1), int(y2), c)
+		images = vswap
 	}
-}
 
-func drawVerticalLine(img *image.RGBA, x int, y1 int, y2 int, c color.Color) {
-	for y := 0; y < y2-y1; y++ {
-		img.Set(x, y1+y, c)
+	if tilesFilename != "" {
+		return raycastmap.LoadTileTableFromFile(tilesFilename, images)
 	}
-}
 
-// clearImage paints the "background" of the game. That is, the roof and the floor.
-func clearImage(img *image.RGBA, ambientLight *maze.Color, torchLight *maze.Color) {
-	colorRoof := maze.NewColor(0.23, 0.23, 0.23)
-	colorFloor := maze.NewColor(0.42, 0.42, 0.42)
-
-	imageHeight := float64(img.Bounds().Dy())
-	halfImageHeight := imageHeight / 2.0
-
-	for y := 0; y < img.Rect.Dy(); y++ {
-		c := colorRoof
-		if y >= (img.Rect.Dy() / 2) {
-			c = colorFloor
+	tiles := raycastmap.DefaultTileTable()
+	if vswapFilename != "" {
+		if err := tiles.LoadTextures(images); err != nil {
+			return nil, err
 		}
+	}
 
-		// Calculation of line height for a wall att perpendicular distance: lineHeight := float64(h) / pixelColumnInfo.PerpendicularDistance
-		halfWallHeightAtDistance := math.Abs(halfImageHeight - float64(y))
-		distance := 1.0 / (halfWallHeightAtDistance * 2.0 / imageHeight)
-
-		const attenuationFalloff = 15.0 // Attenuation distance falloff distance setting
-		distanceAttenuation := min(1.0, max(0.0, attenuationFalloff/(distance*distance)))
-		cosAngle := maze.NewVector(distance, 1.0).Normalized().Y // Height above ground should really be 0.5 i.e. half a wall height up from the ground, not 1.0 (but 1.0 yields better result)
-
-		if useAmbientLight == 0 {
-			ambientLight = maze.NewColor(0.0, 0.0, 0.0)
-		} else if useAmbientLight == 1 {
-			ambientLight = maze.NewColor(1.0, 1.0, 1.0)
-		}
+	return tiles, nil
+}
 
-		if useObserverLight == 0 {
-			torchLight = maze.NewColor(0.0, 0.0, 0.0)
-		}
+// loadVSwap loads a VSWAP file, with the palette used to convert its walls and sprites to images.
+func loadVSwap(vswapFilename string, paletteFilename string) (*wolf3d.VSwap, error) {
+	if paletteFilename == "" {
+		return nil, fmt.Errorf("a palette file is needed to use the textures in a VSWAP file")
+	}
 
-		c = c.Mul(ambientLight).Add(c.Mul(torchLight).Scale(cosAngle).Scale(distanceAttenuation))
-		rgba := c.RGBA()
+	paletteData, err := os.ReadFile(paletteFilename)
+	if err != nil {
+		return nil, err
+	}
 
-		for x := 0; x < img.Rect.Dx(); x++ {
-			img.Set(x, y, rgba)
-		}
+	palette, err := wolf3d.NewPalette(paletteData)
+	if err != nil {
+		return nil, err
 	}
+
+	return wolf3d.LoadVSwapFromFile(vswapFilename, palette)
 }
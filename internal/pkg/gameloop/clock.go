@@ -0,0 +1,35 @@
+package gameloop
+
+import "time"
+
+// Clock gives the current time. The game loop takes the time from a clock, so the simulation can be driven by a
+// ManualClock instead of the wall clock (tests, replays).
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ManualClock is a clock that only moves when it is advanced.
+type ManualClock struct {
+	now time.Time
+}
+
+// NewManualClock creates a manual clock starting at a time.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+func (c *ManualClock) Now() time.Time {
+	return c.now
+}
+
+// Advance moves the clock forward.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,95 @@
+// Package gameloop runs a simulation with a fixed time step ("tick"), independent of how fast frames are rendered.
+package gameloop
+
+import "time"
+
+// DefaultTickRate is the number of simulation ticks per second, like the original game.
+const DefaultTickRate = 70.0
+
+// maxFrameTime is the longest time simulated in one frame. After a hiccup (a breakpoint, a dragged window) the
+// simulation skips ahead instead of running a lot of ticks to catch up, which would make the next frame late as well.
+const maxFrameTime = 250 * time.Millisecond
+
+// fpsSmoothing is how much a new frame time counts in the smoothed frame time, range (0.0, 1.0].
+const fpsSmoothing = 0.1
+
+// Loop is a fixed time step game loop. Each frame, Frame runs the simulation ticks for the time passed since the
+// previous frame: the simulation always moves by the same time step, whatever the frame rate. The time left over (less
+// than a tick) is carried to the next frame, and given as the fraction of a tick to interpolate the rendering with.
+//
+// A Loop is driven by one goroutine, it is not safe for concurrent use.
+type Loop struct {
+	clock Clock
+	tick  time.Duration
+
+	started           bool
+	previousFrame     time.Time
+	accumulated       time.Duration // Time passed that is not simulated yet, less than a tick after a frame
+	ticks             uint64
+	frames            uint64 // Frames timed, not counting the first one
+	frameTime         time.Duration
+	smoothedFrameTime float64 // Seconds
+}
+
+// New creates a game loop with a number of simulation ticks per second (see DefaultTickRate), taking the time from a
+// clock (see SystemClock).
+func New(tickRate float64, clock Clock) *Loop {
+	return &Loop{clock: clock, tick: time.Duration(float64(time.Second) / tickRate)}
+}
+
+// TickDuration gives the (simulated) time of one tick.
+func (l *Loop) TickDuration() time.Duration {
+	return l.tick
+}
+
+// Frame runs the simulation ticks for the time passed since the previous frame, calling simulate with the tick time
+// step in seconds for each tick. It gives the fraction of a tick passed since the last tick, range [0.0, 1.0), to
+// interpolate what is rendered between the state before and after the last tick.
+//
+// The first frame only starts the clock, it runs no ticks.
+func (l *Loop) Frame(simulate func(dt float64)) (alpha float64) {
+	now := l.clock.Now()
+	if !l.started {
+		l.started = true
+		l.previousFrame = now
+		return 0.0
+	}
+
+	l.frameTime = now.Sub(l.previousFrame)
+	l.previousFrame = now
+	if l.frames == 0 {
+		l.smoothedFrameTime = l.frameTime.Seconds()
+	} else {
+		l.smoothedFrameTime += (l.frameTime.Seconds() - l.smoothedFrameTime) * fpsSmoothing
+	}
+	l.frames++
+
+	l.accumulated += min(l.frameTime, maxFrameTime)
+	dt := l.tick.Seconds()
+	for l.accumulated >= l.tick {
+		simulate(dt)
+		l.accumulated -= l.tick
+		l.ticks++
+	}
+
+	return float64(l.accumulated) / float64(l.tick)
+}
+
+// Ticks gives the number of simulation ticks run.
+func (l *Loop) Ticks() uint64 {
+	return l.ticks
+}
+
+// FrameTime gives the time between the two latest frames.
+func (l *Loop) FrameTime() time.Duration {
+	return l.frameTime
+}
+
+// FPS gives the frame rate (frames per second), smoothed over the latest frames. It is zero before the second frame.
+func (l *Loop) FPS() float64 {
+	if l.smoothedFrameTime <= 0.0 {
+		return 0.0
+	}
+
+	return 1.0 / l.smoothedFrameTime
+}
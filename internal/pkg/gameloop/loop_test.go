@@ -0,0 +1,88 @@
+package gameloop
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLoopFrame(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	loop := New(100.0, clock)
+	assert.Equal(t, 10*time.Millisecond, loop.TickDuration())
+
+	simulated := 0.0
+	simulate := func(dt float64) {
+		assert.Equal(t, 0.01, dt, "always the same time step")
+		simulated += dt
+	}
+
+	assert.Equal(t, 0.0, loop.Frame(simulate), "the first frame starts the clock")
+	assert.Equal(t, uint64(0), loop.Ticks())
+
+	clock.Advance(25 * time.Millisecond)
+	assert.InDelta(t, 0.5, loop.Frame(simulate), 0.000001, "half a tick left")
+	assert.Equal(t, uint64(2), loop.Ticks())
+
+	clock.Advance(5 * time.Millisecond)
+	assert.InDelta(t, 0.0, loop.Frame(simulate), 0.000001, "the time left is carried over")
+	assert.Equal(t, uint64(3), loop.Ticks())
+
+	clock.Advance(3 * time.Millisecond)
+	assert.InDelta(t, 0.3, loop.Frame(simulate), 0.000001, "no tick in a short frame")
+	assert.Equal(t, uint64(3), loop.Ticks())
+	assert.InDelta(t, 0.03, simulated, 0.000001)
+}
+
+func TestLoopFrameRateIndependent(t *testing.T) {
+	// The same time passed gives the same ticks, rendered in few long frames or in many short ones
+	ticks := func(frameTime time.Duration) uint64 {
+		clock := NewManualClock(time.Unix(0, 0))
+		loop := New(DefaultTickRate, clock)
+		loop.Frame(func(float64) {})
+		for passed := time.Duration(0); passed < time.Second; passed += frameTime {
+			clock.Advance(frameTime)
+			loop.Frame(func(float64) {})
+		}
+		return loop.Ticks()
+	}
+
+	assert.Equal(t, uint64(70), ticks(time.Millisecond))
+	assert.Equal(t, uint64(70), ticks(20*time.Millisecond))
+	assert.Equal(t, uint64(70), ticks(100*time.Millisecond))
+}
+
+func TestLoopFrameAfterHiccup(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	loop := New(100.0, clock)
+	loop.Frame(func(float64) {})
+
+	clock.Advance(10 * time.Second)
+	loop.Frame(func(float64) {})
+	assert.Equal(t, uint64(25), loop.Ticks(), "no catching up with a long pause")
+	assert.Equal(t, 10*time.Second, loop.FrameTime())
+}
+
+func TestLoopFPS(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	loop := New(DefaultTickRate, clock)
+	assert.Equal(t, 0.0, loop.FPS())
+
+	loop.Frame(func(float64) {})
+	assert.Equal(t, 0.0, loop.FPS(), "unknown before the second frame")
+
+	clock.Advance(20 * time.Millisecond)
+	loop.Frame(func(float64) {})
+	assert.InDelta(t, 50.0, loop.FPS(), 0.01, "from the second frame")
+
+	for frame := 0; frame < 200; frame++ {
+		clock.Advance(20 * time.Millisecond)
+		loop.Frame(func(float64) {})
+	}
+	assert.InDelta(t, 50.0, loop.FPS(), 0.01)
+	assert.Equal(t, 20*time.Millisecond, loop.FrameTime())
+
+	clock.Advance(0)
+	loop.Frame(func(float64) {})
+	assert.False(t, loop.FPS() > 1000.0, "a frame without time passed is not infinitely fast")
+}
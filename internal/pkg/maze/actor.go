@@ -0,0 +1,135 @@
+package maze
+
+import (
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"maze/internal/pkg/wolf3d"
+)
+
+// ActorRadius is the radius of an actor.
+const ActorRadius = 0.25
+
+// corpseSpecials are the names of the specials (see raycastmap.TileTable.SpecialNamed) the corpses of the actors look
+// like. Actors of other kinds leave no corpse.
+var corpseSpecials = map[wolf3d.EnemyKind]string{
+	wolf3d.EnemyGuard: "DeadGuard",
+	wolf3d.EnemyDog:   "DeadDog",
+}
+
+// ActorState is what an actor is doing.
+type ActorState int
+
+const (
+	ActorStanding   ActorState = iota // Standing still, until it notices the player
+	ActorPatrolling                   // Walking a patrol route, until it notices the player
+	ActorChasing                      // Going after the player
+	ActorAttacking                    // Shooting at (or biting) the player
+	ActorDead                         // Dead, a corpse
+)
+
+func (s ActorState) String() string {
+	return [...]string{"standing", "patrolling", "chasing", "attacking", "dead"}[s]
+}
+
+// Actor is a creature in the world that acts on its own, like a guard or a dog.
+//
+// Actors behave like in the original game. Standing and patrolling actors notice the player when they see the player
+// (in front of them) or hear a noise (see World.MakeNoise), and after a short reaction time start chasing the player.
+// Patrolling actors walk from map cell to map cell, turning where the map has a patrol turning point. Chasing actors
+// walk the cheapest way to the player, opening doors on the way, and now and then attack: guards shoot, with less
+// chance to hit the further away the player is, and dogs bite when next to the player.
+//
+// Actors shot (or stabbed) by the player lose health, and die when they have none left: the actor becomes a corpse,
+// and drops ammo.
+type Actor struct {
+	Creature
+	ID        int              // Index of the actor in World.Actors
+	Kind      wolf3d.EnemyKind // What kind of actor
+	State     ActorState
+	Health    int                   // Health points, zero for a dead actor
+	Structure *raycastmap.Structure // How the actor looks (its sprite)
+
+	direction wolf3d.Direction // Direction the actor walks in (or noDirection)
+	walking   bool             // If the actor is walking to the middle of the next map cell
+	target    Vector           // Middle of the map cell the actor is walking to
+
+	noticed  bool    // If the actor has noticed the player, and is about to react
+	reaction float64 // Seconds left before the actor reacts to having noticed the player
+
+	attackTime float64 // Seconds into the current attack
+	attacked   bool    // If the current attack has been made (shot or bite)
+}
+
+// NewActor creates an actor of a kind in a state, in the middle of map cell x, y with a heading.
+func NewActor(kind wolf3d.EnemyKind, state ActorState, x, y int, heading float64, structure *raycastmap.Structure) *Actor {
+	return &Actor{
+		Creature:  Creature{Position: Vector{X: float64(x) + 0.5, Y: float64(y) + 0.5}, Heading: heading, Radius: ActorRadius},
+		Kind:      kind,
+		State:     state,
+		Health:    actorHealth(kind),
+		Structure: structure,
+		direction: wolf3d.Direction(int(math.Round(heading/(math.Pi/4.0))) & 7),
+	}
+}
+
+// IsAlive reports if the actor is not dead.
+func (a *Actor) IsAlive() bool {
+	return a.State != ActorDead
+}
+
+// Update runs the actor for one simulation tick of dt seconds.
+func (a *Actor) Update(world *World, dt float64) {
+	switch a.State {
+	case ActorStanding:
+		a.lookForPlayer(world, dt)
+	case ActorPatrolling:
+		if !a.lookForPlayer(world, dt) {
+			a.walk(world, a.patrolSpeed()*dt, a.selectPathDirection)
+		}
+	case ActorChasing:
+		a.chase(world, dt)
+	case ActorAttacking:
+		a.attack(world, dt)
+	}
+}
+
+// Damage takes health points from the actor. An actor that is not chasing (or attacking) the player yet takes double
+// damage, and starts chasing the player right away. An actor without health left dies.
+func (a *Actor) Damage(world *World, points int) {
+	if !a.IsAlive() {
+		return
+	}
+
+	if a.State == ActorStanding || a.State == ActorPatrolling {
+		points *= 2
+		a.noticed = false
+		a.State = ActorChasing
+	}
+
+	a.Health -= points
+	if a.Health <= 0 {
+		a.die(world)
+	}
+}
+
+// die makes the actor a corpse (or makes it disappear, for kinds without a corpse), dropping what it carries in its
+// map cell: guards, officers and mutants drop a clip, an SS drops a machine gun if the player does not have one yet.
+func (a *Actor) die(world *World) {
+	a.Health = 0
+	a.State = ActorDead
+	a.walking = false
+	a.noticed = false
+	a.Structure = world.special(corpseSpecials[a.Kind])
+
+	x, y := a.Cell()
+	switch a.Kind {
+	case wolf3d.EnemyGuard, wolf3d.EnemyOfficer, wolf3d.EnemyMutant:
+		world.Drop(wolf3d.PickupClip, x, y)
+	case wolf3d.EnemySS:
+		if world.Player.BestWeapon < WeaponMachineGun {
+			world.Drop(wolf3d.PickupMachineGun, x, y)
+		} else {
+			world.Drop(wolf3d.PickupClip, x, y)
+		}
+	}
+}
@@ -0,0 +1,448 @@
+package maze
+
+import (
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"maze/internal/pkg/wolf3d"
+)
+
+// The behaviour of the actors follows the original game. The original game runs 70 ticks per second and measures
+// distances in 1/65536 map cells, the speeds and times are the original ones converted.
+
+const originalTickRate = 70.0
+
+const (
+	sightDistance = 1.5 // Distance (along x and y, in map cells) an actor sees the player within, even behind it
+	biteDistance  = 1.5 // Distance (along x and y, in map cells) a dog bites the player within
+
+	// timeMargin is the rounding margin when comparing times added up from ticks.
+	timeMargin = 0.000001
+)
+
+var (
+	patrolSpeed    = originalSpeed(512.0)
+	dogPatrolSpeed = originalSpeed(1500.0)
+)
+
+// noDirection is the direction of an actor that does not walk anywhere.
+const noDirection wolf3d.Direction = -1
+
+// directionSteps are the map cell steps (x, y) for each direction.
+var directionSteps = [8][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+
+// originalSpeed converts an original speed (1/65536 map cells per tick) to map cells per second.
+func originalSpeed(speed float64) float64 {
+	return speed * originalTickRate / 65536.0
+}
+
+// originalTicks converts original ticks to seconds.
+func originalTicks(ticks float64) float64 {
+	return ticks / originalTickRate
+}
+
+// patrolSpeed gives the speed (map cells per second) the actor walks a patrol route with.
+func (a *Actor) patrolSpeed() float64 {
+	if a.Kind == wolf3d.EnemyDog {
+		return dogPatrolSpeed
+	}
+	return patrolSpeed
+}
+
+// chaseSpeed gives the speed (map cells per second) the actor chases the player with.
+func (a *Actor) chaseSpeed() float64 {
+	switch a.Kind {
+	case wolf3d.EnemyDog:
+		return dogPatrolSpeed * 2.0
+	case wolf3d.EnemyOfficer:
+		return patrolSpeed * 5.0
+	case wolf3d.EnemySS:
+		return patrolSpeed * 4.0
+	default:
+		return patrolSpeed * 3.0
+	}
+}
+
+// reactionTime gives the time (seconds) the actor takes to react to having noticed the player.
+func (a *Actor) reactionTime(world *World) float64 {
+	switch a.Kind {
+	case wolf3d.EnemyGuard:
+		return originalTicks(1.0 + float64(world.random()/4))
+	case wolf3d.EnemyOfficer:
+		return originalTicks(2.0)
+	case wolf3d.EnemySS, wolf3d.EnemyMutant:
+		return originalTicks(1.0 + float64(world.random()/6))
+	case wolf3d.EnemyDog:
+		return originalTicks(1.0 + float64(world.random()/8))
+	default:
+		return originalTicks(1.0)
+	}
+}
+
+// actorHealth gives the health an actor of a kind starts with, like in the original game (the hard difficulty).
+// Bosses (and ghosts) start with a round 1000.
+func actorHealth(kind wolf3d.EnemyKind) int {
+	switch kind {
+	case wolf3d.EnemyGuard:
+		return 25
+	case wolf3d.EnemyOfficer:
+		return 50
+	case wolf3d.EnemySS:
+		return 100
+	case wolf3d.EnemyDog:
+		return 1
+	case wolf3d.EnemyMutant:
+		return 55
+	default:
+		return 1000
+	}
+}
+
+// attackTiming gives when (seconds into an attack) the actor shoots or bites, and how long an attack takes.
+func (a *Actor) attackTiming() (strike float64, duration float64) {
+	switch a.Kind {
+	case wolf3d.EnemyDog:
+		return originalTicks(20.0), originalTicks(50.0)
+	case wolf3d.EnemyOfficer, wolf3d.EnemyMutant:
+		return originalTicks(6.0), originalTicks(36.0)
+	default:
+		return originalTicks(20.0), originalTicks(60.0)
+	}
+}
+
+// lookForPlayer looks for the player, and starts chasing the player once the reaction time after noticing the player
+// (by sight or by noise) has passed. Gives true when the actor starts chasing.
+func (a *Actor) lookForPlayer(world *World, dt float64) bool {
+	if !a.noticed {
+		if a.seesPlayer(world) {
+			a.notice(world)
+		}
+		return false
+	}
+
+	a.reaction -= dt
+	if a.reaction > timeMargin {
+		return false
+	}
+
+	a.noticed = false
+	a.State = ActorChasing
+	return true
+}
+
+// notice makes a standing or patrolling actor notice the player, it starts chasing after its reaction time.
+func (a *Actor) notice(world *World) {
+	if a.noticed || (a.State != ActorStanding && a.State != ActorPatrolling) {
+		return
+	}
+
+	a.noticed = true
+	a.reaction = a.reactionTime(world)
+}
+
+// seesPlayer reports if the actor sees the player: nothing is in the way, and the player is in front of the actor
+// (or close).
+func (a *Actor) seesPlayer(world *World) bool {
+	player := &world.Player.Position
+	if !LineOfSight(a.Position, *player, world.Map) {
+		return false
+	}
+
+	dx, dy := player.X-a.Position.X, player.Y-a.Position.Y
+	if math.Abs(dx) < sightDistance && math.Abs(dy) < sightDistance {
+		return true
+	}
+
+	direction := a.Direction()
+	return dx*direction.X+dy*direction.Y >= 0.0
+}
+
+// chase goes after the player, and now and then attacks.
+func (a *Actor) chase(world *World, dt float64) {
+	if a.decidesToAttack(world, dt) {
+		a.State = ActorAttacking
+		a.attackTime = 0.0
+		a.attacked = false
+		return
+	}
+
+	a.walk(world, a.chaseSpeed()*dt, a.selectChaseDirection)
+}
+
+// decidesToAttack reports if a chasing actor attacks the player this tick. A dog bites the player when next to the
+// player. A guard shoots when it has a clear shot, with a chance that gets smaller the further away the player is.
+// A dead player is not attacked.
+func (a *Actor) decidesToAttack(world *World, dt float64) bool {
+	player := world.Player
+	if !player.IsAlive() || !LineOfSight(a.Position, player.Position, world.Map) {
+		return false
+	}
+
+	if a.Kind == wolf3d.EnemyDog {
+		return a.inBiteDistance(player)
+	}
+
+	distance := a.cellDistance(player)
+	if distance == 0 {
+		return true
+	}
+
+	ticks := dt * originalTickRate
+	return float64(world.random()) < 16.0*ticks/float64(distance)
+}
+
+// attack shoots at (or bites) the player, and goes on chasing when the attack is over.
+func (a *Actor) attack(world *World, dt float64) {
+	player := world.Player
+	a.Heading = math.Atan2(player.Position.Y-a.Position.Y, player.Position.X-a.Position.X)
+	if a.Heading < 0.0 {
+		a.Heading += math.Pi * 2.0
+	}
+
+	strike, duration := a.attackTiming()
+	a.attackTime += dt
+	if !a.attacked && a.attackTime >= strike-timeMargin {
+		a.attacked = true
+		if a.Kind == wolf3d.EnemyDog {
+			a.bite(world)
+		} else {
+			a.shoot(world)
+		}
+	}
+
+	if a.attackTime >= duration-timeMargin {
+		a.State = ActorChasing
+	}
+}
+
+// shoot shoots at the player. The further away the player is, the smaller the chance to hit and the less damage a hit
+// does. A player that has the actor in view has a smaller chance to be hit (as if ducking).
+func (a *Actor) shoot(world *World) {
+	player := world.Player
+	if !player.IsAlive() || !LineOfSight(a.Position, player.Position, world.Map) {
+		return
+	}
+
+	distance := a.cellDistance(player)
+	hitChance := 256 - distance*8
+	if playerFaces(player, a) {
+		hitChance = 256 - distance*16
+	}
+	if world.random() >= hitChance {
+		return
+	}
+
+	switch {
+	case distance < 2:
+		player.Damage(world.random() >> 2)
+	case distance < 4:
+		player.Damage(world.random() >> 3)
+	default:
+		player.Damage(world.random() >> 4)
+	}
+}
+
+// bite bites the player, if the player is still next to the dog.
+func (a *Actor) bite(world *World) {
+	player := world.Player
+	if !player.IsAlive() || !a.inBiteDistance(player) || world.random() >= 180 {
+		return
+	}
+
+	player.Damage(world.random() >> 4)
+}
+
+// inBiteDistance reports if the player is close enough to be bitten.
+func (a *Actor) inBiteDistance(player *Player) bool {
+	return math.Abs(player.Position.X-a.Position.X) <= biteDistance && math.Abs(player.Position.Y-a.Position.Y) <= biteDistance
+}
+
+// cellDistance gives the distance in map cells (along x or y, whichever is further) between the actor and the player.
+func (a *Actor) cellDistance(player *Player) int {
+	x, y := a.Cell()
+	playerX, playerY := player.Cell()
+	return max(abs(playerX-x), abs(playerY-y))
+}
+
+// playerFaces reports if the actor is in the view of the player.
+func playerFaces(player *Player, actor *Actor) bool {
+	toActor := actor.Position.Sub(&player.Position)
+	length := toActor.Length()
+	if length == 0.0 {
+		return true
+	}
+
+	direction := player.Direction()
+	return (toActor.X*direction.X+toActor.Y*direction.Y)/length >= math.Cos(DefaultFOV/2.0*math.Pi/180.0)
+}
+
+// walk walks the actor a distance from map cell middle to map cell middle, choosing the direction to walk in with
+// selectDirection in the middle of each map cell. Closed doors on the way are opened, and waited for.
+func (a *Actor) walk(world *World, distance float64, selectDirection func(world *World) wolf3d.Direction) {
+	for distance > 0.0 {
+		if !a.walking {
+			direction := selectDirection(world)
+			if direction == noDirection {
+				return
+			}
+
+			x, y := a.Cell()
+			step := directionSteps[direction]
+			a.direction = direction
+			a.Heading = direction.Angle()
+			a.target = Vector{X: float64(x+step[0]) + 0.5, Y: float64(y+step[1]) + 0.5}
+			a.walking = true
+		}
+
+		if door := world.Map.DoorAt(int(a.target.X), int(a.target.Y)); door != nil && door.IsBlocking() {
+			door.Open()
+			return
+		}
+
+		toTarget := a.target.Sub(&a.Position)
+		length := toTarget.Length()
+		if length > distance {
+			a.Position = *a.Position.Add(toTarget.Scale(distance / length))
+			return
+		}
+
+		a.Position = a.target
+		a.walking = false
+		distance -= length
+	}
+}
+
+// canWalk reports if the actor can walk in a direction, from the middle of its map cell to the middle of the next map
+// cell. Walls, obstacles, the player and other living actors are in the way. Doors are not (the actor opens them),
+// but doors are never walked through diagonally. Walking diagonally around the corner of a wall or obstacle is not
+// possible either.
+func (a *Actor) canWalk(world *World, direction wolf3d.Direction) bool {
+	x, y := a.Cell()
+	step := directionSteps[direction]
+	toX, toY := x+step[0], y+step[1]
+
+	diagonal := step[0] != 0 && step[1] != 0
+	if diagonal && (!walkableCell(world.Map, toX, y, false) || !walkableCell(world.Map, x, toY, false)) {
+		return false
+	}
+	if !walkableCell(world.Map, toX, toY, !diagonal) || world.Player.InCell(toX, toY) {
+		return false
+	}
+
+	for _, other := range world.Actors {
+		if other != a && other.IsAlive() && other.claims(toX, toY) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// claims reports if the actor is in, or walking to, map cell x, y.
+func (a *Actor) claims(x, y int) bool {
+	cellX, cellY := a.Cell()
+	if cellX == x && cellY == y {
+		return true
+	}
+
+	return a.walking && int(a.target.X) == x && int(a.target.Y) == y
+}
+
+// selectPathDirection selects the direction to walk a patrol route in: on in the same direction, or in the direction
+// of the patrol turning point in the map cell.
+func (a *Actor) selectPathDirection(world *World) wolf3d.Direction {
+	x, y := a.Cell()
+	if direction, ok := world.turningPoints[[2]int{x, y}]; ok {
+		a.direction = direction
+	}
+
+	if a.direction == noDirection || !a.canWalk(world, a.direction) {
+		return noDirection // Wait until the way is free
+	}
+
+	return a.direction
+}
+
+// selectChaseDirection selects the direction to walk in towards the player: the cheapest way to the player (see
+// World.ChaseField). If another actor is in the way, the direct way is tried like in the original game (first along
+// the axis the player is furthest away along), then on in the same direction, then the other directions. Turning
+// around is the last resort.
+func (a *Actor) selectChaseDirection(world *World) wolf3d.Direction {
+	x, y := a.Cell()
+	if next, ok := world.ChaseField().Next(x, y); ok {
+		direction := directionTo(next.X-x, next.Y-y)
+		if a.canWalk(world, direction) {
+			return direction
+		}
+	}
+
+	playerX, playerY := world.Player.Cell()
+	deltaX, deltaY := playerX-x, playerY-y
+
+	towardsX, towardsY := noDirection, noDirection
+	if deltaX > 0 {
+		towardsX = wolf3d.DirectionEast
+	} else if deltaX < 0 {
+		towardsX = wolf3d.DirectionWest
+	}
+	if deltaY > 0 {
+		towardsY = wolf3d.DirectionNorth
+	} else if deltaY < 0 {
+		towardsY = wolf3d.DirectionSouth
+	}
+	if abs(deltaY) > abs(deltaX) {
+		towardsX, towardsY = towardsY, towardsX
+	}
+
+	turnaround := noDirection
+	if a.direction != noDirection {
+		turnaround = (a.direction + 4) & 7
+	}
+
+	search := []wolf3d.Direction{wolf3d.DirectionNorth, wolf3d.DirectionEast, wolf3d.DirectionSouth, wolf3d.DirectionWest}
+	if world.random() > 128 {
+		search = []wolf3d.Direction{wolf3d.DirectionWest, wolf3d.DirectionSouth, wolf3d.DirectionEast, wolf3d.DirectionNorth}
+	}
+
+	candidates := append([]wolf3d.Direction{towardsX, towardsY, a.direction}, search...)
+	for _, direction := range candidates {
+		if direction != noDirection && direction != turnaround && a.canWalk(world, direction) {
+			return direction
+		}
+	}
+
+	if turnaround != noDirection && a.canWalk(world, turnaround) {
+		return turnaround
+	}
+
+	return noDirection
+}
+
+// directionTo gives the direction of a map cell step.
+func directionTo(stepX, stepY int) wolf3d.Direction {
+	for direction, step := range directionSteps {
+		if step[0] == stepX && step[1] == stepY {
+			return wolf3d.Direction(direction)
+		}
+	}
+	return noDirection
+}
+
+// walkableCell reports if map cell x, y can be walked into: inside the map, no wall or obstacle, and a door cell only
+// if throughDoors.
+func walkableCell(worldMap raycastmap.Map, x, y int, throughDoors bool) bool {
+	if x < 0 || y < 0 || x >= worldMap.Width() || y >= worldMap.Height() {
+		return false
+	}
+	if worldMap.DoorAt(x, y) != nil {
+		return throughDoors
+	}
+
+	return !worldMap.ObstacleAt(x, y)
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,250 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"maze/internal/pkg/wolf3d"
+	"testing"
+)
+
+const testTick = 1.0 / 70.0
+
+// runTicks updates a world (and its doors, if any) for a number of ticks.
+func runTicks(world *World, doors *raycastmap.Doors, ticks int) {
+	for tick := 0; tick < ticks; tick++ {
+		world.Update(testTick)
+		if doors != nil {
+			doors.Update(testTick, world.Occupied)
+		}
+	}
+}
+
+func TestActorNoticesPlayerBySight(t *testing.T) {
+	room := raycastmap.NewGridMap(
+		"##########",
+		"#........#",
+		"#........#",
+		"#....#...#",
+		"##########",
+	)
+
+	type testCase struct {
+		name    string
+		player  Vector
+		heading float64
+		notices bool
+	}
+
+	testCases := []testCase{
+		{name: "in front", player: Vector{X: 1.5, Y: 2.5}, heading: math.Pi, notices: true},
+		{name: "to the side", player: Vector{X: 1.5, Y: 3.5}, heading: math.Pi / 2.0, notices: true},
+		{name: "behind", player: Vector{X: 1.5, Y: 2.5}, heading: 0.0, notices: false},
+		{name: "close behind", player: Vector{X: 6.5, Y: 3.5}, heading: 0.0, notices: true},
+		{name: "behind a wall", player: Vector{X: 3.5, Y: 1.5}, heading: math.Pi, notices: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			world := NewWorld(room, wolf3d.DifficultyHard)
+			world.Player.Position = tc.player
+			guard := world.Spawn(NewActor(wolf3d.EnemyGuard, ActorStanding, 7, 2, tc.heading, raycastmap.StructureNone))
+
+			runTicks(world, nil, 1)
+			assert.Equal(t, ActorStanding, guard.State, "reacts after the reaction time")
+
+			runTicks(world, nil, 70)
+			if tc.notices {
+				assert.NotEqual(t, ActorStanding, guard.State)
+			} else {
+				assert.Equal(t, ActorStanding, guard.State)
+			}
+		})
+	}
+}
+
+func TestActorNoticesPlayerByNoise(t *testing.T) {
+	// Two rooms with a door in between
+	rooms := raycastmap.NewGridMap(
+		"#########",
+		"#...#...#",
+		"#...|...#",
+		"#...#...#",
+		"#########",
+	)
+	door := rooms.DoorAt(4, 2)
+
+	world := NewWorld(rooms, wolf3d.DifficultyHard)
+	world.Player.Position = Vector{X: 1.5, Y: 1.5}
+	near := world.Spawn(NewActor(wolf3d.EnemyGuard, ActorStanding, 3, 3, 0.0, raycastmap.StructureNone))
+	behindTheDoor := world.Spawn(NewActor(wolf3d.EnemyDog, ActorPatrolling, 6, 2, 0.0, raycastmap.StructureNone))
+
+	runTicks(world, nil, 70)
+	assert.Equal(t, ActorStanding, near.State, "the player is behind the guard")
+
+	world.MakeNoise(1, 1)
+	runTicks(world, nil, 70)
+	assert.Equal(t, ActorChasing, near.State)
+	assert.Equal(t, ActorPatrolling, behindTheDoor.State, "the closed door keeps the noise out")
+
+	door.Open()
+	world.MakeNoise(1, 1)
+	runTicks(world, nil, 1)
+	assert.Equal(t, ActorPatrolling, behindTheDoor.State, "reacts after the reaction time")
+	runTicks(world, nil, 70)
+	assert.NotEqual(t, ActorPatrolling, behindTheDoor.State, "heard through the opening door")
+}
+
+func TestActorPatrol(t *testing.T) {
+	// The player is out of sight, walled in at the top right
+	room := raycastmap.NewGridMap(
+		"#########",
+		"#.....#.#",
+		"#.....###",
+		"#.....###",
+		"#.....###",
+		"#########",
+	)
+	patrolMap := objectTestMap{SliceMap: room.SliceMap, objects: map[raycastmap.Cell]wolf3d.Object{
+		{X: 2, Y: 1}: {Kind: wolf3d.ObjectEnemy, Enemy: wolf3d.EnemyGuard, Direction: wolf3d.DirectionEast, Patrol: true},
+		{X: 5, Y: 1}: {Kind: wolf3d.ObjectTurningPoint, Direction: wolf3d.DirectionNorth},
+		{X: 5, Y: 4}: {Kind: wolf3d.ObjectTurningPoint, Direction: wolf3d.DirectionWest},
+		{X: 1, Y: 4}: {Kind: wolf3d.ObjectTurningPoint, Direction: wolf3d.DirectionSouth},
+		{X: 1, Y: 1}: {Kind: wolf3d.ObjectTurningPoint, Direction: wolf3d.DirectionEast},
+	}}
+
+	world := NewWorld(patrolMap, wolf3d.DifficultyHard)
+	world.Player.Position = Vector{X: 7.5, Y: 4.5}
+	guard := world.Actors[0]
+
+	runTicks(world, nil, 700)
+	distance := 10.0 * patrolSpeed
+	assert.InDelta(t, 5.5, guard.Position.X, 0.000001, "turned north at the first turning point")
+	assert.InDelta(t, 1.5+distance-3.0, guard.Position.Y, 0.001)
+	assert.Equal(t, math.Pi/2.0, guard.Heading)
+
+	// Round the room (14 map cells) back to the start
+	runTicks(world, nil, int(math.Round(14.0/patrolSpeed*70.0))-700)
+	assert.InDelta(t, 2.5, guard.Position.X, 0.01)
+	assert.InDelta(t, 1.5, guard.Position.Y, 0.000001)
+	assert.Equal(t, ActorPatrolling, guard.State)
+}
+
+func TestActorChaseThroughDoor(t *testing.T) {
+	corridor := raycastmap.NewGridMap(
+		"############",
+		"#....|.....#",
+		"############",
+	)
+	doors := corridor.Doors()
+	door := corridor.DoorAt(5, 1)
+
+	world := NewWorld(corridor, wolf3d.DifficultyHard)
+	world.Player.Position = Vector{X: 10.5, Y: 1.5}
+	dog := world.Spawn(NewActor(wolf3d.EnemyDog, ActorChasing, 1, 1, 0.0, raycastmap.StructureNone))
+
+	runTicks(world, doors, 70)
+	assert.Equal(t, Vector{X: 4.5, Y: 1.5}, dog.Position, "waiting for the door to open")
+	assert.Equal(t, raycastmap.DoorOpening, door.State)
+
+	runTicks(world, doors, 140)
+	assert.Greater(t, dog.Position.X, 6.5, "through the door")
+	x, _ := dog.Cell()
+	assert.Less(t, x, 10, "never in the cell of the player")
+
+	runTicks(world, doors, 700)
+	assert.Less(t, world.Player.Health, PlayerHealth, "bitten")
+}
+
+func TestActorChaseAroundWalls(t *testing.T) {
+	// The player is straight north of the dog, the way there is around the walls
+	maze := raycastmap.NewGridMap(
+		"#########",
+		"#.......#",
+		"#.##.##.#",
+		"#.#...#.#",
+		"#.#####.#",
+		"#.......#",
+		"#########",
+	)
+
+	world := NewWorld(maze, wolf3d.DifficultyHard)
+	world.Player.Position = Vector{X: 4.5, Y: 3.5}
+	dog := world.Spawn(NewActor(wolf3d.EnemyDog, ActorChasing, 4, 1, math.Pi/2.0, raycastmap.StructureNone))
+
+	runTicks(world, nil, 700)
+	assert.True(t, dog.inBiteDistance(world.Player), "found the way around")
+	assert.Less(t, world.Player.Health, PlayerHealth)
+}
+
+func TestActorShoots(t *testing.T) {
+	room := raycastmap.NewGridMap(
+		"#########",
+		"#.......#",
+		"#########",
+	)
+
+	world := NewWorld(room, wolf3d.DifficultyHard)
+	world.Player.Position = Vector{X: 1.5, Y: 1.5}
+	world.Player.Heading = math.Pi // Facing away
+	guard := world.Spawn(NewActor(wolf3d.EnemyGuard, ActorChasing, 7, 1, math.Pi, raycastmap.StructureNone))
+
+	attacks := 0
+	for tick := 0; tick < 700; tick++ {
+		previous := guard.State
+		runTicks(world, nil, 1)
+		if guard.State == ActorAttacking && previous != ActorAttacking {
+			attacks++
+		}
+	}
+
+	assert.Positive(t, attacks)
+	assert.Less(t, world.Player.Health, PlayerHealth, "hit")
+
+	t.Run("no shooting through walls", func(t *testing.T) {
+		world.Player.Health = PlayerHealth
+		world.Player.Position = Vector{X: 1.5, Y: 1.5}
+		guard.State = ActorAttacking
+		guard.attackTime = 0.0
+		guard.attacked = false
+		guard.Position = Vector{X: 1.5, Y: 0.5} // Inside the wall below
+
+		for tick := 0; tick < 70; tick++ {
+			guard.attack(world, testTick)
+		}
+		assert.Equal(t, PlayerHealth, world.Player.Health)
+	})
+}
+
+func TestPlayerDies(t *testing.T) {
+	room := raycastmap.NewGridMap(
+		"#######",
+		"#.....#",
+		"#######",
+	)
+
+	world := NewWorld(room, wolf3d.DifficultyHard)
+	world.Player.Health = 1
+	guard := world.Spawn(NewActor(wolf3d.EnemyGuard, ActorChasing, 3, 1, math.Pi, raycastmap.StructureNone))
+	for tick := 0; tick < 700 && world.Player.IsAlive(); tick++ {
+		runTicks(world, nil, 1)
+	}
+	assert.False(t, world.Player.IsAlive(), "shot")
+	assert.Equal(t, 0, world.Player.Health)
+
+	runTicks(world, nil, 70) // The attack that shot the player ends
+	assert.Equal(t, ActorChasing, guard.State)
+
+	clip := world.Drop(wolf3d.PickupClip, 1, 1)
+	world.Player.Trigger = true
+	attacks := 0
+	for tick := 0; tick < 700; tick++ {
+		runTicks(world, nil, 1)
+		if guard.State == ActorAttacking {
+			attacks++
+		}
+	}
+	assert.Zero(t, attacks, "a dead player is not attacked")
+	assert.Equal(t, PlayerAmmo, world.Player.Ammo, "a dead player does not shoot")
+	assert.False(t, clip.Taken, "a dead player does not pick up items")
+}
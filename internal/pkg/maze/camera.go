@@ -0,0 +1,107 @@
+package maze
+
+import "math"
+
+const (
+	DefaultFOV       = 66.0 // Default horizontal field of view in degrees (at the reference aspect ratio)
+	DefaultEyeHeight = 0.5  // Default eye height, in the middle between floor and ceiling
+
+	// ReferenceAspectRatio is the aspect ratio (width/height) the horizontal field of view is given for.
+	// Wider screens show more to the sides ("Hor+"), narrower screens show less, the vertical field of view stays the same.
+	ReferenceAspectRatio = 4.0 / 3.0
+)
+
+// Camera is the observer point of view.
+type Camera struct {
+	Position  Vector  // Position in the map
+	Heading   float64 // View direction angle in radians, 0.0 is east and π/2 is north
+	FOV       float64 // Horizontal field of view in degrees at the reference aspect ratio. Zero means DefaultFOV.
+	EyeHeight float64 // Eye height above the floor in wall heights, range (0.0, 1.0). Zero means DefaultEyeHeight.
+	Pitch     float64 // Vertical look angle in radians, positive is looking up. Done by y-shearing, so keep it small.
+
+	// MaxDistance is the (perpendicular) distance rays are cast, nothing further away is seen. Zero means no maximum.
+	// Set it where the fog is full (see raycastmap.Fog), so rays stop early in large maps.
+	MaxDistance float64
+}
+
+// NewCamera creates a camera at a position with a heading, using the default field of view and eye height.
+func NewCamera(position Vector, heading float64) Camera {
+	return Camera{Position: position, Heading: heading, FOV: DefaultFOV, EyeHeight: DefaultEyeHeight}
+}
+
+// Projection is how a camera projects the maze onto a screen (image) of a certain size.
+//
+// Pixels are square: a wall (height 1.0) at perpendicular distance d is FocalLength/d pixels high, and a wall
+// cell side (width 1.0) straight ahead at distance d is FocalLength/d pixels wide.
+type Projection struct {
+	Direction   Vector  // View direction, length 1.0
+	Plane       Vector  // Camera plane, perpendicular to the direction and pointing to the right. Its length is tan(horizontal FOV/2).
+	FocalLength float64 // Pixels per map unit at perpendicular distance 1.0
+	Horizon     float64 // Screen row of the horizon (at eye height), moved by the pitch
+	EyeHeight   float64 // Eye height above the floor in wall heights
+	MaxDistance float64 // Perpendicular distance rays are cast, zero means no maximum
+	Width       int     // Screen width in pixels
+	Height      int     // Screen height in pixels
+}
+
+// Projection gives the projection of the camera onto a screen of width x height pixels.
+func (c Camera) Projection(width int, height int) Projection {
+	fov := c.FOV
+	if fov <= 0.0 {
+		fov = DefaultFOV
+	}
+	eyeHeight := c.EyeHeight
+	if eyeHeight <= 0.0 {
+		eyeHeight = DefaultEyeHeight
+	}
+
+	// Hor+: the vertical field of view is fixed by the horizontal field of view at the reference aspect ratio
+	verticalHalfTan := math.Tan(fov*math.Pi/360.0) / ReferenceAspectRatio
+	horizontalHalfTan := verticalHalfTan * float64(width) / float64(height)
+	focalLength := float64(height) / 2.0 / verticalHalfTan
+
+	dirX := math.Cos(c.Heading)
+	dirY := math.Sin(c.Heading)
+
+	return Projection{
+		Direction:   Vector{X: dirX, Y: dirY},
+		Plane:       Vector{X: horizontalHalfTan * dirY, Y: horizontalHalfTan * -dirX},
+		FocalLength: focalLength,
+		Horizon:     float64(height)/2.0 + focalLength*math.Tan(c.Pitch),
+		EyeHeight:   eyeHeight,
+		MaxDistance: max(0.0, c.MaxDistance),
+		Width:       width,
+		Height:      height,
+	}
+}
+
+// RayDirection gives the direction of the ray through a pixel column. The direction is not normalized, its length
+// along the view direction is 1.0 so distances along it are perpendicular distances.
+func (p *Projection) RayDirection(pixelColumn int) Vector {
+	cameraX := 2.0*float64(pixelColumn)/float64(p.Width) - 1.0 // camera plane pos [-1, 1]
+	return Vector{
+		X: p.Direction.X + p.Plane.X*cameraX,
+		Y: p.Direction.Y + p.Plane.Y*cameraX,
+	}
+}
+
+// WallSpan gives the screen rows of the top (ceiling) and the bottom (floor) of a wall at a perpendicular distance.
+// The rows can be outside the screen.
+func (p *Projection) WallSpan(perpendicularDistance float64) (top float64, bottom float64) {
+	wallHeight := p.FocalLength / perpendicularDistance
+	top = p.Horizon - (1.0-p.EyeHeight)*wallHeight
+	return top, top + wallHeight
+}
+
+// RowDistance gives the perpendicular distance to the floor (below the horizon) or the ceiling (above the horizon)
+// seen in the middle of a screen row. The distance is infinite at the horizon.
+func (p *Projection) RowDistance(row int) float64 {
+	rowOffset := float64(row) + 0.5 - p.Horizon
+	if rowOffset > 0.0 {
+		return p.EyeHeight * p.FocalLength / rowOffset
+	}
+	if rowOffset < 0.0 {
+		return (1.0 - p.EyeHeight) * p.FocalLength / -rowOffset
+	}
+	return math.Inf(1)
+}
@@ -0,0 +1,89 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestCameraProjection(t *testing.T) {
+	camera := NewCamera(Vector{X: 2.5, Y: 3.5}, math.Pi/2.0)
+
+	t.Run("field of view at the reference aspect ratio", func(t *testing.T) {
+		projection := camera.Projection(640, 480)
+
+		assert.InDelta(t, 0.0, projection.Direction.X, 1e-9)
+		assert.InDelta(t, 1.0, projection.Direction.Y, 1e-9)
+		assert.InDelta(t, math.Tan(DefaultFOV*math.Pi/360.0), projection.Plane.Length(), 1e-9)
+		assert.Greater(t, projection.Plane.X, 0.0, "camera plane points to the right")
+		assert.InDelta(t, 320.0/math.Tan(DefaultFOV*math.Pi/360.0), projection.FocalLength, 1e-9)
+		assert.InDelta(t, 240.0, projection.Horizon, 1e-9)
+	})
+
+	t.Run("hor+ shows more on wider screens", func(t *testing.T) {
+		reference := camera.Projection(640, 480)
+		widescreen := camera.Projection(854, 480)
+
+		assert.InDelta(t, reference.FocalLength, widescreen.FocalLength, 1e-9, "the vertical field of view is the same")
+		assert.InDelta(t, reference.Plane.Length()*854.0/640.0, widescreen.Plane.Length(), 1e-9)
+	})
+
+	t.Run("pixels are square", func(t *testing.T) {
+		projection := camera.Projection(320, 200)
+
+		// A wall cell side straight ahead at distance 4 is as wide as it is high
+		left := projection.RayDirection(0)
+		pixelsPerUnit := float64(projection.Width) / (2.0 * projection.Plane.Length() * 4.0)
+		top, bottom := projection.WallSpan(4.0)
+		assert.InDelta(t, pixelsPerUnit, bottom-top, 1e-9)
+		assert.InDelta(t, 1.0, left.X*projection.Direction.X+left.Y*projection.Direction.Y, 1e-9)
+	})
+
+	t.Run("wider field of view makes walls smaller", func(t *testing.T) {
+		wide := camera
+		wide.FOV = 90.0
+
+		assert.Less(t, wide.Projection(320, 200).FocalLength, camera.Projection(320, 200).FocalLength)
+	})
+
+	t.Run("eye height moves the wall span", func(t *testing.T) {
+		low := camera
+		low.EyeHeight = 0.25
+		projection := low.Projection(320, 200)
+
+		top, bottom := projection.WallSpan(2.0)
+		wallHeight := projection.FocalLength / 2.0
+		assert.InDelta(t, 100.0-0.75*wallHeight, top, 1e-9)
+		assert.InDelta(t, 100.0+0.25*wallHeight, bottom, 1e-9)
+	})
+
+	t.Run("pitch shears the horizon", func(t *testing.T) {
+		up := camera
+		up.Pitch = 0.1
+		projection := up.Projection(320, 200)
+
+		assert.InDelta(t, 100.0+projection.FocalLength*math.Tan(0.1), projection.Horizon, 1e-9)
+
+		top, bottom := projection.WallSpan(1.0)
+		assert.InDelta(t, projection.Horizon-projection.FocalLength/2.0, top, 1e-9)
+		assert.InDelta(t, projection.Horizon+projection.FocalLength/2.0, bottom, 1e-9)
+	})
+
+	t.Run("row distance matches the wall span", func(t *testing.T) {
+		projection := camera.Projection(320, 200)
+
+		for _, distance := range []float64{1.0, 2.0, 4.0} {
+			top, bottom := projection.WallSpan(distance)
+			floorRow := int(math.Round(bottom - 0.5))
+			ceilingRow := int(math.Round(top - 0.5))
+			assert.InDelta(t, distance, projection.RowDistance(floorRow), distance*0.05)
+			assert.InDelta(t, distance, projection.RowDistance(ceilingRow), distance*0.05)
+		}
+		oddHeight := NewCamera(Vector{}, 0.0).Projection(320, 201)
+		assert.True(t, math.IsInf(oddHeight.RowDistance(100), 1), "row at the horizon")
+	})
+
+	t.Run("zero field of view and eye height use the defaults", func(t *testing.T) {
+		assert.Equal(t, camera.Projection(320, 200), Camera{Position: camera.Position, Heading: camera.Heading}.Projection(320, 200))
+	})
+}
@@ -0,0 +1,137 @@
+package maze
+
+import (
+	"math"
+	"maze/internal/pkg/raycastmap"
+)
+
+const (
+	// collisionStepFraction is the longest step of a movement as a fraction of the circle radius.
+	// Short steps keep a fast circle from passing through corners (or thin obstacles) between two steps.
+	collisionStepFraction = 0.5
+
+	// collisionIterations is how many times a step is pushed out of the blocking map cell it overlaps most. More than
+	// one cell can block a step (like in the inner corner of two walls), and pushing out of one can push into another.
+	collisionIterations = 4
+)
+
+// MoveCircle moves a circle (like the observer) with a radius from a position by a motion, and gives the new position.
+// The map cells where the map has an obstacle (see raycastmap.Map.ObstacleAt: walls, closed doors, barrels, tables...)
+// and the cells outside the map block the circle. The circle slides along what blocks it instead of stopping: only the
+// part of the motion against the blocking side is lost. The circle slides around corners the same way.
+func MoveCircle(position Vector, radius float64, motion Vector, worldMap raycastmap.Map) Vector {
+	steps := 1
+	if radius > 0.0 {
+		length := math.Sqrt(motion.X*motion.X + motion.Y*motion.Y)
+		steps = max(1, int(math.Ceil(length/(radius*collisionStepFraction))))
+	}
+	stepX, stepY := motion.X/float64(steps), motion.Y/float64(steps)
+
+	for step := 0; step < steps; step++ {
+		position.X += stepX
+		position.Y += stepY
+		position = pushOutOfObstacles(position, radius, worldMap)
+	}
+
+	return position
+}
+
+// CircleBlocked reports if a circle at a position overlaps any blocking map cell, see MoveCircle.
+func CircleBlocked(position Vector, radius float64, worldMap raycastmap.Map) bool {
+	blocked := false
+	overlappingObstacles(position, radius, worldMap, func(_, _ int, _, _, _ float64) bool {
+		blocked = true
+		return false
+	})
+
+	return blocked
+}
+
+// pushOutOfObstacles pushes a circle out of the blocking map cells it overlaps, along the shortest way out.
+func pushOutOfObstacles(position Vector, radius float64, worldMap raycastmap.Map) Vector {
+	for iteration := 0; iteration < collisionIterations; iteration++ {
+		// The cell overlapped most, cells along the same wall side are pushed out of at once
+		deepest := false
+		deepestX, deepestY := 0, 0
+		deepestDX, deepestDY, deepestDistance := 0.0, 0.0, math.Inf(1)
+		overlappingObstacles(position, radius, worldMap, func(x, y int, dx, dy, distance float64) bool {
+			if distance < deepestDistance {
+				deepest = true
+				deepestX, deepestY = x, y
+				deepestDX, deepestDY, deepestDistance = dx, dy, distance
+			}
+			return true
+		})
+
+		if !deepest {
+			break
+		}
+
+		if deepestDistance > 0.0 {
+			// Push away from the closest point of the cell: straight out of a side, or round a corner
+			position.X += deepestDX / deepestDistance * (radius - deepestDistance)
+			position.Y += deepestDY / deepestDistance * (radius - deepestDistance)
+		} else {
+			position = pushOutOfCell(position, radius, deepestX, deepestY) // The middle is inside the cell
+		}
+	}
+
+	return position
+}
+
+// overlappingObstacles calls overlap for each blocking map cell a circle overlaps, with the offset dx, dy from the
+// closest point of the cell to the circle middle, and the distance (zero if the middle is inside the cell).
+// Stops when overlap returns false.
+func overlappingObstacles(position Vector, radius float64, worldMap raycastmap.Map, overlap func(x, y int, dx, dy, distance float64) bool) {
+	minX, maxX := int(math.Floor(position.X-radius)), int(math.Floor(position.X+radius))
+	minY, maxY := int(math.Floor(position.Y-radius)), int(math.Floor(position.Y+radius))
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			if !blocksMovement(worldMap, x, y) {
+				continue
+			}
+
+			dx := position.X - min(max(position.X, float64(x)), float64(x+1))
+			dy := position.Y - min(max(position.Y, float64(y)), float64(y+1))
+			distanceSquared := dx*dx + dy*dy
+			if distanceSquared >= radius*radius {
+				continue // Touching is not overlapping
+			}
+
+			if !overlap(x, y, dx, dy, math.Sqrt(distanceSquared)) {
+				return
+			}
+		}
+	}
+}
+
+// pushOutOfCell pushes a circle with its middle inside a map cell out through the closest cell side.
+func pushOutOfCell(position Vector, radius float64, x, y int) Vector {
+	left := position.X - float64(x)
+	right := float64(x+1) - position.X
+	bottom := position.Y - float64(y)
+	top := float64(y+1) - position.Y
+
+	switch min(left, right, bottom, top) {
+	case left:
+		position.X = float64(x) - radius
+	case right:
+		position.X = float64(x+1) + radius
+	case bottom:
+		position.Y = float64(y) - radius
+	default:
+		position.Y = float64(y+1) + radius
+	}
+
+	return position
+}
+
+// blocksMovement reports if map cell x, y blocks movement: an obstacle, or outside the map.
+func blocksMovement(worldMap raycastmap.Map, x, y int) bool {
+	if x < 0 || y < 0 || x >= worldMap.Width() || y >= worldMap.Height() {
+		return true
+	}
+
+	return worldMap.ObstacleAt(x, y)
+}
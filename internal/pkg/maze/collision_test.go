@@ -0,0 +1,99 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"maze/internal/pkg/raycastmap"
+	"testing"
+)
+
+// obstacleTestMap is a SliceMap with obstacles that are not walls, like barrels and tables.
+type obstacleTestMap struct {
+	raycastmap.SliceMap
+	obstacles map[raycastmap.Cell]bool
+}
+
+func (m obstacleTestMap) ObstacleAt(x, y int) bool {
+	return m.SliceMap.ObstacleAt(x, y) || m.obstacles[raycastmap.Cell{X: x, Y: y}]
+}
+
+func TestMoveCircle(t *testing.T) {
+	const radius = 0.2
+
+	// A room of 4 x 4 cells (x 1-4, y 1-4) with a pillar at 3, 3 (the map data is indexed [x][y])
+	room := raycastmap.NewSliceMap([][]int{
+		{1, 1, 1, 1, 1, 1},
+		{1, 0, 0, 0, 0, 1},
+		{1, 0, 0, 0, 0, 1},
+		{1, 0, 0, 1, 0, 1},
+		{1, 0, 0, 0, 0, 1},
+		{1, 1, 1, 1, 1, 1},
+	}, 0.0, 0.0, 0.0, wallValueToStructure)
+
+	t.Run("free movement", func(t *testing.T) {
+		moved := MoveCircle(Vector{X: 1.5, Y: 1.5}, radius, Vector{X: 1.0, Y: 0.5}, room)
+		assert.InDelta(t, 2.5, moved.X, 0.000001)
+		assert.InDelta(t, 2.0, moved.Y, 0.000001)
+	})
+
+	t.Run("stops at the wall", func(t *testing.T) {
+		moved := MoveCircle(Vector{X: 2.5, Y: 1.5}, radius, Vector{X: 0.0, Y: -1.0}, room)
+		assert.InDelta(t, 2.5, moved.X, 0.000001)
+		assert.InDelta(t, 1.0+radius, moved.Y, 0.000001)
+	})
+
+	t.Run("slides along the wall", func(t *testing.T) {
+		moved := MoveCircle(Vector{X: 1.5, Y: 1.5}, radius, Vector{X: 1.0, Y: -1.0}, room)
+		assert.InDelta(t, 2.5, moved.X, 0.000001, "the motion along the wall is kept")
+		assert.InDelta(t, 1.0+radius, moved.Y, 0.000001)
+	})
+
+	t.Run("slides into the inner corner", func(t *testing.T) {
+		moved := MoveCircle(Vector{X: 1.5, Y: 1.5}, radius, Vector{X: -1.0, Y: -1.0}, room)
+		assert.InDelta(t, 1.0+radius, moved.X, 0.000001)
+		assert.InDelta(t, 1.0+radius, moved.Y, 0.000001)
+	})
+
+	t.Run("slides around the corner of the pillar", func(t *testing.T) {
+		// Heading north, just off the west side of the pillar: the corner pushes aside instead of stopping
+		moved := MoveCircle(Vector{X: 2.9, Y: 2.0}, radius, Vector{X: 0.0, Y: 2.0}, room)
+		assert.InDelta(t, 4.0, moved.Y, 0.1, "not snagged on the corner")
+		assert.LessOrEqual(t, moved.X, 3.0-radius+0.000001, "pushed west of the pillar")
+		assert.False(t, CircleBlocked(moved, radius, room))
+	})
+
+	t.Run("does not pass through walls when moving fast", func(t *testing.T) {
+		moved := MoveCircle(Vector{X: 2.5, Y: 3.5}, radius, Vector{X: 10.0, Y: 0.0}, room)
+		assert.InDelta(t, 3.0-radius, moved.X, 0.000001, "stopped by the pillar")
+
+		moved = MoveCircle(Vector{X: 4.5, Y: 1.5}, radius, Vector{X: 10.0, Y: 0.0}, room)
+		assert.InDelta(t, 5.0-radius, moved.X, 0.000001, "stopped by the outer wall")
+	})
+
+	t.Run("obstacles that are not walls block", func(t *testing.T) {
+		barrel := raycastmap.Cell{X: 2, Y: 1}
+		withBarrel := obstacleTestMap{SliceMap: room, obstacles: map[raycastmap.Cell]bool{barrel: true}}
+		assert.False(t, withBarrel.WallAt(barrel.X, barrel.Y))
+
+		moved := MoveCircle(Vector{X: 1.5, Y: 1.5}, radius, Vector{X: 2.0, Y: 0.0}, withBarrel)
+		assert.InDelta(t, 2.0-radius, moved.X, 0.000001)
+		assert.InDelta(t, 1.5, moved.Y, 0.000001)
+	})
+
+	t.Run("outside the map blocks", func(t *testing.T) {
+		open := raycastmap.NewSliceMap([][]int{{0, 0}, {0, 0}}, 0.0, 0.0, 0.0, wallValueToStructure)
+
+		moved := MoveCircle(Vector{X: 1.0, Y: 1.0}, radius, Vector{X: -5.0, Y: 5.0}, open)
+		assert.InDelta(t, radius, moved.X, 0.000001)
+		assert.InDelta(t, 2.0-radius, moved.Y, 0.000001)
+	})
+}
+
+func TestCircleBlocked(t *testing.T) {
+	corridor := raycastmap.NewSliceMap([][]int{{1, 1, 1}, {1, 0, 1}, {1, 0, 1}, {1, 1, 1}}, 0.0, 0.0, 0.0, wallValueToStructure)
+
+	assert.False(t, CircleBlocked(Vector{X: 1.5, Y: 1.5}, 0.2, corridor))
+	assert.False(t, CircleBlocked(Vector{X: 1.2, Y: 1.5}, 0.2, corridor), "touching the wall")
+	assert.True(t, CircleBlocked(Vector{X: 1.1, Y: 1.5}, 0.2, corridor))
+	assert.True(t, CircleBlocked(Vector{X: 1.15, Y: 2.85}, 0.2, corridor), "overlapping the inner corner")
+	assert.True(t, CircleBlocked(Vector{X: 0.5, Y: 0.5}, 0.2, corridor), "inside a wall")
+}
@@ -1,74 +1,125 @@
 package maze
 
-import "image/color"
+import (
+	"image/color"
+	"math"
+)
 
+// Color is a light or surface color with the channels in the range [0.0, 1.0] (light can be brighter).
+// Colors are values, the operations give new colors without allocating.
+//
+// The channels are either sRGB values (like the bytes of images), or linear light values where adding and scaling
+// colors is physically right. Use the SRGB functions to convert, and a ToneMapper to turn linear light into image bytes.
 type Color struct {
 	R, G, B float64
 }
 
-func NewColor(r, g, b float64) *Color {
-	return &Color{R: r, G: g, B: b}
+// srgbByteToLinear is the linear value of each sRGB byte value, textures are converted a lot.
+var srgbByteToLinear = func() (table [256]float64) {
+	for i := range table {
+		table[i] = SRGBToLinear(float64(i) / 255.0)
+	}
+	return table
+}()
+
+func NewColor(r, g, b float64) Color {
+	return Color{R: r, G: g, B: b}
 }
 
-func NewColorFromByte(r, g, b byte) *Color {
+func NewColorFromByte(r, g, b byte) Color {
 	const byteNormalize = 1.0 / 255.0
-	return &Color{R: float64(r) * byteNormalize, G: float64(g) * byteNormalize, B: float64(b) * byteNormalize}
+	return Color{R: float64(r) * byteNormalize, G: float64(g) * byteNormalize, B: float64(b) * byteNormalize}
+}
+
+// NewColorFromSRGBByte gives the linear light color of sRGB bytes (like texture pixels).
+func NewColorFromSRGBByte(r, g, b byte) Color {
+	return Color{R: srgbByteToLinear[r], G: srgbByteToLinear[g], B: srgbByteToLinear[b]}
 }
 
-func NewColorFromColor(color color.Color) *Color {
+// NewColorFromSRGBColor gives the linear light color of an sRGB color (like a texture dominant color).
+func NewColorFromSRGBColor(color color.Color) Color {
+	r, g, b, _ := color.RGBA()
+	return NewColorFromSRGBByte(byte(r>>8), byte(g>>8), byte(b>>8))
+}
+
+func NewColorFromColor(color color.Color) Color {
 	const byteNormalize = 1.0 / 255.0
 
 	r, g, b, _ := color.RGBA()
-	return &Color{
+	return Color{
 		R: float64(r>>8) * byteNormalize,
 		G: float64(g>>8) * byteNormalize,
 		B: float64(b>>8) * byteNormalize,
 	}
 }
 
-func (c *Color) RGBA() color.RGBA {
+func (c Color) RGBA() color.RGBA {
 	r, g, b := c.Bytes()
 	return color.RGBA{R: r, G: g, B: b, A: 255}
 }
 
-func (c *Color) FadeTo(to *Color, factor float64) *Color {
-	return &Color{
-		R: clamp(c.R+(to.R-c.R)*factor, 0.0, 1.0),
-		G: clamp(c.G+(to.G-c.G)*factor, 0.0, 1.0),
-		B: clamp(c.B+(to.B-c.B)*factor, 0.0, 1.0),
+// FadeTo gives the color faded towards another color, factor 0.0 is this color and 1.0 is the other color.
+// Colors brighter than 1.0 stay bright, they are only clamped when converted to bytes.
+func (c Color) FadeTo(to Color, factor float64) Color {
+	return Color{
+		R: c.R + (to.R-c.R)*factor,
+		G: c.G + (to.G-c.G)*factor,
+		B: c.B + (to.B-c.B)*factor,
 	}
 }
 
-func (c *Color) Mul(m *Color) *Color {
-	return &Color{
+func (c Color) Mul(m Color) Color {
+	return Color{
 		R: c.R * m.R,
 		G: c.G * m.G,
 		B: c.B * m.B,
 	}
 }
 
-func (c *Color) Add(m *Color) *Color {
-	return &Color{
+func (c Color) Add(m Color) Color {
+	return Color{
 		R: c.R + m.R,
 		G: c.G + m.G,
 		B: c.B + m.B,
 	}
 }
 
-func (c *Color) Scale(t float64) *Color {
-	return &Color{
+func (c Color) Scale(t float64) Color {
+	return Color{
 		R: c.R * t,
 		G: c.G * t,
 		B: c.B * t,
 	}
 }
 
-func (c *Color) Bytes() (r, g, b byte) {
+// SRGBToLinear gives the sRGB color linear light.
+func (c Color) SRGBToLinear() Color {
+	return Color{R: SRGBToLinear(c.R), G: SRGBToLinear(c.G), B: SRGBToLinear(c.B)}
+}
+
+// Bytes gives the color bytes, the channels clamped to the range [0.0, 1.0].
+func (c Color) Bytes() (r, g, b byte) {
 	return byte(clamp(c.R, 0.0, 1.0) * 255.0),
 		byte(clamp(c.G, 0.0, 1.0) * 255.0),
 		byte(clamp(c.B, 0.0, 1.0) * 255.0)
 }
 
+// SRGBToLinear gives the linear light value of an sRGB value, range [0.0, 1.0].
+func SRGBToLinear(value float64) float64 {
+	if value <= 0.04045 {
+		return value / 12.92
+	}
+	return math.Pow((value+0.055)/1.055, 2.4)
+}
+
+// LinearToSRGB gives the sRGB value of a linear light value, range [0.0, 1.0].
+func LinearToSRGB(value float64) float64 {
+	if value <= 0.0031308 {
+		return value * 12.92
+	}
+	return 1.055*math.Pow(value, 1.0/2.4) - 0.055
+}
+
 func clamp(value, min, max float64) float64 {
 	if value < min {
 		return min
@@ -0,0 +1,40 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"testing"
+)
+
+func TestSRGBToLinear(t *testing.T) {
+	assert.Equal(t, 0.0, SRGBToLinear(0.0))
+	assert.Equal(t, 1.0, SRGBToLinear(1.0))
+	assert.InDelta(t, 0.214041, SRGBToLinear(0.5), 0.000001)
+	assert.InDelta(t, 0.002428, SRGBToLinear(0.03136), 0.000001, "linear segment in the dark")
+
+	assert.InDelta(t, 0.5, LinearToSRGB(0.214041), 0.000001)
+	assert.InDelta(t, 0.735357, LinearToSRGB(0.5), 0.000001, "half the light is brighter than half the sRGB value")
+
+	for i := 0; i < 256; i++ {
+		value := float64(i) / 255.0
+		assert.InDelta(t, value, LinearToSRGB(SRGBToLinear(value)), 0.000001)
+	}
+}
+
+func TestNewColorFromSRGBByte(t *testing.T) {
+	assert.Equal(t, Color{}, NewColorFromSRGBByte(0, 0, 0))
+	assert.Equal(t, NewColor(1.0, 1.0, 1.0), NewColorFromSRGBByte(255, 255, 255))
+
+	c := NewColorFromSRGBByte(128, 64, 255)
+	assert.InDelta(t, 0.215861, c.R, 0.000001)
+	assert.InDelta(t, 0.051269, c.G, 0.000001)
+	assert.Equal(t, 1.0, c.B)
+	assert.Equal(t, c, NewColorFromSRGBColor(color.RGBA{R: 128, G: 64, B: 255, A: 255}))
+	assert.Equal(t, NewColorFromByte(128, 64, 255).SRGBToLinear(), c)
+}
+
+func TestColorFadeTo(t *testing.T) {
+	bright := NewColor(4.0, 2.0, 0.0)
+	assert.Equal(t, bright, bright.FadeTo(Color{}, 0.0))
+	assert.Equal(t, NewColor(2.0, 1.0, 0.5), bright.FadeTo(NewColor(0.0, 0.0, 1.0), 0.5), "light brighter than 1.0 is not clamped")
+}
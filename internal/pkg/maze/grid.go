@@ -0,0 +1,50 @@
+package maze
+
+import "math"
+
+// walkCells visits the map cells a line segment passes through, in order from the cell of from to the cell of to.
+// The walk stops when visit returns false.
+//
+// This is the same grid traversal (DDA) as RaycastRay, but between two points instead of until a wall is hit.
+func walkCells(from Vector, to Vector, visit func(x, y int) bool) {
+	mapX, mapY := int(math.Floor(from.X)), int(math.Floor(from.Y))
+	endX, endY := int(math.Floor(to.X)), int(math.Floor(to.Y))
+
+	if !visit(mapX, mapY) {
+		return
+	}
+
+	dirX, dirY := to.X-from.X, to.Y-from.Y
+
+	deltaDistX, deltaDistY := humongousLarge, humongousLarge
+	if dirX != 0.0 {
+		deltaDistX = math.Abs(1.0 / dirX)
+	}
+	if dirY != 0.0 {
+		deltaDistY = math.Abs(1.0 / dirY)
+	}
+
+	stepX, sideDistX := 1, (float64(mapX)+1.0-from.X)*deltaDistX
+	if dirX < 0.0 {
+		stepX, sideDistX = -1, (from.X-float64(mapX))*deltaDistX
+	}
+	stepY, sideDistY := 1, (float64(mapY)+1.0-from.Y)*deltaDistY
+	if dirY < 0.0 {
+		stepY, sideDistY = -1, (from.Y-float64(mapY))*deltaDistY
+	}
+
+	// Each step is one cell in x or y direction towards the end cell, never past it (rounding can not make it overshoot)
+	for mapX != endX || mapY != endY {
+		if mapY == endY || (mapX != endX && sideDistX < sideDistY) {
+			sideDistX += deltaDistX
+			mapX += stepX
+		} else {
+			sideDistY += deltaDistY
+			mapY += stepY
+		}
+
+		if !visit(mapX, mapY) {
+			return
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWalkCells(t *testing.T) {
+	type cell struct{ x, y int }
+
+	walk := func(from, to Vector) []cell {
+		var cells []cell
+		walkCells(from, to, func(x, y int) bool {
+			cells = append(cells, cell{x, y})
+			return true
+		})
+		return cells
+	}
+
+	assert.Equal(t, []cell{{2, 1}}, walk(Vector{X: 2.2, Y: 1.3}, Vector{X: 2.8, Y: 1.9}), "same cell")
+	assert.Equal(t, []cell{{0, 0}, {1, 0}, {2, 0}, {3, 0}}, walk(Vector{X: 0.5, Y: 0.5}, Vector{X: 3.5, Y: 0.5}), "east")
+	assert.Equal(t, []cell{{0, 3}, {0, 2}, {0, 1}}, walk(Vector{X: 0.5, Y: 3.5}, Vector{X: 0.5, Y: 1.5}), "south")
+	assert.Equal(t, []cell{{0, 0}, {1, 0}, {1, 1}, {2, 1}, {3, 1}}, walk(Vector{X: 0.5, Y: 0.5}, Vector{X: 3.5, Y: 1.5}), "shallow")
+	assert.Equal(t, []cell{{3, 1}, {2, 1}, {2, 0}, {1, 0}, {0, 0}}, walk(Vector{X: 3.5, Y: 1.5}, Vector{X: 0.5, Y: 0.5}), "shallow, backwards")
+
+	t.Run("stops when visit returns false", func(t *testing.T) {
+		var cells []cell
+		walkCells(Vector{X: 0.5, Y: 0.5}, Vector{X: 5.5, Y: 0.5}, func(x, y int) bool {
+			cells = append(cells, cell{x, y})
+			return x < 2
+		})
+		assert.Equal(t, []cell{{0, 0}, {1, 0}, {2, 0}}, cells)
+	})
+}
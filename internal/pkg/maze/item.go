@@ -0,0 +1,82 @@
+package maze
+
+import (
+	"maze/internal/pkg/raycastmap"
+	"maze/internal/pkg/wolf3d"
+)
+
+const (
+	clipAmmo        = 8 // Ammo in a clip of the map
+	droppedClipAmmo = 4 // Ammo in a clip dropped by an actor
+	machineGunAmmo  = 6 // Ammo that comes with a machine gun
+	chainGunAmmo    = 6 // Ammo that comes with a chain gun
+)
+
+// itemSpecials are the names of the specials (see raycastmap.TileTable.SpecialNamed) items look like.
+var itemSpecials = map[wolf3d.Pickup]string{
+	wolf3d.PickupClip:       "AmmoClip",
+	wolf3d.PickupMachineGun: "AutomaticRifle",
+}
+
+// isWeaponOrAmmo reports if a pickup is a weapon or ammo, the pickups of the map that are items (see World.Items).
+func isWeaponOrAmmo(pickup wolf3d.Pickup) bool {
+	return pickup == wolf3d.PickupClip || pickup == wolf3d.PickupMachineGun || pickup == wolf3d.PickupChainGun
+}
+
+// Item is something the player picks up by walking over it, like a machine gun or the ammo clip a dead guard drops.
+type Item struct {
+	Position  Vector
+	Pickup    wolf3d.Pickup
+	Structure *raycastmap.Structure // How the item looks (its sprite)
+	Dropped   bool                  // If an actor dropped the item, a dropped clip has less ammo
+	Taken     bool                  // If the player has picked up the item
+}
+
+// Drop drops an item in the middle of map cell x, y.
+func (w *World) Drop(pickup wolf3d.Pickup, x, y int) *Item {
+	item := &Item{
+		Position:  Vector{X: float64(x) + 0.5, Y: float64(y) + 0.5},
+		Pickup:    pickup,
+		Structure: w.special(itemSpecials[pickup]),
+		Dropped:   true,
+	}
+	w.Items = append(w.Items, item)
+	return item
+}
+
+// pickUpItems picks up the items in the map cell of the player. Ammo is left when the player cannot carry more.
+func (w *World) pickUpItems() {
+	player := w.Player
+	x, y := player.Cell()
+
+	for _, item := range w.Items {
+		if item.Taken || int(item.Position.X) != x || int(item.Position.Y) != y {
+			continue
+		}
+
+		switch item.Pickup {
+		case wolf3d.PickupClip:
+			ammo := clipAmmo
+			if item.Dropped {
+				ammo = droppedClipAmmo
+			}
+			item.Taken = player.GiveAmmo(ammo)
+		case wolf3d.PickupMachineGun:
+			player.GiveWeapon(WeaponMachineGun)
+			player.GiveAmmo(machineGunAmmo)
+			item.Taken = true
+		case wolf3d.PickupChainGun:
+			player.GiveWeapon(WeaponChainGun)
+			player.GiveAmmo(chainGunAmmo)
+			item.Taken = true
+		}
+	}
+}
+
+// special gives the special of the tile table with a name, or nil if there is none.
+func (w *World) special(name string) *raycastmap.Structure {
+	if w.tiles == nil || name == "" {
+		return nil
+	}
+	return w.tiles.SpecialNamed(name)
+}
@@ -0,0 +1,221 @@
+package maze
+
+import (
+	"math"
+	"maze/internal/pkg/raycastmap"
+)
+
+// defaultLightFalloff is the falloff of lights without a falloff: the light fades with the square of the distance.
+const defaultLightFalloff = 2.0
+
+// PointLight is a colored light in the maze (a lamp), fading with the distance to nothing at its radius.
+type PointLight struct {
+	Position Vector
+	Color    Color
+	Radius   float64 // Distance (in cells) where the light has faded to nothing
+	Falloff  float64 // Exponent of the fading with the distance, 1.0 is linear
+}
+
+// Intensity gives how much of the light color reaches a distance, range [0.0, 1.0].
+func (l *PointLight) Intensity(distance float64) float64 {
+	if distance >= l.Radius {
+		return 0.0
+	}
+
+	return math.Pow(1.0-distance/l.Radius, l.Falloff)
+}
+
+// CollectLights gives all point lights in the map: the specials with a light, each one in the middle of its map cell.
+func CollectLights(worldMap raycastmap.Map) []PointLight {
+	var lights []PointLight
+
+	for y := 0; y < worldMap.Height(); y++ {
+		for x := 0; x < worldMap.Width(); x++ {
+			light := worldMap.SpecialAt(x, y).Light
+			if light == nil {
+				continue
+			}
+
+			falloff := light.Falloff
+			if falloff == 0.0 {
+				falloff = defaultLightFalloff
+			}
+
+			lights = append(lights, PointLight{
+				Position: Vector{X: float64(x) + 0.5, Y: float64(y) + 0.5},
+				Color:    NewColor(light.Color[0], light.Color[1], light.Color[2]),
+				Radius:   light.Radius,
+				Falloff:  falloff,
+			})
+		}
+	}
+
+	return lights
+}
+
+// LightMap is the light of point lights, baked per map cell (at the middle of the cell).
+//
+// A light reaches a map cell if there is a line of sight from the light to the cell: walls block the light, and doors
+// let through as much light as they are open. When doors move, Update bakes the lights passing through them again.
+type LightMap struct {
+	worldMap      raycastmap.Map
+	width, height int
+	lights        []bakedLight
+	cells         []Color // Sum of the lights, indexed by y*width + x
+}
+
+// bakedLight is the light of a point light in the map cells within its radius.
+type bakedLight struct {
+	PointLight
+	minX, minY, maxX, maxY int     // The map cells within the light radius (inclusive)
+	cells                  []Color // Light in the cells within the radius, indexed by (y-minY)*(maxX-minX+1) + (x-minX)
+	doors                  []bakedDoor
+}
+
+// bakedDoor is a door the light passes (or is blocked by), with how far it was open when the light was baked.
+type bakedDoor struct {
+	door   *raycastmap.Door
+	offset float64
+}
+
+// NewLightMap bakes the light of point lights in a map, see CollectLights.
+func NewLightMap(worldMap raycastmap.Map, lights []PointLight) *LightMap {
+	m := &LightMap{
+		worldMap: worldMap,
+		width:    worldMap.Width(),
+		height:   worldMap.Height(),
+		lights:   make([]bakedLight, len(lights)),
+		cells:    make([]Color, worldMap.Width()*worldMap.Height()),
+	}
+
+	for i, light := range lights {
+		m.lights[i] = bakedLight{
+			PointLight: light,
+			minX:       max(0, int(math.Floor(light.Position.X-light.Radius))),
+			minY:       max(0, int(math.Floor(light.Position.Y-light.Radius))),
+			maxX:       min(m.width-1, int(math.Floor(light.Position.X+light.Radius))),
+			maxY:       min(m.height-1, int(math.Floor(light.Position.Y+light.Radius))),
+		}
+		m.bake(&m.lights[i])
+	}
+	m.accumulate()
+
+	return m
+}
+
+// At gives the light at a map position.
+func (m *LightMap) At(position *Vector) Color {
+	return m.CellAt(int(math.Floor(position.X)), int(math.Floor(position.Y)))
+}
+
+// CellAt gives the light in map cell x, y. There is no light outside the map.
+func (m *LightMap) CellAt(x, y int) Color {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return Color{}
+	}
+
+	return m.cells[y*m.width+x]
+}
+
+// Update bakes the lights again that pass through doors that have moved since the lights were baked.
+// It reports if any light was baked again.
+func (m *LightMap) Update() bool {
+	changed := false
+
+	for i := range m.lights {
+		light := &m.lights[i]
+		for _, door := range light.doors {
+			if door.door.Offset != door.offset {
+				m.bake(light)
+				changed = true
+				break
+			}
+		}
+	}
+
+	if changed {
+		m.accumulate()
+	}
+
+	return changed
+}
+
+// bake calculates the light in the map cells within the light radius, and the doors on the way.
+func (m *LightMap) bake(light *bakedLight) {
+	columns := light.maxX - light.minX + 1
+	rows := light.maxY - light.minY + 1
+	if columns <= 0 || rows <= 0 {
+		return // Outside the map
+	}
+
+	if light.cells == nil {
+		light.cells = make([]Color, columns*rows)
+	}
+	light.doors = light.doors[:0]
+
+	for y := light.minY; y <= light.maxY; y++ {
+		for x := light.minX; x <= light.maxX; x++ {
+			cellCenter := Vector{X: float64(x) + 0.5, Y: float64(y) + 0.5}
+			intensity := light.Intensity(cellCenter.Sub(&light.Position).Length())
+			lit := !m.worldMap.WallAt(x, y) || m.worldMap.DoorAt(x, y) != nil // Wall sides are lit by the cell in front of them
+			if intensity > 0.0 && lit {
+				intensity *= m.transmission(light, cellCenter)
+			} else {
+				intensity = 0.0
+			}
+
+			light.cells[(y-light.minY)*columns+(x-light.minX)] = light.Color.Scale(intensity)
+		}
+	}
+}
+
+// transmission gives how much of a light reaches a cell center, range [0.0, 1.0]: 0.0 if a wall or a closed door is
+// in the way. The doors on the way are added to the doors of the light.
+func (m *LightMap) transmission(light *bakedLight, cellCenter Vector) float64 {
+	endX, endY := int(cellCenter.X), int(cellCenter.Y)
+	transmission := 1.0
+
+	walkCells(light.Position, cellCenter, func(x, y int) bool {
+		if x == endX && y == endY {
+			return false // The cell itself does not block its own light (door cells are lit as if open)
+		}
+
+		if door := m.worldMap.DoorAt(x, y); door != nil {
+			if !containsDoor(light.doors, door) {
+				light.doors = append(light.doors, bakedDoor{door: door, offset: door.Offset})
+			}
+			transmission *= door.Offset
+		} else if m.worldMap.WallAt(x, y) {
+			transmission = 0.0
+		}
+
+		return transmission > 0.0
+	})
+
+	return transmission
+}
+
+func containsDoor(doors []bakedDoor, door *raycastmap.Door) bool {
+	for _, bakedDoor := range doors {
+		if bakedDoor.door == door {
+			return true
+		}
+	}
+	return false
+}
+
+// accumulate sums the light of all lights in each map cell.
+func (m *LightMap) accumulate() {
+	clear(m.cells)
+
+	for i := range m.lights {
+		light := &m.lights[i]
+		columns := light.maxX - light.minX + 1
+		for y := light.minY; y <= light.maxY; y++ {
+			for x := light.minX; x <= light.maxX; x++ {
+				cell := &m.cells[y*m.width+x]
+				*cell = cell.Add(light.cells[(y-light.minY)*columns+(x-light.minX)])
+			}
+		}
+	}
+}
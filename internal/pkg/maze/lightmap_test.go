@@ -0,0 +1,99 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"maze/internal/pkg/raycastmap"
+	"testing"
+)
+
+func TestPointLightIntensity(t *testing.T) {
+	light := PointLight{Radius: 4.0, Falloff: 1.0}
+	assert.Equal(t, 1.0, light.Intensity(0.0))
+	assert.Equal(t, 0.5, light.Intensity(2.0))
+	assert.Zero(t, light.Intensity(4.0))
+	assert.Zero(t, light.Intensity(5.0))
+
+	light.Falloff = 2.0
+	assert.Equal(t, 0.25, light.Intensity(2.0))
+}
+
+func TestCollectLights(t *testing.T) {
+	levelMap, err := raycastmap.NewWolfensteinMap(0)
+	assert.NoError(t, err)
+
+	lights := CollectLights(levelMap)
+	assert.NotEmpty(t, lights)
+
+	for _, light := range lights {
+		x, y := int(light.Position.X), int(light.Position.Y)
+		assert.Equal(t, float64(x)+0.5, light.Position.X)
+		assert.Equal(t, float64(y)+0.5, light.Position.Y)
+		assert.NotNil(t, levelMap.SpecialAt(x, y).Light)
+		assert.Positive(t, light.Radius)
+		assert.Equal(t, defaultLightFalloff, light.Falloff)
+	}
+}
+
+func TestLightMap(t *testing.T) {
+	// A room (x 1-3) and a room (x 5-8) separated by a wall at x=4, with an opening at y=3 (the map data is indexed [x][y])
+	roomMap := raycastmap.NewSliceMap([][]int{
+		{1, 1, 1, 1, 1},
+		{1, 0, 0, 0, 1}, {1, 0, 0, 0, 1}, {1, 0, 0, 0, 1},
+		{1, 1, 1, 0, 1},
+		{1, 0, 0, 0, 1}, {1, 0, 0, 0, 1}, {1, 0, 0, 0, 1}, {1, 0, 0, 0, 1},
+		{1, 1, 1, 1, 1},
+	}, 0.0, 0.0, 0.0, wallValueToStructure)
+	red := NewColor(1.0, 0.0, 0.0)
+	lightMap := NewLightMap(roomMap, []PointLight{{Position: Vector{X: 2.5, Y: 1.5}, Color: red, Radius: 5.0, Falloff: 1.0}})
+
+	assert.Equal(t, red, lightMap.CellAt(2, 1), "full light in the light cell")
+	assert.Equal(t, red, lightMap.At(&Vector{X: 2.1, Y: 1.9}))
+	assert.InDelta(t, 0.8, lightMap.CellAt(3, 1).R, 0.000001)
+	assert.Zero(t, lightMap.CellAt(3, 1).G)
+
+	assert.Zero(t, lightMap.CellAt(4, 1), "wall cell")
+	assert.Zero(t, lightMap.CellAt(5, 1), "behind the wall")
+	assert.Positive(t, lightMap.CellAt(4, 3).R, "in the opening")
+	assert.Zero(t, lightMap.CellAt(5, 3), "the wall is in the way")
+	assert.Zero(t, lightMap.CellAt(8, 3), "outside the radius")
+	assert.Zero(t, lightMap.CellAt(-1, 0), "outside the map")
+
+	assert.False(t, lightMap.Update(), "no doors")
+
+	t.Run("lights add up", func(t *testing.T) {
+		blue := NewColor(0.0, 0.0, 1.0)
+		twoLights := NewLightMap(roomMap, []PointLight{
+			{Position: Vector{X: 2.5, Y: 1.5}, Color: red, Radius: 5.0, Falloff: 1.0},
+			{Position: Vector{X: 3.5, Y: 1.5}, Color: blue, Radius: 5.0, Falloff: 1.0},
+		})
+
+		assert.Equal(t, 1.0, twoLights.CellAt(2, 1).R)
+		assert.InDelta(t, 0.8, twoLights.CellAt(2, 1).B, 0.000001)
+	})
+}
+
+func TestLightMapDoors(t *testing.T) {
+	corridor := [][]int{
+		{1, 1, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 1, 1},
+	}
+	doors := raycastmap.NewDoors(len(corridor), len(corridor[0]))
+	door := raycastmap.NewDoor(3, 1, true, raycastmap.DefaultTileTable().DoorFrame())
+	doors.Add(door)
+	doorMap := doorTestMap{SliceMap: raycastmap.NewSliceMap(corridor, 1.5, 1.5, 0.0, wallValueToStructure), doors: doors}
+
+	white := NewColor(1.0, 1.0, 1.0)
+	lightMap := NewLightMap(doorMap, []PointLight{{Position: Vector{X: 1.5, Y: 1.5}, Color: white, Radius: 6.0, Falloff: 1.0}})
+
+	assert.Positive(t, lightMap.CellAt(2, 1).R)
+	assert.Positive(t, lightMap.CellAt(3, 1).R, "the door cell is lit")
+	assert.Zero(t, lightMap.CellAt(4, 1), "behind the closed door")
+
+	door.Offset = 0.5
+	assert.True(t, lightMap.Update())
+	assert.InDelta(t, 0.5*(1.0-3.0/6.0), lightMap.CellAt(4, 1).R, 0.000001, "half of the light through the half open door")
+
+	door.Offset = 1.0
+	assert.True(t, lightMap.Update())
+	assert.InDelta(t, 1.0-3.0/6.0, lightMap.CellAt(4, 1).R, 0.000001)
+	assert.False(t, lightMap.Update(), "the door has not moved")
+}
@@ -1,13 +1,86 @@
 package maze
 
-import "maze/internal/pkg/raycastmap"
+import (
+	"math"
+	"maze/internal/pkg/raycastmap"
+)
 
+const (
+	PlayerRadius = 0.2 // Radius of the player, how close the player can get to walls and obstacles
+	PlayerHealth = 100 // Health of the player at the start
+)
+
+// Creature is something that moves around in the world, the player or an actor: a circle (for collisions) with a
+// heading.
 type Creature struct {
-	location    *Vector
-	viewHeading float64
+	Position Vector
+	Heading  float64 // Angle in radians, range [0.0, 2π). East is 0 and north is π/2.
+	Radius   float64
+}
+
+// Direction gives the heading as a direction vector.
+func (c *Creature) Direction() *Vector {
+	return NewDirectionVector(c.Heading)
+}
+
+// Cell gives the map cell the middle of the creature is in.
+func (c *Creature) Cell() (x, y int) {
+	return int(math.Floor(c.Position.X)), int(math.Floor(c.Position.Y))
+}
+
+// InCell reports if the creature (with its radius) is (partly) inside map cell x, y.
+func (c *Creature) InCell(x, y int) bool {
+	return int(math.Floor(c.Position.X-c.Radius)) <= x && x <= int(math.Floor(c.Position.X+c.Radius)) &&
+		int(math.Floor(c.Position.Y-c.Radius)) <= y && y <= int(math.Floor(c.Position.Y+c.Radius))
+}
+
+// Move moves the creature by a motion, sliding along walls and obstacles in the way (see MoveCircle).
+func (c *Creature) Move(motion Vector, worldMap raycastmap.Map) {
+	c.Position = MoveCircle(c.Position, c.Radius, motion, worldMap)
+}
+
+// Turn turns the creature by an angle in radians, counterclockwise (to the left) for a positive angle.
+func (c *Creature) Turn(angle float64) {
+	c.Heading = math.Mod(c.Heading+angle, math.Pi*2.0)
+	if c.Heading < 0.0 {
+		c.Heading += math.Pi * 2.0
+	}
+}
+
+// Player is the creature moved by the person playing.
+type Player struct {
+	Creature
+	Health int // Health points, range [0, PlayerHealth]
+
+	Weapon     Weapon // Weapon in hand
+	BestWeapon Weapon // Best weapon owned, the player owns all weapons up to it
+	Ammo       int    // Bullets, range [0, MaxAmmo]
+	Trigger    bool   // If the trigger is pulled (held), the player attacks with the weapon in hand (see World.Update)
+
+	chosenWeapon   Weapon  // Weapon selected, taken back in hand when the player out of ammo gets ammo again
+	triggerHeld    bool    // If the trigger is held since the latest attack
+	attackCooldown float64 // Seconds left before the weapon can attack again
+}
+
+// NewPlayer creates a player at a position with a heading, with full health and a pistol.
+func NewPlayer(position Vector, heading float64) *Player {
+	return &Player{
+		Creature:     Creature{Position: position, Heading: heading, Radius: PlayerRadius},
+		Health:       PlayerHealth,
+		Weapon:       WeaponPistol,
+		BestWeapon:   WeaponPistol,
+		Ammo:         PlayerAmmo,
+		chosenWeapon: WeaponPistol,
+	}
+}
+
+// Damage takes health points from the player. A player without health is dead.
+func (p *Player) Damage(points int) {
+	p.Health = max(0, p.Health-points)
 }
 
-type World struct {
-	stage *raycastmap.Map
-	hero  *Creature
+// IsAlive reports if the player has health left. A dead player does not pick up items and does not attack, and the
+// actors do not attack a dead player.
+func (p *Player) IsAlive() bool {
+	return p.Health > 0
 }
@@ -3,6 +3,7 @@ package maze
 import (
 	"github.com/stretchr/testify/assert"
 	"math"
+	"maze/internal/pkg/raycastmap"
 	"testing"
 )
 
@@ -41,3 +42,42 @@ func TestLine_Intersection(t *testing.T) {
 		})
 	}
 }
+
+func TestCreatureTurn(t *testing.T) {
+	creature := Creature{Heading: math.Pi}
+
+	creature.Turn(math.Pi / 2.0)
+	assert.InDelta(t, math.Pi*3.0/2.0, creature.Heading, 0.000001)
+
+	creature.Turn(math.Pi)
+	assert.InDelta(t, math.Pi/2.0, creature.Heading, 0.000001, "wraps around")
+
+	creature.Turn(-math.Pi)
+	assert.InDelta(t, math.Pi*3.0/2.0, creature.Heading, 0.000001, "wraps around clockwise")
+}
+
+func TestCreatureCells(t *testing.T) {
+	creature := Creature{Position: Vector{X: 2.9, Y: 1.5}, Radius: 0.2}
+
+	x, y := creature.Cell()
+	assert.Equal(t, 2, x)
+	assert.Equal(t, 1, y)
+
+	assert.True(t, creature.InCell(2, 1))
+	assert.True(t, creature.InCell(3, 1), "partly in the next cell")
+	assert.False(t, creature.InCell(1, 1))
+	assert.False(t, creature.InCell(2, 2))
+
+	creature.Position = Vector{X: 0.1, Y: 0.5}
+	assert.True(t, creature.InCell(-1, 0), "partly outside the map")
+}
+
+func TestCreatureMove(t *testing.T) {
+	// A corridor from x 1 to 3 at y 1 (the map data is indexed [x][y])
+	corridor := raycastmap.NewSliceMap([][]int{{1, 1, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 1, 1}}, 0.0, 0.0, 0.0, wallValueToStructure)
+	creature := Creature{Position: Vector{X: 1.5, Y: 1.5}, Heading: 0.0, Radius: 0.2}
+
+	creature.Move(*creature.Direction().Scale(5.0), corridor)
+	assert.InDelta(t, 4.0-0.2, creature.Position.X, 0.000001, "stopped by the wall at the end")
+	assert.InDelta(t, 1.5, creature.Position.Y, 0.000001)
+}
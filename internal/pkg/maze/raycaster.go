@@ -0,0 +1,96 @@
+package maze
+
+import (
+	"maze/internal/pkg/raycastmap"
+	"runtime"
+	"sync"
+)
+
+// chunksPerWorker is how many column ranges each worker gets per frame (on average).
+// Some columns are more expensive than others (long rays), smaller ranges even out the work between the workers.
+const chunksPerWorker = 4
+
+// Raycaster casts the rays of a frame in parallel, with the pixel columns split over a pool of worker goroutines.
+// The result is identical to RaycastInto. Casting the rays of a frame does not allocate.
+//
+// A Raycaster casts one frame at a time, it is not safe for concurrent use.
+// The map must not be changed while the rays are cast (doors are moved between frames).
+type Raycaster struct {
+	workers int
+	jobs    chan columnRange
+	wg      sync.WaitGroup
+
+	// The current frame, set before the column ranges are handed out to the workers
+	pixelColumnInfos []IntersectionInfo
+	projection       Projection
+	observer         Vector
+	worldMap         raycastmap.Map
+}
+
+type columnRange struct {
+	start, end int
+}
+
+// NewRaycaster creates a raycaster with a number of worker goroutines. Zero (or less) workers means one worker
+// per CPU (GOMAXPROCS). With one worker the rays are cast in the calling goroutine.
+//
+// Close the raycaster to stop the worker goroutines.
+func NewRaycaster(workers int) *Raycaster {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	r := &Raycaster{workers: workers}
+	if workers > 1 {
+		r.jobs = make(chan columnRange, workers*chunksPerWorker)
+		for i := 0; i < workers; i++ {
+			go r.work()
+		}
+	}
+
+	return r
+}
+
+// Workers gives the number of worker goroutines.
+func (r *Raycaster) Workers() int {
+	return r.workers
+}
+
+// Raycast casts a ray for each pixel column of a screen pixelColumnCount x pixelRowCount pixels, viewed through a camera.
+// The pixel column infos of dst are reused if it has the capacity, see RaycastInto.
+func (r *Raycaster) Raycast(dst []IntersectionInfo, camera Camera, pixelColumnCount int, pixelRowCount int, worldMap raycastmap.Map) []IntersectionInfo {
+	if r.jobs == nil {
+		return RaycastInto(dst, camera, pixelColumnCount, pixelRowCount, worldMap)
+	}
+
+	r.pixelColumnInfos = resizeIntersectionInfos(dst, pixelColumnCount)
+	r.projection = camera.Projection(pixelColumnCount, pixelRowCount)
+	r.observer = camera.Position
+	r.worldMap = worldMap
+
+	chunkCount := min(pixelColumnCount, r.workers*chunksPerWorker)
+	r.wg.Add(chunkCount)
+	for chunk := 0; chunk < chunkCount; chunk++ {
+		r.jobs <- columnRange{start: chunk * pixelColumnCount / chunkCount, end: (chunk + 1) * pixelColumnCount / chunkCount}
+	}
+	r.wg.Wait()
+
+	pixelColumnInfos := r.pixelColumnInfos
+	r.pixelColumnInfos, r.worldMap = nil, nil // Do not keep the caller's slice and map alive
+
+	return pixelColumnInfos
+}
+
+// Close stops the worker goroutines. The raycaster can not be used after it has been closed.
+func (r *Raycaster) Close() {
+	if r.jobs != nil {
+		close(r.jobs)
+	}
+}
+
+func (r *Raycaster) work() {
+	for columns := range r.jobs {
+		raycastColumns(r.pixelColumnInfos, &r.projection, &r.observer, r.worldMap, columns.start, columns.end)
+		r.wg.Done()
+	}
+}
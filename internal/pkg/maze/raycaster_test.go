@@ -0,0 +1,116 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"testing"
+)
+
+func TestRaycasterIdenticalToSerial(t *testing.T) {
+	levelMap, err := raycastmap.NewWolfensteinMap(0)
+	assert.NoError(t, err)
+
+	// Open one of the doors half-way, so rays pass through doors as well
+	levelMap.Doors().All()[0].Offset = 0.5
+
+	cameras := []Camera{
+		NewCamera(Vector{X: levelMap.StartX(), Y: levelMap.StartY()}, levelMap.StartDir()),
+		NewCamera(Vector{X: levelMap.StartX() - 1.2, Y: levelMap.StartY() + 1.0}, levelMap.StartDir()-math.Pi/5.0),
+		NewCamera(Vector{X: levelMap.StartX(), Y: levelMap.StartY()}, 0.3),
+		{Position: Vector{X: levelMap.StartX(), Y: levelMap.StartY()}, Heading: 0.3, MaxDistance: 4.0},
+	}
+
+	for _, workers := range []int{0, 1, 2, 3, 8} {
+		raycaster := NewRaycaster(workers)
+
+		for _, size := range []struct{ width, height int }{{640, 400}, {321, 200}, {5, 4}, {1, 1}} {
+			for _, camera := range cameras {
+				expected := Raycast(camera, size.width, size.height, levelMap)
+				actual := raycaster.Raycast(nil, camera, size.width, size.height, levelMap)
+				assert.Equal(t, expected, actual, "workers %d, %d x %d, camera %+v", workers, size.width, size.height, camera)
+			}
+		}
+
+		raycaster.Close()
+	}
+}
+
+func TestRaycasterWorkers(t *testing.T) {
+	raycaster := NewRaycaster(3)
+	defer raycaster.Close()
+	assert.Equal(t, 3, raycaster.Workers())
+
+	defaultRaycaster := NewRaycaster(0)
+	defer defaultRaycaster.Close()
+	assert.Positive(t, defaultRaycaster.Workers())
+}
+
+func TestRaycastIntoReusesPixelColumnInfos(t *testing.T) {
+	levelMap, err := raycastmap.NewWolfensteinMap(0)
+	assert.NoError(t, err)
+	camera := NewCamera(Vector{X: levelMap.StartX(), Y: levelMap.StartY()}, levelMap.StartDir())
+
+	pixelColumnInfos := RaycastInto(nil, camera, 640, 400, levelMap)
+	assert.Len(t, pixelColumnInfos, 640)
+
+	smaller := RaycastInto(pixelColumnInfos, camera, 320, 200, levelMap)
+	assert.Len(t, smaller, 320)
+	assert.Same(t, &pixelColumnInfos[0], &smaller[0])
+
+	raycaster := NewRaycaster(4)
+	defer raycaster.Close()
+
+	allocations := testing.AllocsPerRun(10, func() {
+		pixelColumnInfos = RaycastInto(pixelColumnInfos, camera, 640, 400, levelMap)
+	})
+	assert.Zero(t, allocations, "serial")
+
+	allocations = testing.AllocsPerRun(10, func() {
+		pixelColumnInfos = raycaster.Raycast(pixelColumnInfos, camera, 640, 400, levelMap)
+	})
+	assert.Zero(t, allocations, "parallel")
+}
+
+func benchmarkLevelMap(b *testing.B) (*raycastmap.WolfensteinMap, Camera) {
+	levelMap, err := raycastmap.NewWolfensteinMap(0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return levelMap, NewCamera(Vector{X: levelMap.StartX(), Y: levelMap.StartY()}, levelMap.StartDir()-math.Pi/5.0)
+}
+
+func BenchmarkRaycast(b *testing.B) {
+	levelMap, camera := benchmarkLevelMap(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Raycast(camera, 640, 400, levelMap)
+	}
+}
+
+func BenchmarkRaycastInto(b *testing.B) {
+	levelMap, camera := benchmarkLevelMap(b)
+	pixelColumnInfos := make([]IntersectionInfo, 640)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		pixelColumnInfos = RaycastInto(pixelColumnInfos, camera, 640, 400, levelMap)
+	}
+}
+
+func BenchmarkRaycaster(b *testing.B) {
+	levelMap, camera := benchmarkLevelMap(b)
+	pixelColumnInfos := make([]IntersectionInfo, 640)
+	raycaster := NewRaycaster(0)
+	defer raycaster.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		pixelColumnInfos = raycaster.Raycast(pixelColumnInfos, camera, 640, 400, levelMap)
+	}
+}
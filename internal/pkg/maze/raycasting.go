@@ -10,15 +10,24 @@ const humongousLarge = 1e30
 type IntersectionInfo struct {
 	Hit                        bool
 	PerpendicularDistance      float64
-	Wall                       *raycastmap.Cell
+	Wall                       raycastmap.Cell
 	Side                       int // North-South wall (=0) or East-West wall (=1)
 	WallSideIntersectionOffset float64
-	ObserverPoint              *Vector
-	IntersectionPoint          *Vector
+	ObserverPoint              Vector
+	IntersectionPoint          Vector
 	IntersectionCosAngle       float64
 }
 
+// RaycastRay casts a ray from start in direction rayDir until it hits a wall (or a closed part of a door).
+// Nothing is allocated, start and rayDir are not kept.
 func RaycastRay(start *Vector, rayDir *Vector, worldMap raycastmap.Map) (intersectionInfo IntersectionInfo) {
+	return RaycastRayWithin(start, rayDir, 0.0, worldMap)
+}
+
+// RaycastRayWithin is RaycastRay that stops stepping through the map at a maximum distance along rayDir (the
+// perpendicular distance for the rays of a camera projection). If no wall is hit within maxDistance, Hit is false and
+// the intersection is at maxDistance, in an empty structure. Zero maxDistance means no maximum.
+func RaycastRayWithin(start *Vector, rayDir *Vector, maxDistance float64, worldMap raycastmap.Map) (intersectionInfo IntersectionInfo) {
 	// Direction Vector is always of length 1.0.
 	// The direction Vector points in the direction the observer is viewing along (at the center of observer view).
 	//var rayDirX = math.Cos(directionAngle)
@@ -73,7 +82,15 @@ func RaycastRay(start *Vector, rayDir *Vector, worldMap raycastmap.Map) (interse
 	// perform DDA
 	side := -1 // was a NS or an EW wall hit?
 	hit := false
+	var cellDoor *raycastmap.Door     // The door in the map cell the ray currently is in (if any)
+	var previousDoor *raycastmap.Door // The door in the map cell the ray was in before the current map cell (if any)
 	for !hit {
+		previousDoor = cellDoor
+
+		if maxDistance > 0.0 && min(sideDistX, sideDistY) > maxDistance {
+			return missedIntersectionInfo(start, rayDir, maxDistance, mapX, mapY) // The next map cell is too far away
+		}
+
 		// Jump to the next map square, either in x-direction or in y-direction
 		if sideDistX < sideDistY {
 			sideDistX += deltaDistX
@@ -85,9 +102,37 @@ func RaycastRay(start *Vector, rayDir *Vector, worldMap raycastmap.Map) (interse
 			side = 1 // NS side
 		}
 
+		cellDoor = worldMap.DoorAt(mapX, mapY)
+		if cellDoor != nil {
+			// Doors are thin walls in the middle of the cell. The ray might pass the door cell if the door is (partly) open.
+			entryDistance := sideDistY - deltaDistY
+			if side == 0 {
+				entryDistance = sideDistX - deltaDistX
+			}
+			exitDistance := min(sideDistX, sideDistY)
+
+			if doorHit, doorDistance, doorOffset := doorIntersection(cellDoor, start, rayDir, entryDistance, exitDistance); doorHit && (maxDistance <= 0.0 || doorDistance <= maxDistance) {
+				return doorIntersectionInfo(cellDoor, start, rayDir, doorDistance, doorOffset, worldMap)
+			}
+
+			continue
+		}
+
 		hit = worldMap.WallAt(mapX, mapY) // Check if ray has hit a wall
 	}
 
+	// The wall sides next to a door (the door jambs) use the door frame structure
+	structure := worldMap.StructureAt(mapX, mapY)
+	if previousDoor != nil && previousDoor.Frame != nil {
+		doorJambSide := 0
+		if previousDoor.Vertical {
+			doorJambSide = 1
+		}
+		if side == doorJambSide {
+			structure = previousDoor.Frame
+		}
+	}
+
 	// Calculate distance projected on a camera direction
 	// (Euclidean distance would give fisheye effect)
 	var perpWallDist float64
@@ -120,42 +165,42 @@ func RaycastRay(start *Vector, rayDir *Vector, worldMap raycastmap.Map) (interse
 	}
 	wallIntersectionOffset -= math.Floor(wallIntersectionOffset)
 
-	// Calculate intersection point coordinate
-	intersectionPoint := &Vector{X: float64(mapX), Y: float64(mapY)}
+	// Calculate intersection point coordinate (value vectors, the hot loop should not allocate)
+	intersectionPoint := Vector{X: float64(mapX), Y: float64(mapY)}
 	intersectionCosAngle := 1.0
 	if side == 0 { // We are hit west or east side of a wall cell.
 		if rayDir.X <= 0 {
 			// We are facing/tracing westwards, thus we hit the east side of the wall cell
 			// Adjust final cell distance as we hit the east side, thus wall coordinate-cell width (1.0)
-			intersectionPoint = intersectionPoint.AddXY(1.0, wallIntersectionOffset)
-			intersectionCosAngle = math.Abs(rayDir.Normalized().X)
+			intersectionPoint = Vector{X: intersectionPoint.X + 1.0, Y: intersectionPoint.Y + wallIntersectionOffset}
+			intersectionCosAngle = math.Abs(normalizedX(rayDir.X, rayDir.Y))
 		} else if rayDir.X > 0 {
 			// We are facing/tracing eastwards, thus we hit the west side of the wall cell
 			// Adjust final cell distance as we hit the east side, thus wall coordinate-cell width (1.0)
-			intersectionPoint = intersectionPoint.AddXY(0.0, 1.0-wallIntersectionOffset)
-			intersectionCosAngle = math.Abs(intersectionPoint.Sub(start).Normalized().X)
+			intersectionPoint = Vector{X: intersectionPoint.X, Y: intersectionPoint.Y + (1.0 - wallIntersectionOffset)}
+			intersectionCosAngle = math.Abs(normalizedX(intersectionPoint.X-start.X, intersectionPoint.Y-start.Y))
 		}
 	} else if side == 1 { // We are hit nest or south side of a wall cell.
 		if rayDir.Y >= 0.0 {
 			// We are facing/tracing northwards, thus we hit the south side of the wall cell
 			// Adjust final cell distance as we hit the east side, thus wall coordinate-cell width (1.0)
-			intersectionPoint = intersectionPoint.AddXY(wallIntersectionOffset, 0.0)
-			intersectionCosAngle = math.Abs(rayDir.Normalized().Y)
+			intersectionPoint = Vector{X: intersectionPoint.X + wallIntersectionOffset, Y: intersectionPoint.Y}
+			intersectionCosAngle = math.Abs(normalizedX(rayDir.Y, rayDir.X))
 		} else if rayDir.Y < 0 {
 			// We are facing/tracing southwards, thus we hit the north side of the wall cell
 			// Adjust final cell distance as we hit the east side, thus wall coordinate-cell width (1.0)
-			intersectionPoint = intersectionPoint.AddXY(1.0-wallIntersectionOffset, 1.0)
-			intersectionCosAngle = math.Abs(rayDir.Normalized().Y)
+			intersectionPoint = Vector{X: intersectionPoint.X + (1.0 - wallIntersectionOffset), Y: intersectionPoint.Y + 1.0}
+			intersectionCosAngle = math.Abs(normalizedX(rayDir.Y, rayDir.X))
 		}
 	}
 
 	intersectionInfo = IntersectionInfo{
 		Hit:                        hit,
 		PerpendicularDistance:      perpWallDist,
-		ObserverPoint:              start,
+		ObserverPoint:              *start,
 		IntersectionPoint:          intersectionPoint,
 		IntersectionCosAngle:       intersectionCosAngle,
-		Wall:                       &raycastmap.Cell{X: mapX, Y: mapY, Structure: worldMap.StructureAt(mapX, mapY)},
+		Wall:                       raycastmap.Cell{X: mapX, Y: mapY, Structure: structure},
 		Side:                       side,
 		WallSideIntersectionOffset: wallIntersectionOffset,
 	}
@@ -163,29 +208,113 @@ func RaycastRay(start *Vector, rayDir *Vector, worldMap raycastmap.Map) (interse
 	return intersectionInfo
 }
 
-func Raycast(pixelColumnCount int, observer *Vector, viewDirectionAngle float64, worldMap raycastmap.Map) (pixelColumnInfos []IntersectionInfo) {
-	// Direction Vector is always of length 1.0.
-	// The direction Vector points in the direction the observer is viewing along (at the center of observer view).
-	dirX := math.Cos(viewDirectionAngle)
-	dirY := math.Sin(viewDirectionAngle)
-
-	// initial camera plane Vector
-	// camera plane Vector is always perpendicular to direction Vector
-	fov := 0.66
-	planeX := fov * dirY
-	planeY := fov * -dirX
-
-	for pixelColumn := 0; pixelColumn < pixelColumnCount; pixelColumn++ {
-		// calculate ray position and direction
-		cameraX := 2.0*float64(pixelColumn)/float64(pixelColumnCount) - 1.0 // camera plane pos [-1, 1]
-		rayDir := &Vector{
-			X: dirX + planeX*cameraX,
-			Y: dirY + planeY*cameraX,
+// doorIntersection tests if a ray hits the closed part of a door.
+// The door is a thin wall in the middle of the door cell, that slides open towards the lower coordinate side of the cell.
+//
+// The entryDistance and exitDistance are the distances along the ray where it enters and leaves the door cell.
+// The distance returned is the distance along the ray to the door, and the offset is the texture offset [0.0, 1.0] on the door.
+func doorIntersection(door *raycastmap.Door, start *Vector, rayDir *Vector, entryDistance float64, exitDistance float64) (hit bool, distance float64, offset float64) {
+	if door.Vertical {
+		if rayDir.X == 0.0 {
+			return false, 0.0, 0.0 // Ray is parallel to the door
 		}
+		distance = (float64(door.X) + 0.5 - start.X) / rayDir.X
+		offset = start.Y + distance*rayDir.Y - float64(door.Y)
+	} else {
+		if rayDir.Y == 0.0 {
+			return false, 0.0, 0.0 // Ray is parallel to the door
+		}
+		distance = (float64(door.Y) + 0.5 - start.Y) / rayDir.Y
+		offset = start.X + distance*rayDir.X - float64(door.X)
+	}
+
+	if distance < entryDistance || distance > exitDistance {
+		return false, 0.0, 0.0 // Ray leaves the door cell before it reaches the door
+	}
+
+	if offset < door.Offset {
+		return false, 0.0, 0.0 // Ray passes through the part where the door has slid open
+	}
+
+	return true, distance, offset - door.Offset
+}
 
-		pixelColumnInfo := RaycastRay(observer, rayDir, worldMap)
-		pixelColumnInfos = append(pixelColumnInfos, pixelColumnInfo)
+// normalizedX gives the x component of the vector x, y normalized to length 1.0.
+// Same as Vector.Normalized, without allocating a vector.
+func normalizedX(x, y float64) float64 {
+	return x * (1.0 / math.Sqrt(x*x+y*y))
+}
+
+func doorIntersectionInfo(door *raycastmap.Door, start *Vector, rayDir *Vector, distance float64, offset float64, worldMap raycastmap.Map) IntersectionInfo {
+	intersectionPoint := Vector{X: start.X + rayDir.X*distance, Y: start.Y + rayDir.Y*distance}
+
+	side := 1
+	intersectionCosAngle := math.Abs(normalizedX(rayDir.Y, rayDir.X))
+	flipOffset := rayDir.Y < 0.0
+	if door.Vertical {
+		side = 0
+		intersectionCosAngle = math.Abs(normalizedX(rayDir.X, rayDir.Y))
+		flipOffset = rayDir.X > 0.0
+	}
+
+	// Keep the door texture the same way around as for wall textures, from whatever side the door is viewed
+	if flipOffset {
+		offset = 1.0 - offset
+	}
+
+	return IntersectionInfo{
+		Hit:                        true,
+		PerpendicularDistance:      distance,
+		ObserverPoint:              *start,
+		IntersectionPoint:          intersectionPoint,
+		IntersectionCosAngle:       intersectionCosAngle,
+		Wall:                       raycastmap.Cell{X: door.X, Y: door.Y, Structure: worldMap.StructureAt(door.X, door.Y)},
+		Side:                       side,
+		WallSideIntersectionOffset: min(max(offset, 0.0), math.Nextafter(1.0, 0.0)),
+	}
+}
+
+// missedIntersectionInfo is the intersection info of a ray that did not hit anything within maxDistance.
+func missedIntersectionInfo(start *Vector, rayDir *Vector, maxDistance float64, mapX, mapY int) IntersectionInfo {
+	return IntersectionInfo{
+		Hit:                   false,
+		PerpendicularDistance: maxDistance,
+		ObserverPoint:         *start,
+		IntersectionPoint:     Vector{X: start.X + rayDir.X*maxDistance, Y: start.Y + rayDir.Y*maxDistance},
+		IntersectionCosAngle:  1.0,
+		Wall:                  raycastmap.Cell{X: mapX, Y: mapY, Structure: raycastmap.StructureNone},
+		Side:                  -1,
 	}
+}
+
+// Raycast casts a ray for each pixel column of a screen pixelColumnCount x pixelRowCount pixels, viewed through a camera.
+func Raycast(camera Camera, pixelColumnCount int, pixelRowCount int, worldMap raycastmap.Map) (pixelColumnInfos []IntersectionInfo) {
+	return RaycastInto(nil, camera, pixelColumnCount, pixelRowCount, worldMap)
+}
+
+// RaycastInto is Raycast reusing the pixel column infos of dst (if it has the capacity), so that casting the rays
+// of a frame does not allocate. The pixel column infos are returned, with the length pixelColumnCount.
+func RaycastInto(dst []IntersectionInfo, camera Camera, pixelColumnCount int, pixelRowCount int, worldMap raycastmap.Map) []IntersectionInfo {
+	projection := camera.Projection(pixelColumnCount, pixelRowCount)
+	pixelColumnInfos := resizeIntersectionInfos(dst, pixelColumnCount)
+	raycastColumns(pixelColumnInfos, &projection, &camera.Position, worldMap, 0, pixelColumnCount)
 
 	return pixelColumnInfos
 }
+
+// raycastColumns casts the rays of the pixel columns from startColumn up to (not including) endColumn.
+func raycastColumns(pixelColumnInfos []IntersectionInfo, projection *Projection, observer *Vector, worldMap raycastmap.Map, startColumn int, endColumn int) {
+	for pixelColumn := startColumn; pixelColumn < endColumn; pixelColumn++ {
+		rayDir := projection.RayDirection(pixelColumn)
+		pixelColumnInfos[pixelColumn] = RaycastRayWithin(observer, &rayDir, projection.MaxDistance, worldMap)
+	}
+}
+
+// resizeIntersectionInfos gives infos with the length n, reusing the infos if the capacity is enough.
+func resizeIntersectionInfos(infos []IntersectionInfo, n int) []IntersectionInfo {
+	if cap(infos) < n {
+		return make([]IntersectionInfo, n)
+	}
+
+	return infos[:n]
+}
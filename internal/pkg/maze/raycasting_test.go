@@ -23,7 +23,7 @@ func wallValueToStructure(v int) *raycastmap.Structure {
 		return raycastmap.StructureNone
 	}
 
-	return raycastmap.StructureGreyStoneWall1
+	return raycastmap.DefaultTileTable().StructureNamed("GreyStoneWall1")
 }
 
 func TestRaycastDistance(t *testing.T) {
@@ -58,7 +58,7 @@ func TestRaycastViewToConsole(t *testing.T) {
 	var observerX = 22.0
 	var observerY = 12.0
 
-	pixelColumnInfos := Raycast(80, &Vector{observerX, observerY}, math.Pi, raycastmap.TestMap1)
+	pixelColumnInfos := Raycast(NewCamera(Vector{observerX, observerY}, math.Pi), 80, 80, raycastmap.TestMap1)
 	heightFactor := 40.0
 	for _, pixelColumnInfo := range pixelColumnInfos {
 		height := heightFactor / pixelColumnInfo.PerpendicularDistance
@@ -75,3 +75,101 @@ func line(count int, character string) string {
 
 	return builder.String()
 }
+
+// doorTestMap is a SliceMap with doors.
+type doorTestMap struct {
+	raycastmap.SliceMap
+	doors *raycastmap.Doors
+}
+
+func (m doorTestMap) DoorAt(x, y int) *raycastmap.Door {
+	return m.doors.At(x, y)
+}
+
+func (m doorTestMap) WallAt(x, y int) bool {
+	return m.SliceMap.WallAt(x, y) || m.doors.At(x, y) != nil
+}
+
+func TestRaycastRayWithin(t *testing.T) {
+	horizontalTestMap := raycastmap.NewSliceMap([][]int{
+		{1}, {0}, {0}, {0}, {0}, {0}, {0}, {0}, {0}, {1},
+	}, 0.0, 0.0, 0.0, wallValueToStructure)
+
+	t.Run("wall within the max distance is hit", func(t *testing.T) {
+		info := RaycastRayWithin(&Vector{4.5, 0.5}, &Vector{1.0, 0.0}, 4.5, horizontalTestMap)
+
+		assert.Equal(t, RaycastRay(&Vector{4.5, 0.5}, &Vector{1.0, 0.0}, horizontalTestMap), info)
+		assert.True(t, info.Hit)
+	})
+
+	t.Run("wall beyond the max distance is not hit", func(t *testing.T) {
+		info := RaycastRayWithin(&Vector{4.5, 0.5}, &Vector{1.0, 0.0}, 3.0, horizontalTestMap)
+
+		assert.False(t, info.Hit)
+		assert.Equal(t, 3.0, info.PerpendicularDistance)
+		assert.Equal(t, Vector{X: 7.5, Y: 0.5}, info.IntersectionPoint)
+		assert.Same(t, raycastmap.StructureNone, info.Wall.Structure)
+	})
+
+	t.Run("closed door beyond the max distance is not hit", func(t *testing.T) {
+		corridor := [][]int{{1, 1, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 1, 1}}
+		doors := raycastmap.NewDoors(len(corridor), len(corridor[0]))
+		doors.Add(raycastmap.NewDoor(3, 1, true, raycastmap.DefaultTileTable().DoorFrame()))
+		doorMap := doorTestMap{SliceMap: raycastmap.NewSliceMap(corridor, 1.5, 1.5, 0.0, wallValueToStructure), doors: doors}
+
+		assert.True(t, RaycastRayWithin(&Vector{1.5, 1.5}, &Vector{1.0, 0.0}, 2.0, doorMap).Hit)
+		assert.False(t, RaycastRayWithin(&Vector{1.5, 1.5}, &Vector{1.0, 0.0}, 1.8, doorMap).Hit, "the ray enters the door cell, but the door is further away")
+	})
+}
+
+func TestRaycastDoor(t *testing.T) {
+	// Horizontal corridor with a vertical door in cell x=5 and walls (door jambs) above and below it
+	corridor := [][]int{
+		{1, 1, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}, {1, 1, 1},
+	}
+	doors := raycastmap.NewDoors(len(corridor), len(corridor[0]))
+	door := raycastmap.NewDoor(5, 1, true, raycastmap.DefaultTileTable().DoorFrame())
+	doors.Add(door)
+	doorMap := doorTestMap{SliceMap: raycastmap.NewSliceMap(corridor, 1.5, 1.5, 0.0, wallValueToStructure), doors: doors}
+
+	t.Run("closed door is hit in the middle of the door cell", func(t *testing.T) {
+		info := RaycastRay(&Vector{1.5, 1.5}, &Vector{1.0, 0.0}, doorMap)
+
+		assert.Equal(t, 5, info.Wall.X)
+		assert.Equal(t, 0, info.Side)
+		assert.InDelta(t, 4.0, info.PerpendicularDistance, 0.000001)
+		assert.InDelta(t, 5.5, info.IntersectionPoint.X, 0.000001)
+	})
+
+	t.Run("open door is passed through", func(t *testing.T) {
+		door.Offset = 1.0
+		defer func() { door.Offset = 0.0 }()
+
+		info := RaycastRay(&Vector{1.5, 1.5}, &Vector{1.0, 0.0}, doorMap)
+
+		assert.Equal(t, 9, info.Wall.X)
+		assert.InDelta(t, 9.0, info.IntersectionPoint.X, 0.000001)
+	})
+
+	t.Run("half open door is passed through on its open side", func(t *testing.T) {
+		door.Offset = 0.5
+		defer func() { door.Offset = 0.0 }()
+
+		openSide := RaycastRay(&Vector{1.5, 1.25}, &Vector{1.0, 0.0}, doorMap)
+		closedSide := RaycastRay(&Vector{1.5, 1.75}, &Vector{1.0, 0.0}, doorMap)
+
+		assert.Equal(t, 9, openSide.Wall.X)
+		assert.Equal(t, 5, closedSide.Wall.X)
+	})
+
+	t.Run("wall next to the door cell is a door jamb", func(t *testing.T) {
+		door.Offset = 1.0
+		defer func() { door.Offset = 0.0 }()
+
+		info := RaycastRay(&Vector{4.5, 1.5}, &Vector{1.0, 0.8}, doorMap)
+
+		assert.Equal(t, 5, info.Wall.X)
+		assert.Equal(t, 2, info.Wall.Y)
+		assert.Equal(t, raycastmap.DefaultTileTable().DoorFrame(), info.Wall.Structure)
+	})
+}
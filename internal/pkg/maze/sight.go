@@ -0,0 +1,331 @@
+package maze
+
+import (
+	"container/heap"
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"sort"
+)
+
+// visibleGap is the smallest angle (in radians) of the view into a map cell for the cell to be visible. Cells only
+// seen along one exact line, like through the corner where two walls touch, are not visible.
+const visibleGap = 1e-9
+
+// LineOfSight reports if two points see each other: no walls and no closed doors are in the way. Doors that are (partly)
+// open can be seen through. A point inside a wall sees nothing.
+func LineOfSight(from, to Vector, worldMap raycastmap.Map) bool {
+	clear := true
+	walkCells(from, to, func(x, y int) bool {
+		clear = !blocksSight(worldMap, x, y)
+		return clear
+	})
+
+	return clear
+}
+
+// VisibleCells gives the map cells visible from a position looking in a direction (heading, in radians), within a
+// (horizontal) field of view in degrees. A field of view of 360 degrees sees all around. Nothing further away than
+// maxDistance is seen, zero means no maximum.
+//
+// The visibility is exact: a cell is visible when any part of it is in view, no matter how small. The cells of walls
+// and closed doors in view are visible, the cells behind them are not. The cells are visited outward from the position
+// (nearest first) through the cells in view only, so the time taken grows with the number of visible cells, not with
+// the size of the map.
+func VisibleCells(position Vector, heading float64, fov float64, maxDistance float64, worldMap raycastmap.Map) *CellSet {
+	width, height := worldMap.Width(), worldMap.Height()
+	visible := NewCellSet(width, height)
+
+	ownX, ownY := int(math.Floor(position.X)), int(math.Floor(position.Y))
+	visible.Add(ownX, ownY)
+	if blocksSight(worldMap, ownX, ownY) {
+		return visible
+	}
+
+	distance := maxDistance
+	if distance <= 0.0 {
+		distance = math.Inf(1)
+	}
+
+	view := []angleRange{{-math.Pi, math.Pi}}
+	if fovAngle := fov * math.Pi / 180.0; fovAngle < 2.0*math.Pi {
+		view = newAngleRanges(heading-fovAngle/2.0, heading+fovAngle/2.0)
+	}
+
+	queued := NewCellSet(width, height)
+	queue := &cellQueue{}
+	enqueue := func(x, y int) {
+		if x >= 0 && y >= 0 && x < width && y < height && !queued.Contains(x, y) {
+			queued.Add(x, y)
+			heap.Push(queue, newViewedCell(position, x, y))
+		}
+	}
+
+	// The cells around the position (diagonal ones too, for a position on the corner of a cell), then the cells next
+	// to each visible cell that does not block the sight. A cell in view is always next to a nearer cell in view.
+	queued.Add(ownX, ownY)
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			enqueue(ownX+dx, ownY+dy)
+		}
+	}
+
+	// The directions blocked by the visible walls and closed doors. A cell that hides (part of) another cell is always
+	// nearer to the position, so it is visited first.
+	var shadows shadowRanges
+	for queue.Len() > 0 {
+		cell := heap.Pop(queue).(viewedCell)
+		if cell.near > distance {
+			break // The cells left are all further away
+		}
+
+		if !cell.inView(position, shadows.cutFrom(cell.directionsWithin(view)), distance) {
+			continue
+		}
+
+		visible.Add(cell.x, cell.y)
+		if blocksSight(worldMap, cell.x, cell.y) {
+			shadows.add(newAngleRanges(cell.center+cell.low, cell.center+cell.high))
+			continue
+		}
+
+		enqueue(cell.x+1, cell.y)
+		enqueue(cell.x-1, cell.y)
+		enqueue(cell.x, cell.y+1)
+		enqueue(cell.x, cell.y-1)
+	}
+
+	return visible
+}
+
+// angleRange is a range of directions, from low to high (in radians, counterclockwise).
+type angleRange struct {
+	low, high float64
+}
+
+// newAngleRanges gives the directions from low to high (in radians, counterclockwise, at most a full turn) as ranges
+// between -pi and pi: two ranges when the directions pass the direction pi.
+func newAngleRanges(low, high float64) []angleRange {
+	turn := low - math.Remainder(low, 2.0*math.Pi)
+	low, high = low-turn, high-turn
+	if high <= math.Pi {
+		return []angleRange{{low, high}}
+	}
+
+	return []angleRange{{low, math.Pi}, {-math.Pi, high - 2.0*math.Pi}}
+}
+
+// shadowRanges are the directions (between -pi and pi) in which the sight is blocked, as sorted ranges that do not
+// overlap.
+type shadowRanges []angleRange
+
+// add adds the ranges (between -pi and pi) to the shadows, merging overlapping ranges.
+func (s *shadowRanges) add(ranges []angleRange) {
+	for _, r := range ranges {
+		first := sort.Search(len(*s), func(i int) bool { return (*s)[i].high >= r.low })
+		last := first
+		for last < len(*s) && (*s)[last].low <= r.high {
+			r.low, r.high = min(r.low, (*s)[last].low), max(r.high, (*s)[last].high)
+			last++
+		}
+
+		*s = append((*s)[:first], append([]angleRange{r}, (*s)[last:]...)...)
+	}
+}
+
+// cutFrom removes the shadows from a list of ranges (between -pi and pi). Gives the parts of the ranges that are left,
+// leaving out the parts narrower than visibleGap.
+func (s shadowRanges) cutFrom(ranges []angleRange) []angleRange {
+	var left []angleRange
+	for _, r := range ranges {
+		low := r.low
+		for i := sort.Search(len(s), func(i int) bool { return s[i].high > r.low }); i < len(s) && s[i].low < r.high; i++ {
+			if s[i].low-low > visibleGap {
+				left = append(left, angleRange{low, s[i].low})
+			}
+			low = max(low, s[i].high)
+		}
+
+		if r.high-low > visibleGap {
+			left = append(left, angleRange{low, r.high})
+		}
+	}
+
+	return left
+}
+
+// viewedCell is a map cell as seen from a position: the directions and distances to it.
+type viewedCell struct {
+	x, y      int
+	center    float64 // Direction to the middle of the cell
+	low, high float64 // Directions to the sides of the cell, relative to center
+	near      float64 // Distance to the nearest point of the cell
+	nearest   float64 // Direction to the nearest point of the cell, relative to center
+}
+
+// newViewedCell gives map cell x, y as seen from a position outside of it (or on its border).
+func newViewedCell(position Vector, x, y int) viewedCell {
+	cell := viewedCell{x: x, y: y, center: math.Atan2(float64(y)+0.5-position.Y, float64(x)+0.5-position.X)}
+
+	nearX := min(max(position.X, float64(x)), float64(x+1))
+	nearY := min(max(position.Y, float64(y)), float64(y+1))
+	cell.near = math.Hypot(nearX-position.X, nearY-position.Y)
+	cell.nearest = angleBetween(cell.center, math.Atan2(nearY-position.Y, nearX-position.X))
+
+	for corner := 0; corner < 4; corner++ {
+		dx, dy := float64(x+corner%2)-position.X, float64(y+corner/2)-position.Y
+		if dx == 0.0 && dy == 0.0 {
+			continue // The position is on this corner
+		}
+
+		direction := angleBetween(cell.center, math.Atan2(dy, dx))
+		cell.low, cell.high = min(cell.low, direction), max(cell.high, direction)
+	}
+
+	return cell
+}
+
+// directionsWithin gives the directions into the cell (between -pi and pi) within a view (ranges between -pi and pi).
+func (c viewedCell) directionsWithin(view []angleRange) []angleRange {
+	var directions []angleRange
+	for _, cell := range newAngleRanges(c.center+c.low, c.center+c.high) {
+		for _, seen := range view {
+			if low, high := max(cell.low, seen.low), min(cell.high, seen.high); high-low > visibleGap {
+				directions = append(directions, angleRange{low, high})
+			}
+		}
+	}
+
+	return directions
+}
+
+// inView reports if a part of the cell seen in the directions (between -pi and pi) is within a distance.
+func (c viewedCell) inView(position Vector, directions []angleRange, distance float64) bool {
+	for _, view := range directions {
+		if c.nearestWithin(position, view) <= distance {
+			return true
+		}
+	}
+
+	return false
+}
+
+// entry gives the distance from a position, in a direction (in radians), to where the line enters the cell. Gives
+// infinity if the line misses the cell.
+func (c viewedCell) entry(position Vector, angle float64) float64 {
+	direction := NewDirectionVector(angle)
+	enter, exit := 0.0, math.Inf(1)
+	for _, axis := range [...][3]float64{{position.X, direction.X, float64(c.x)}, {position.Y, direction.Y, float64(c.y)}} {
+		from, step, side := axis[0], axis[1], axis[2]
+		if step == 0.0 {
+			if from < side || from > side+1.0 {
+				return math.Inf(1)
+			}
+			continue
+		}
+
+		near, far := (side-from)/step, (side+1.0-from)/step
+		enter, exit = max(enter, min(near, far)), min(exit, max(near, far))
+	}
+
+	if enter > exit {
+		return math.Inf(1)
+	}
+	return enter
+}
+
+// nearestWithin gives the distance from a position to the nearest part of the cell seen in a range of directions
+// (between -pi and pi).
+func (c viewedCell) nearestWithin(position Vector, view angleRange) float64 {
+	low := angleBetween(c.center, view.low)
+	if c.near == 0.0 || (c.nearest >= low && c.nearest <= low+view.high-view.low) {
+		return c.near
+	}
+
+	// The nearest point is out of view, the nearest point in view is on the side of the view
+	return min(c.entry(position, view.low), c.entry(position, view.high))
+}
+
+// cellQueue is a priority queue of viewed cells, nearest first, see container/heap.
+type cellQueue []viewedCell
+
+func (q cellQueue) Len() int {
+	return len(q)
+}
+
+func (q cellQueue) Less(i, j int) bool {
+	return q[i].near < q[j].near
+}
+
+func (q cellQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *cellQueue) Push(cell any) {
+	*q = append(*q, cell.(viewedCell))
+}
+
+func (q *cellQueue) Pop() any {
+	old := *q
+	cell := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return cell
+}
+
+// angleBetween gives the angle (in radians) to turn from one direction to another, between -pi and pi.
+func angleBetween(from, to float64) float64 {
+	return math.Remainder(to-from, 2.0*math.Pi)
+}
+
+// blocksSight reports if map cell x, y blocks the sight: a wall or a closed door.
+func blocksSight(worldMap raycastmap.Map, x, y int) bool {
+	if door := worldMap.DoorAt(x, y); door != nil {
+		return door.Offset <= 0.0
+	}
+
+	return worldMap.WallAt(x, y)
+}
+
+// CellSet is a set of map cells, like the cells visible from a position.
+type CellSet struct {
+	width, height int
+	cells         []bool
+	count         int
+}
+
+// NewCellSet creates an empty set for the map cells of a map of width x height cells.
+func NewCellSet(width, height int) *CellSet {
+	return &CellSet{width: width, height: height, cells: make([]bool, width*height)}
+}
+
+// Add adds map cell x, y to the set. Cells outside the map are left out.
+func (s *CellSet) Add(x, y int) {
+	if x < 0 || y < 0 || x >= s.width || y >= s.height || s.cells[y*s.width+x] {
+		return
+	}
+
+	s.cells[y*s.width+x] = true
+	s.count++
+}
+
+// Contains reports if map cell x, y is in the set.
+func (s *CellSet) Contains(x, y int) bool {
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return false
+	}
+
+	return s.cells[y*s.width+x]
+}
+
+// AddAll adds all map cells of another set (of the same map) to the set, like the cells seen so far on an automap.
+func (s *CellSet) AddAll(other *CellSet) {
+	for i, contained := range other.cells {
+		if contained {
+			s.Add(i%s.width, i/s.width)
+		}
+	}
+}
+
+// Len gives the number of map cells in the set.
+func (s *CellSet) Len() int {
+	return s.count
+}
@@ -0,0 +1,121 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"testing"
+)
+
+func TestLineOfSight(t *testing.T) {
+	// A room with a pillar, and a corridor behind a door
+	rooms := raycastmap.NewGridMap(
+		"##########",
+		"#......#.#",
+		"#..#...#.#",
+		"#......|.#",
+		"##########",
+	)
+	door := rooms.DoorAt(7, 1)
+
+	assert.True(t, LineOfSight(Vector{X: 1.5, Y: 3.5}, Vector{X: 6.5, Y: 3.5}, rooms))
+	assert.True(t, LineOfSight(Vector{X: 1.2, Y: 1.2}, Vector{X: 1.8, Y: 1.7}, rooms), "in the same cell")
+	assert.False(t, LineOfSight(Vector{X: 1.5, Y: 2.5}, Vector{X: 5.5, Y: 2.5}, rooms), "the pillar is in the way")
+	assert.False(t, LineOfSight(Vector{X: 5.5, Y: 2.5}, Vector{X: 1.5, Y: 2.5}, rooms), "the other way too")
+	assert.False(t, LineOfSight(Vector{X: 1.5, Y: 2.5}, Vector{X: 3.5, Y: 2.5}, rooms), "inside a wall")
+
+	assert.False(t, LineOfSight(Vector{X: 5.5, Y: 1.5}, Vector{X: 8.5, Y: 1.5}, rooms), "closed door")
+	door.Offset = 0.25
+	assert.True(t, LineOfSight(Vector{X: 5.5, Y: 1.5}, Vector{X: 8.5, Y: 1.5}, rooms), "partly open door")
+	door.Offset = 1.0
+	assert.True(t, LineOfSight(Vector{X: 5.5, Y: 1.5}, Vector{X: 8.5, Y: 1.5}, rooms), "open door")
+}
+
+func TestVisibleCells(t *testing.T) {
+	room := raycastmap.NewGridMap(
+		"##########",
+		"#........#",
+		"#........#",
+		"#...#....#",
+		"#........#",
+		"##########",
+	)
+	position := Vector{X: 2.5, Y: 2.5}
+
+	t.Run("all around", func(t *testing.T) {
+		visible := VisibleCells(position, 0.0, 360.0, 0.0, room)
+
+		assert.True(t, visible.Contains(2, 2), "the own cell")
+		assert.True(t, visible.Contains(8, 4))
+		assert.True(t, visible.Contains(4, 2), "the pillar")
+		assert.True(t, visible.Contains(0, 2), "the walls around")
+		assert.True(t, visible.Contains(7, 5))
+		assert.False(t, visible.Contains(0, 0), "the corners are hidden behind the walls")
+		assert.False(t, visible.Contains(5, 2), "behind the pillar")
+		assert.False(t, visible.Contains(6, 2), "behind the pillar")
+		assert.False(t, visible.Contains(-1, 2), "outside the map")
+		assert.Less(t, visible.Len(), room.Width()*room.Height()-4, "all but the corners and behind the pillar")
+	})
+
+	t.Run("field of view", func(t *testing.T) {
+		visible := VisibleCells(position, math.Pi/2.0, 90.0, 0.0, room)
+
+		assert.True(t, visible.Contains(2, 4), "straight ahead")
+		assert.True(t, visible.Contains(2, 5), "the wall ahead")
+		assert.True(t, visible.Contains(4, 4), "at the edge of the view")
+		assert.False(t, visible.Contains(5, 3), "beside the view")
+		assert.False(t, visible.Contains(2, 1), "behind")
+	})
+
+	t.Run("max distance", func(t *testing.T) {
+		visible := VisibleCells(Vector{X: 1.5, Y: 1.5}, 0.0, 30.0, 3.0, room)
+
+		assert.True(t, visible.Contains(4, 1))
+		assert.False(t, visible.Contains(6, 1), "too far away")
+	})
+
+	t.Run("partly visible", func(t *testing.T) {
+		pillar := raycastmap.NewGridMap(
+			"############",
+			"#..........#",
+			"#..#.......#",
+			"#..........#",
+			"############",
+		)
+		position := Vector{X: 1.5, Y: 1.625}
+		visible := VisibleCells(position, 0.0, 90.0, 0.0, pillar)
+
+		assert.True(t, visible.Contains(10, 3), "just the corner of the cell is seen past the corner of the pillar")
+		assert.True(t, LineOfSight(position, Vector{X: 10.95, Y: 3.01}, pillar))
+		assert.False(t, visible.Contains(9, 3), "just hidden behind the pillar")
+	})
+
+	t.Run("seen so far", func(t *testing.T) {
+		seen := NewCellSet(room.Width(), room.Height())
+		seen.AddAll(VisibleCells(position, 0.0, 360.0, 0.0, room))
+		seen.AddAll(VisibleCells(Vector{X: 6.5, Y: 2.5}, 0.0, 360.0, 0.0, room))
+
+		assert.True(t, seen.Contains(5, 2))
+		assert.Equal(t, room.Width()*room.Height()-4, seen.Len())
+	})
+}
+
+func BenchmarkVisibleCells(b *testing.B) {
+	levelMap, camera := benchmarkLevelMap(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		VisibleCells(camera.Position, camera.Heading, 360.0, 0.0, levelMap)
+	}
+}
+
+func BenchmarkVisibleCellsFOV(b *testing.B) {
+	levelMap, camera := benchmarkLevelMap(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		VisibleCells(camera.Position, camera.Heading, DefaultFOV, 0.0, levelMap)
+	}
+}
@@ -0,0 +1,114 @@
+package maze
+
+import (
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"sort"
+)
+
+// nearClipDistance is the minimum depth (in front of the camera plane) a sprite must have to be rendered.
+const nearClipDistance = 0.1
+
+// Sprite is an object in the maze that is rendered as a camera facing billboard (barrels, lamps, guards, treasures...).
+type Sprite struct {
+	Position  *Vector
+	Structure *raycastmap.Structure
+}
+
+// ProjectedSprite is a Sprite projected onto the screen (the camera plane).
+type ProjectedSprite struct {
+	Sprite
+	Depth       float64 // Distance from the observer projected on the view direction. Comparable to IntersectionInfo.PerpendicularDistance.
+	ScreenX     int     // Pixel column of the sprite (horizontal) center
+	Width       int     // Sprite width in pixels
+	Height      int     // Sprite height in pixels
+	Top         int     // Pixel row of the top of the sprite (can be outside the screen)
+	StartColumn int     // First pixel column covered by the sprite (can be outside the screen)
+	EndColumn   int     // Pixel column after the last pixel column covered by the sprite (can be outside the screen)
+}
+
+// TextureOffset gives the horizontal texture offset [0.0, 1.0) for a pixel column covered by the sprite.
+func (ps *ProjectedSprite) TextureOffset(pixelColumn int) float64 {
+	return float64(pixelColumn-ps.StartColumn) / float64(ps.Width)
+}
+
+// VisibleInColumn reports if the sprite is in front of the wall found for a pixel column.
+func (ps *ProjectedSprite) VisibleInColumn(pixelColumnInfo IntersectionInfo) bool {
+	return ps.Depth < pixelColumnInfo.PerpendicularDistance
+}
+
+// CollectSprites gives all sprites in the map, each one positioned in the middle of its map cell.
+func CollectSprites(worldMap raycastmap.Map) []Sprite {
+	var sprites []Sprite
+
+	for y := 0; y < worldMap.Height(); y++ {
+		for x := 0; x < worldMap.Width(); x++ {
+			special := worldMap.SpecialAt(x, y)
+			if special != nil && special.IsSprite() && special.Texture != nil {
+				sprites = append(sprites, Sprite{Position: &Vector{X: float64(x) + 0.5, Y: float64(y) + 0.5}, Structure: special})
+			}
+		}
+	}
+
+	return sprites
+}
+
+// ProjectSprites projects sprites onto a screen of pixelColumnCount x pixelRowCount pixels, viewed through a camera.
+// Sprites behind the camera or completely outside the screen are left out.
+//
+// The projected sprites are sorted back-to-front (the furthest sprite first) and
+// are supposed to be painted in that order ("painter's algorithm").
+func ProjectSprites(sprites []Sprite, camera Camera, pixelColumnCount int, pixelRowCount int) []ProjectedSprite {
+	projection := camera.Projection(pixelColumnCount, pixelRowCount)
+	direction, plane := projection.Direction, projection.Plane
+	observer := &camera.Position
+
+	// Inverse of the camera matrix [plane direction] determinant
+	invDet := 1.0 / (plane.X*direction.Y - direction.X*plane.Y)
+
+	projectedSprites := make([]ProjectedSprite, 0, len(sprites))
+	for _, sprite := range sprites {
+		relative := sprite.Position.Sub(observer)
+
+		// Transform sprite position with the inverse camera matrix
+		transformX := invDet * (direction.Y*relative.X - direction.X*relative.Y) // Sideways (to the right) on the camera plane
+		transformY := invDet * (-plane.Y*relative.X + plane.X*relative.Y)        // Depth (perpendicular distance)
+
+		if transformY < nearClipDistance {
+			continue // Behind (or too close to) the observer
+		}
+
+		// Same height calculation as for wall pixel columns, sprites stand on the floor and are as high as walls
+		top, bottom := projection.WallSpan(transformY)
+		height := int(bottom - top)
+		width := height
+		if texture := sprite.Structure.Texture; texture != nil && texture.Height() > 0 {
+			width = height * texture.Width() / texture.Height()
+		}
+
+		screenX := int(float64(pixelColumnCount) / 2.0 * (1.0 + transformX/transformY))
+		startColumn := screenX - width/2
+		endColumn := startColumn + width
+
+		if endColumn <= 0 || startColumn >= pixelColumnCount || width <= 0 {
+			continue // Outside the screen
+		}
+
+		projectedSprites = append(projectedSprites, ProjectedSprite{
+			Sprite:      sprite,
+			Depth:       transformY,
+			ScreenX:     screenX,
+			Width:       width,
+			Height:      height,
+			Top:         int(math.Floor(top)),
+			StartColumn: startColumn,
+			EndColumn:   endColumn,
+		})
+	}
+
+	sort.SliceStable(projectedSprites, func(i, j int) bool {
+		return projectedSprites[i].Depth > projectedSprites[j].Depth
+	})
+
+	return projectedSprites
+}
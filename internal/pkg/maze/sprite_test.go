@@ -0,0 +1,71 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"testing"
+)
+
+func TestProjectSprites(t *testing.T) {
+	const pixelColumnCount = 320
+	const pixelRowCount = 200
+
+	observer := &Vector{X: 0.5, Y: 0.5}
+	barrel := raycastmap.DefaultTileTable().SpecialNamed("GreenBarrel")
+
+	t.Run("sprite straight ahead is centered", func(t *testing.T) {
+		sprites := []Sprite{{Position: &Vector{X: 4.5, Y: 0.5}, Structure: barrel}}
+
+		projectedSprites := ProjectSprites(sprites, NewCamera(*observer, 0.0), pixelColumnCount, pixelRowCount)
+
+		assert.Len(t, projectedSprites, 1)
+		assert.InDelta(t, 4.0, projectedSprites[0].Depth, 0.000001)
+		assert.Equal(t, pixelColumnCount/2, projectedSprites[0].ScreenX)
+		projection := NewCamera(*observer, 0.0).Projection(pixelColumnCount, pixelRowCount)
+		assert.Equal(t, int(projection.FocalLength/4.0), projectedSprites[0].Height)
+		assert.InDelta(t, pixelRowCount/2-projectedSprites[0].Height/2, projectedSprites[0].Top, 1.0)
+		assert.Equal(t, projectedSprites[0].Height*barrel.Texture.Width()/barrel.Texture.Height(), projectedSprites[0].Width)
+	})
+
+	t.Run("sprite behind observer is culled", func(t *testing.T) {
+		sprites := []Sprite{{Position: &Vector{X: -3.5, Y: 0.5}, Structure: barrel}}
+
+		projectedSprites := ProjectSprites(sprites, NewCamera(*observer, 0.0), pixelColumnCount, pixelRowCount)
+
+		assert.Empty(t, projectedSprites)
+	})
+
+	t.Run("sprite to the right is projected right of center", func(t *testing.T) {
+		sprites := []Sprite{{Position: &Vector{X: 4.5, Y: -0.5}, Structure: barrel}}
+
+		projectedSprites := ProjectSprites(sprites, NewCamera(*observer, 0.0), pixelColumnCount, pixelRowCount)
+
+		assert.Len(t, projectedSprites, 1)
+		assert.Greater(t, projectedSprites[0].ScreenX, pixelColumnCount/2)
+	})
+
+	t.Run("sprites are sorted back to front", func(t *testing.T) {
+		sprites := []Sprite{
+			{Position: &Vector{X: 0.5, Y: 2.5}, Structure: barrel},
+			{Position: &Vector{X: 0.5, Y: 6.5}, Structure: barrel},
+			{Position: &Vector{X: 0.5, Y: 4.5}, Structure: barrel},
+		}
+
+		projectedSprites := ProjectSprites(sprites, NewCamera(*observer, math.Pi/2.0), pixelColumnCount, pixelRowCount)
+
+		assert.Len(t, projectedSprites, 3)
+		assert.InDelta(t, 6.0, projectedSprites[0].Depth, 0.000001)
+		assert.InDelta(t, 4.0, projectedSprites[1].Depth, 0.000001)
+		assert.InDelta(t, 2.0, projectedSprites[2].Depth, 0.000001)
+	})
+
+	t.Run("sprite is clipped by closer wall", func(t *testing.T) {
+		sprites := []Sprite{{Position: &Vector{X: 4.5, Y: 0.5}, Structure: barrel}}
+		projectedSprites := ProjectSprites(sprites, NewCamera(*observer, 0.0), pixelColumnCount, pixelRowCount)
+
+		assert.Len(t, projectedSprites, 1)
+		assert.False(t, projectedSprites[0].VisibleInColumn(IntersectionInfo{PerpendicularDistance: 3.0}))
+		assert.True(t, projectedSprites[0].VisibleInColumn(IntersectionInfo{PerpendicularDistance: 5.0}))
+	})
+}
@@ -0,0 +1,84 @@
+package maze
+
+import "math"
+
+// ToneMapping is how linear light of any brightness is compressed into the range [0.0, 1.0] of the screen.
+type ToneMapping int
+
+const (
+	ToneMappingClamp    ToneMapping = iota // Cut off at 1.0, bright light washes out to flat white
+	ToneMappingReinhard                    // x / (1 + x), never quite white, keeps detail in bright light
+	ToneMappingACES                        // ACES filmic curve (fitted), a slight toe in the dark and a soft shoulder in the bright
+)
+
+func (t ToneMapping) String() string {
+	switch t {
+	case ToneMappingClamp:
+		return "CLAMP"
+	case ToneMappingReinhard:
+		return "REINHARD"
+	case ToneMappingACES:
+		return "ACES"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// encodeTableSize is the number of steps of the tone mapped range [0.0, 1.0] encoded with the gamma in a table.
+// The steps are spaced by the square root of the value, so they are fine enough for the steep gamma curve in the dark.
+const encodeTableSize = 4096
+
+// ToneMapper turns linear light colors into image (sRGB) bytes: the light is scaled by the exposure, compressed by
+// the tone mapping, and encoded with the gamma.
+//
+// A ToneMapper is not changed after it has been created, it is safe for concurrent use.
+type ToneMapper struct {
+	toneMapping ToneMapping
+	exposure    float64
+	encode      [encodeTableSize + 1]byte // Image byte of each tone mapped step (square root spaced), with the gamma
+}
+
+// NewToneMapper creates a tone mapper. The exposure scales the light before the tone mapping, 1.0 is unchanged.
+// The gamma is the display gamma (like 2.2), zero means the sRGB curve.
+func NewToneMapper(toneMapping ToneMapping, exposure float64, gamma float64) *ToneMapper {
+	t := &ToneMapper{toneMapping: toneMapping, exposure: exposure}
+
+	for i := range t.encode {
+		value := float64(i) / encodeTableSize
+		value *= value
+		if gamma > 0.0 {
+			value = math.Pow(value, 1.0/gamma)
+		} else {
+			value = LinearToSRGB(value)
+		}
+		t.encode[i] = byte(math.Round(value * 255.0))
+	}
+
+	return t
+}
+
+// ToneMapping gives the tone mapping of the tone mapper.
+func (t *ToneMapper) ToneMapping() ToneMapping {
+	return t.toneMapping
+}
+
+// Map gives the tone mapped value of a linear light value (with the exposure), range [0.0, 1.0].
+func (t *ToneMapper) Map(value float64) float64 {
+	value = max(0.0, value*t.exposure)
+
+	switch t.toneMapping {
+	case ToneMappingReinhard:
+		value = value / (1.0 + value)
+	case ToneMappingACES:
+		value = (value * (2.51*value + 0.03)) / (value*(2.43*value+0.59) + 0.14)
+	}
+
+	return min(1.0, value)
+}
+
+// Bytes gives the image bytes of a linear light color.
+func (t *ToneMapper) Bytes(c Color) (r, g, b byte) {
+	return t.encode[int(math.Sqrt(t.Map(c.R))*encodeTableSize+0.5)],
+		t.encode[int(math.Sqrt(t.Map(c.G))*encodeTableSize+0.5)],
+		t.encode[int(math.Sqrt(t.Map(c.B))*encodeTableSize+0.5)]
+}
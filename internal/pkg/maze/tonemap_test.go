@@ -0,0 +1,72 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestToneMapperMap(t *testing.T) {
+	clamp := NewToneMapper(ToneMappingClamp, 1.0, 0.0)
+	assert.Equal(t, 0.0, clamp.Map(-1.0))
+	assert.Equal(t, 0.5, clamp.Map(0.5))
+	assert.Equal(t, 1.0, clamp.Map(4.0))
+
+	reinhard := NewToneMapper(ToneMappingReinhard, 1.0, 0.0)
+	assert.Equal(t, 0.0, reinhard.Map(0.0))
+	assert.Equal(t, 0.5, reinhard.Map(1.0))
+	assert.Equal(t, 0.8, reinhard.Map(4.0))
+	assert.Less(t, reinhard.Map(1000.0), 1.0, "never quite white")
+
+	aces := NewToneMapper(ToneMappingACES, 1.0, 0.0)
+	assert.Equal(t, 0.0, aces.Map(0.0))
+	assert.InDelta(t, 0.803797, aces.Map(1.0), 0.000001)
+	assert.Equal(t, 1.0, aces.Map(1000.0), "white at last")
+	assert.Less(t, aces.Map(0.01), 0.01, "toe in the dark")
+
+	exposed := NewToneMapper(ToneMappingReinhard, 2.0, 0.0)
+	assert.Equal(t, reinhard.Map(2.0), exposed.Map(1.0))
+
+	for _, toneMapper := range []*ToneMapper{clamp, reinhard, aces} {
+		previous := 0.0
+		for value := 0.0; value < 10.0; value += 0.01 {
+			mapped := toneMapper.Map(value)
+			assert.GreaterOrEqual(t, mapped, previous, "brighter light is never darker on the screen")
+			previous = mapped
+		}
+	}
+}
+
+func TestToneMapperBytes(t *testing.T) {
+	srgb := NewToneMapper(ToneMappingClamp, 1.0, 0.0)
+
+	r, g, b := srgb.Bytes(NewColor(0.0, 1.0, 8.0))
+	assert.Equal(t, []byte{0, 255, 255}, []byte{r, g, b})
+
+	for i := 0; i < 256; i++ {
+		r, _, _ = srgb.Bytes(NewColorFromSRGBByte(byte(i), 0, 0))
+		assert.Equal(t, byte(i), r, "sRGB bytes are unchanged by the sRGB round trip")
+	}
+
+	gamma := NewToneMapper(ToneMappingClamp, 1.0, 2.2)
+	r, g, b = gamma.Bytes(NewColor(0.5, 0.218, 0.0))
+	assert.Equal(t, []byte{186, 128, 0}, []byte{r, g, b})
+
+	reinhard := NewToneMapper(ToneMappingReinhard, 1.0, 0.0)
+	r, g, b = reinhard.Bytes(NewColor(1.0, 4.0, 16.0)) // Tone mapped to 1/2, 4/5 and 16/17
+	assert.InDelta(t, 255.0*LinearToSRGB(1.0/2.0), float64(r), 1.0)
+	assert.InDelta(t, 255.0*LinearToSRGB(4.0/5.0), float64(g), 1.0)
+	assert.InDelta(t, 255.0*LinearToSRGB(16.0/17.0), float64(b), 1.0, "bright light keeps its detail instead of washing out to white")
+
+	for value := 0.0; value <= 1.0; value += 0.001 {
+		r, _, _ = gamma.Bytes(NewColor(value, 0.0, 0.0))
+		assert.InDelta(t, math.Pow(value, 1.0/2.2)*255.0, float64(r), 1.0, "encoded with the gamma at %g", value)
+	}
+}
+
+func TestToneMappingString(t *testing.T) {
+	assert.Equal(t, "CLAMP", ToneMappingClamp.String())
+	assert.Equal(t, "REINHARD", ToneMappingReinhard.String())
+	assert.Equal(t, "ACES", ToneMappingACES.String())
+	assert.Equal(t, ToneMappingACES, NewToneMapper(ToneMappingACES, 1.0, 0.0).ToneMapping())
+}
@@ -0,0 +1,180 @@
+package maze
+
+import "math"
+
+// Weapon is a weapon of the player. The weapons are in order: the player owns all weapons up to the best one.
+type Weapon int
+
+const (
+	WeaponKnife      Weapon = iota // Stabs an actor right in front of the player, needs no ammo
+	WeaponPistol                   // One shot for each pull of the trigger
+	WeaponMachineGun               // Keeps shooting while the trigger is held
+	WeaponChainGun                 // Keeps shooting, twice as fast as the machine gun
+)
+
+func (w Weapon) String() string {
+	return [...]string{"knife", "pistol", "machine gun", "chain gun"}[w]
+}
+
+const (
+	PlayerAmmo = 8  // Ammo of the player at the start
+	MaxAmmo    = 99 // Most ammo the player can carry
+
+	knifeReach = 1.5 // Distance (in map cells) the knife reaches
+)
+
+// weaponInfo describes how a weapon attacks.
+type weaponInfo struct {
+	interval  float64 // Seconds between two attacks
+	automatic bool    // If holding the trigger keeps attacking, instead of attacking once for each pull
+	gun       bool    // If the weapon shoots: it uses a bullet for each shot and makes noise
+}
+
+// weapons holds the information on each weapon, with the attack times of the original game.
+var weapons = [...]weaponInfo{
+	WeaponKnife:      {interval: originalTicks(24.0)},
+	WeaponPistol:     {interval: originalTicks(24.0), gun: true},
+	WeaponMachineGun: {interval: originalTicks(12.0), automatic: true, gun: true},
+	WeaponChainGun:   {interval: originalTicks(6.0), automatic: true, gun: true},
+}
+
+// SelectWeapon switches to a weapon, if the player owns it and has ammo for it. Gives true if the weapon is selected.
+func (p *Player) SelectWeapon(weapon Weapon) bool {
+	if weapon < WeaponKnife || weapon > p.BestWeapon || (weapons[weapon].gun && p.Ammo <= 0) {
+		return false
+	}
+
+	p.Weapon = weapon
+	p.chosenWeapon = weapon
+	return true
+}
+
+// GiveWeapon gives the player a weapon (and the weapons before it). A weapon better than the ones owned is selected.
+func (p *Player) GiveWeapon(weapon Weapon) {
+	if weapon <= p.BestWeapon {
+		return
+	}
+
+	p.BestWeapon = weapon
+	p.Weapon = weapon
+	p.chosenWeapon = weapon
+}
+
+// GiveAmmo gives the player ammo, up to MaxAmmo. A player that ran out of ammo switches back to the weapon chosen
+// before. Gives false if the player cannot carry more ammo.
+func (p *Player) GiveAmmo(ammo int) bool {
+	if p.Ammo >= MaxAmmo {
+		return false
+	}
+
+	if p.Ammo <= 0 && p.Weapon == WeaponKnife {
+		p.Weapon = p.chosenWeapon
+	}
+	p.Ammo = min(MaxAmmo, p.Ammo+ammo)
+	return true
+}
+
+// updateWeapon attacks with the weapon of the player while the trigger is pulled, as fast as the weapon allows.
+func (w *World) updateWeapon(dt float64) {
+	player := w.Player
+	player.attackCooldown = max(0.0, player.attackCooldown-dt)
+
+	if !player.Trigger {
+		player.triggerHeld = false
+		return
+	}
+
+	weapon := weapons[player.Weapon]
+	if player.attackCooldown > timeMargin || (player.triggerHeld && !weapon.automatic) {
+		return
+	}
+
+	player.triggerHeld = true
+	player.attackCooldown = weapon.interval
+	if weapon.gun {
+		w.shoot()
+	} else {
+		w.stab()
+	}
+}
+
+// stab stabs the actor right in front of the player with the knife, if any. Stabbing makes no noise.
+func (w *World) stab() {
+	player := w.Player
+	if actor, _ := w.Hitscan(player.Position, *player.Direction(), knifeReach); actor != nil {
+		actor.Damage(w, w.random()>>4)
+	}
+}
+
+// shoot shoots a bullet along the view of the player, at the nearest actor in the way. The shot is heard by the
+// actors around (see MakeNoise). Like in the original game, the further away the actor is, the less damage the shot
+// does, and far away actors can be missed. A player out of ammo switches to the knife.
+func (w *World) shoot() {
+	player := w.Player
+	player.Ammo--
+	if player.Ammo <= 0 {
+		player.Ammo = 0
+		player.Weapon = WeaponKnife
+	}
+
+	w.MakeNoise(player.Cell())
+
+	actor, _ := w.Hitscan(player.Position, *player.Direction(), 0.0)
+	if actor == nil {
+		return
+	}
+
+	var damage int
+	switch distance := actor.cellDistance(player); {
+	case distance < 2:
+		damage = w.random() / 4
+	case distance < 4:
+		damage = w.random() / 6
+	default:
+		if w.random()/12 < distance {
+			return // Missed
+		}
+		damage = w.random() / 6
+	}
+
+	actor.Damage(w, damage)
+}
+
+// Hitscan finds the nearest living actor hit by a ray from a position in a direction (of length 1), before the ray
+// hits a wall or a closed door (see RaycastRay). Nothing further away than reach is hit, zero means no maximum.
+// Gives the actor hit (nil if none) and the distance to it.
+func (w *World) Hitscan(from Vector, direction Vector, reach float64) (*Actor, float64) {
+	wallDistance := math.Inf(1)
+	if intersection := RaycastRayWithin(&from, &direction, reach, w.Map); intersection.Hit {
+		wallDistance = intersection.PerpendicularDistance
+	}
+	if reach > 0.0 {
+		wallDistance = min(wallDistance, reach)
+	}
+
+	var hit *Actor
+	hitDistance := wallDistance
+	for _, actor := range w.Actors {
+		if !actor.IsAlive() {
+			continue
+		}
+
+		// The distance along the ray where it enters the circle of the actor
+		toActor := actor.Position.Sub(&from)
+		along := toActor.X*direction.X + toActor.Y*direction.Y
+		across := toActor.X*toActor.X + toActor.Y*toActor.Y - along*along
+		if along < 0.0 || across > actor.Radius*actor.Radius {
+			continue
+		}
+
+		if distance := max(0.0, along-math.Sqrt(actor.Radius*actor.Radius-across)); distance < hitDistance {
+			hit = actor
+			hitDistance = distance
+		}
+	}
+
+	if hit == nil {
+		return nil, 0.0
+	}
+	return hit, hitDistance
+}
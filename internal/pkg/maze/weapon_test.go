@@ -0,0 +1,256 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"maze/internal/pkg/wolf3d"
+	"testing"
+)
+
+func TestHitscan(t *testing.T) {
+	room := raycastmap.NewGridMap(
+		"##########",
+		"#........#",
+		"#......#.#",
+		"#........#",
+		"##########",
+	)
+
+	world := NewWorld(room, wolf3d.DifficultyHard)
+	near := world.Spawn(NewActor(wolf3d.EnemyGuard, ActorStanding, 4, 1, 0.0, nil))
+	far := world.Spawn(NewActor(wolf3d.EnemyGuard, ActorStanding, 7, 1, 0.0, nil))
+	behindWall := world.Spawn(NewActor(wolf3d.EnemyGuard, ActorStanding, 8, 2, 0.0, nil))
+	east := Vector{X: 1.0, Y: 0.0}
+
+	actor, distance := world.Hitscan(Vector{X: 1.5, Y: 1.5}, east, 0.0)
+	assert.Same(t, near, actor, "the nearest actor")
+	assert.InDelta(t, 3.0-ActorRadius, distance, 0.000001)
+
+	actor, _ = world.Hitscan(Vector{X: 1.5, Y: 1.6}, east, 0.0)
+	assert.Same(t, near, actor, "off the middle, but within the radius")
+
+	actor, _ = world.Hitscan(Vector{X: 1.5, Y: 1.5}, *NewDirectionVector(math.Pi / 4.0), 0.0)
+	assert.Nil(t, actor, "nobody in the way")
+
+	actor, _ = world.Hitscan(Vector{X: 1.5, Y: 1.5}, east, 2.0)
+	assert.Nil(t, actor, "out of reach")
+
+	near.State = ActorDead
+	actor, _ = world.Hitscan(Vector{X: 1.5, Y: 1.5}, east, 0.0)
+	assert.Same(t, far, actor, "dead actors are not hit")
+
+	actor, _ = world.Hitscan(Vector{X: 1.5, Y: 2.5}, east, 0.0)
+	assert.Nil(t, actor, "the wall is in the way")
+	assert.True(t, behindWall.IsAlive())
+
+	actor, _ = world.Hitscan(Vector{X: 8.5, Y: 3.5}, Vector{X: 0.0, Y: -1.0}, 0.0)
+	assert.Same(t, behindWall, actor, "from the other side of the wall")
+}
+
+func TestHitscanDoor(t *testing.T) {
+	corridor := raycastmap.NewGridMap(
+		"#######",
+		"#..|..#",
+		"#######",
+	)
+	door := corridor.DoorAt(3, 1)
+
+	world := NewWorld(corridor, wolf3d.DifficultyHard)
+	guard := world.Spawn(NewActor(wolf3d.EnemyGuard, ActorStanding, 5, 1, 0.0, nil))
+
+	actor, _ := world.Hitscan(Vector{X: 1.5, Y: 1.5}, Vector{X: 1.0, Y: 0.0}, 0.0)
+	assert.Nil(t, actor, "the closed door is in the way")
+
+	door.Offset = 1.0
+	actor, _ = world.Hitscan(Vector{X: 1.5, Y: 1.5}, Vector{X: 1.0, Y: 0.0}, 0.0)
+	assert.Same(t, guard, actor, "through the open door")
+}
+
+func TestPlayerShoots(t *testing.T) {
+	room := raycastmap.NewGridMap(
+		"##########",
+		"#........#",
+		"#........#",
+		"##########",
+	)
+
+	world := NewWorld(room, wolf3d.DifficultyHard)
+	ss := world.Spawn(NewActor(wolf3d.EnemySS, ActorStanding, 3, 1, 0.0, nil))
+	assert.Equal(t, WeaponPistol, world.Player.Weapon)
+	assert.Equal(t, PlayerAmmo, world.Player.Ammo)
+	assert.Equal(t, 100, ss.Health)
+
+	world.Player.Trigger = true
+	world.Update(testTick)
+	assert.Less(t, ss.Health, 100)
+	assert.Equal(t, ActorChasing, ss.State, "a shot actor chases the player right away")
+	runTicks(world, nil, 70)
+	assert.Equal(t, PlayerAmmo-1, world.Player.Ammo, "one shot for each pull of the pistol trigger")
+
+	world.Player.Trigger = false
+	world.Update(testTick)
+	world.Player.Trigger = true
+	world.Update(testTick)
+	assert.Equal(t, PlayerAmmo-2, world.Player.Ammo, "pulled again")
+	runTicks(world, nil, 24)
+
+	world.Player.GiveWeapon(WeaponMachineGun)
+	assert.Equal(t, WeaponMachineGun, world.Player.Weapon, "a better weapon is taken in hand")
+	runTicks(world, nil, 24)
+	assert.Equal(t, PlayerAmmo-4, world.Player.Ammo, "the machine gun keeps shooting, once every 12 ticks")
+
+	world.Player.GiveWeapon(WeaponChainGun)
+	runTicks(world, nil, 24)
+	assert.Equal(t, PlayerAmmo-8, world.Player.Ammo, "the chain gun shoots twice as fast")
+}
+
+func TestPlayerOutOfAmmo(t *testing.T) {
+	room := raycastmap.NewGridMap(
+		"#####",
+		"#...#",
+		"#####",
+	)
+
+	world := NewWorld(room, wolf3d.DifficultyHard)
+	world.Player.Ammo = 1
+	world.Player.Trigger = true
+	world.Update(testTick)
+	assert.Equal(t, 0, world.Player.Ammo)
+	assert.Equal(t, WeaponKnife, world.Player.Weapon, "out of ammo")
+	assert.False(t, world.Player.SelectWeapon(WeaponPistol), "no ammo for the pistol")
+	assert.False(t, world.Player.SelectWeapon(WeaponMachineGun), "not owned")
+
+	assert.True(t, world.Player.GiveAmmo(4))
+	assert.Equal(t, WeaponPistol, world.Player.Weapon, "back to the pistol")
+	assert.True(t, world.Player.SelectWeapon(WeaponKnife))
+
+	world.Player.Ammo = MaxAmmo - 1
+	assert.True(t, world.Player.GiveAmmo(4))
+	assert.Equal(t, MaxAmmo, world.Player.Ammo)
+	assert.False(t, world.Player.GiveAmmo(4), "cannot carry more")
+	assert.Equal(t, WeaponKnife, world.Player.Weapon, "the knife stays in hand")
+}
+
+func TestPlayerStabs(t *testing.T) {
+	room := raycastmap.NewGridMap(
+		"#######",
+		"#.....#",
+		"#######",
+	)
+
+	world := NewWorld(room, wolf3d.DifficultyHard)
+	dog := world.Spawn(NewActor(wolf3d.EnemyDog, ActorStanding, 4, 1, 0.0, nil))
+	world.Player.SelectWeapon(WeaponKnife)
+
+	world.Player.Trigger = true
+	world.Update(testTick)
+	assert.True(t, dog.IsAlive(), "out of reach of the knife")
+	assert.False(t, dog.noticed, "the knife makes no noise")
+	assert.Equal(t, PlayerAmmo, world.Player.Ammo)
+
+	world.Player.Position = Vector{X: 3.5, Y: 1.5}
+	world.Player.Trigger = false
+	world.Update(testTick)
+	for tick := 0; tick < 100 && dog.IsAlive(); tick++ {
+		world.Player.Trigger = !world.Player.Trigger
+		world.Update(testTick)
+	}
+	assert.False(t, dog.IsAlive(), "stabbed")
+	assert.Empty(t, world.Items, "dogs drop nothing")
+}
+
+func TestShotIsHeard(t *testing.T) {
+	rooms := raycastmap.NewGridMap(
+		"##########",
+		"#....#...#",
+		"#........#",
+		"#....#...#",
+		"##########",
+	)
+
+	world := NewWorld(rooms, wolf3d.DifficultyHard)
+	world.Player.Heading = math.Pi // Facing the wall, away from the guards
+	nextRoom := world.Spawn(NewActor(wolf3d.EnemyGuard, ActorStanding, 7, 3, 0.0, nil))
+
+	world.Player.Trigger = true
+	world.Update(testTick)
+	assert.True(t, nextRoom.noticed, "heard through the opening")
+	assert.Equal(t, ActorStanding, nextRoom.State, "reacts after its reaction time")
+}
+
+func TestActorDies(t *testing.T) {
+	room := raycastmap.NewGridMap(
+		"#######",
+		"#.....#",
+		"#######",
+	)
+	tiles := raycastmap.DefaultTileTable()
+
+	world := NewWorld(room, wolf3d.DifficultyHard)
+	guard := world.Spawn(NewActor(wolf3d.EnemyGuard, ActorPatrolling, 3, 1, 0.0, tiles.SpecialNamed("BrownGuard")))
+	ss := world.Spawn(NewActor(wolf3d.EnemySS, ActorStanding, 5, 1, 0.0, nil))
+
+	guard.Damage(world, 10)
+	assert.Equal(t, 5, guard.Health, "double damage when not chasing the player yet")
+	assert.Equal(t, ActorChasing, guard.State)
+	guard.Damage(world, 5)
+	assert.Equal(t, 0, guard.Health)
+	assert.Equal(t, ActorDead, guard.State)
+	assert.Same(t, tiles.SpecialNamed("DeadGuard"), guard.Structure, "a corpse")
+
+	guard.Damage(world, 5)
+	assert.Equal(t, 0, guard.Health, "dead already")
+
+	assert.Len(t, world.Items, 1)
+	clip := world.Items[0]
+	assert.Equal(t, wolf3d.PickupClip, clip.Pickup)
+	assert.Equal(t, Vector{X: 3.5, Y: 1.5}, clip.Position)
+	assert.Same(t, tiles.SpecialNamed("AmmoClip"), clip.Structure)
+	assert.Contains(t, world.Sprites(), Sprite{Position: &clip.Position, Structure: clip.Structure})
+
+	ss.Damage(world, 200)
+	assert.Nil(t, ss.Structure, "no corpse for an SS")
+	assert.Len(t, world.Items, 2)
+	assert.Equal(t, wolf3d.PickupMachineGun, world.Items[1].Pickup, "an SS drops a machine gun")
+
+	world.Player.Position = Vector{X: 3.5, Y: 1.5}
+	world.Update(testTick)
+	assert.True(t, clip.Taken)
+	assert.Equal(t, PlayerAmmo+droppedClipAmmo, world.Player.Ammo)
+	assert.NotContains(t, world.Sprites(), Sprite{Position: &clip.Position, Structure: clip.Structure})
+
+	world.Player.Position = Vector{X: 5.5, Y: 1.5}
+	world.Update(testTick)
+	assert.True(t, world.Items[1].Taken)
+	assert.Equal(t, WeaponMachineGun, world.Player.BestWeapon)
+	assert.Equal(t, WeaponMachineGun, world.Player.Weapon)
+	assert.Equal(t, PlayerAmmo+droppedClipAmmo+machineGunAmmo, world.Player.Ammo)
+}
+
+func TestPlayerPicksUpMapItems(t *testing.T) {
+	roomMap := newObjectTestMap(map[raycastmap.Cell]wolf3d.Object{
+		{X: 2, Y: 1}: {Kind: wolf3d.ObjectPickup, Pickup: wolf3d.PickupClip},
+		{X: 3, Y: 1}: {Kind: wolf3d.ObjectPickup, Pickup: wolf3d.PickupChainGun},
+		{X: 4, Y: 1}: {Kind: wolf3d.ObjectPickup, Pickup: wolf3d.PickupCross},
+	})
+
+	world := NewWorld(roomMap, wolf3d.DifficultyHard)
+	assert.Len(t, world.Items, 2, "only the weapons and ammo are items")
+	clip, chainGun := world.Items[0], world.Items[1]
+	assert.Equal(t, Vector{X: 2.5, Y: 1.5}, clip.Position)
+	assert.False(t, clip.Dropped)
+
+	world.Player.Position = clip.Position
+	world.Update(testTick)
+	assert.True(t, clip.Taken)
+	assert.Equal(t, PlayerAmmo+clipAmmo, world.Player.Ammo, "a clip of the map is full")
+
+	world.Player.Position = chainGun.Position
+	world.Update(testTick)
+	assert.True(t, chainGun.Taken)
+	assert.Equal(t, WeaponChainGun, world.Player.BestWeapon)
+	assert.Equal(t, WeaponChainGun, world.Player.Weapon)
+	assert.Equal(t, PlayerAmmo+clipAmmo+chainGunAmmo, world.Player.Ammo)
+	assert.True(t, world.Player.SelectWeapon(WeaponMachineGun), "the weapons before the chain gun are owned too")
+}
@@ -0,0 +1,244 @@
+package maze
+
+import (
+	"math/rand/v2"
+	"maze/internal/pkg/pathfinding"
+	"maze/internal/pkg/raycastmap"
+	"maze/internal/pkg/wolf3d"
+)
+
+// World is the state of the game: the map, the player and the actors in it. The simulation (see World.Update), the AI
+// and the rendering all work on it.
+type World struct {
+	Map    raycastmap.Map
+	Player *Player
+	Actors []*Actor // All actors, dead ones too
+	Items  []*Item  // Weapons and ammo of the map and items dropped by actors, taken ones too
+
+	// Random gives the random numbers of the simulation (reaction times, hit chances, damage...). It starts with the
+	// same seed for each world, replace it to play differently each time.
+	Random *rand.Rand
+
+	tiles         *raycastmap.TileTable       // Specials for corpses and dropped items
+	statics       []Sprite                    // Sprites of the map that are not actors (barrels, lamps, treasures...)
+	cells         [][]*Actor                  // Actors per map cell (index y * width + x), see ActorsAt
+	turningPoints map[[2]int]wolf3d.Direction // Patrol turning points per map cell
+	chaseField    *pathfinding.FlowField      // Ways to the player, see ChaseField
+}
+
+// NewWorld creates the world of a map, with the player at the map start point. If the map has objects (see
+// raycastmap.ObjectMap), an actor is spawned for each enemy present in a difficulty, and the weapons and ammo of the
+// map are items to pick up.
+//
+// Corpses and dropped items look like the specials of the tile table of the map (see raycastmap.WolfensteinMap.Tiles),
+// or of the default tile table for maps without one.
+func NewWorld(worldMap raycastmap.Map, difficulty wolf3d.Difficulty) *World {
+	w := &World{
+		Map:           worldMap,
+		Player:        NewPlayer(Vector{X: worldMap.StartX(), Y: worldMap.StartY()}, worldMap.StartDir()),
+		Random:        rand.New(rand.NewPCG(1, 2)),
+		tiles:         raycastmap.DefaultTileTable(),
+		cells:         make([][]*Actor, worldMap.Width()*worldMap.Height()),
+		turningPoints: make(map[[2]int]wolf3d.Direction),
+	}
+	if tiled, ok := worldMap.(interface{ Tiles() *raycastmap.TileTable }); ok {
+		w.tiles = tiled.Tiles()
+	}
+
+	nonStaticCells := make(map[[2]int]bool) // Cells of the actors and the items, their sprites are not static
+	if objects, ok := worldMap.(raycastmap.ObjectMap); ok {
+		for y := 0; y < worldMap.Height(); y++ {
+			for x := 0; x < worldMap.Width(); x++ {
+				object := objects.ObjectAt(x, y)
+				if object.Kind == wolf3d.ObjectTurningPoint {
+					w.turningPoints[[2]int{x, y}] = object.Direction
+				}
+				if object.Kind == wolf3d.ObjectPickup && isWeaponOrAmmo(object.Pickup) {
+					nonStaticCells[[2]int{x, y}] = true
+					w.Items = append(w.Items, &Item{
+						Position:  Vector{X: float64(x) + 0.5, Y: float64(y) + 0.5},
+						Pickup:    object.Pickup,
+						Structure: worldMap.SpecialAt(x, y),
+					})
+				}
+				if object.Kind != wolf3d.ObjectEnemy {
+					continue
+				}
+
+				nonStaticCells[[2]int{x, y}] = true
+				if object.Difficulty > difficulty {
+					continue // Not present in the difficulty
+				}
+
+				state := ActorStanding
+				if object.Patrol {
+					state = ActorPatrolling
+				}
+				w.Spawn(NewActor(object.Enemy, state, x, y, object.Direction.Angle(), worldMap.SpecialAt(x, y)))
+			}
+		}
+	}
+
+	for _, sprite := range CollectSprites(worldMap) {
+		if !nonStaticCells[[2]int{int(sprite.Position.X), int(sprite.Position.Y)}] {
+			w.statics = append(w.statics, sprite)
+		}
+	}
+
+	return w
+}
+
+// KeepPlayer takes over the player of a previous world, like when the player goes on to the next level. The player keeps
+// its health, weapons and ammo, and starts at the start point of this world with the trigger let go.
+func (w *World) KeepPlayer(player *Player) {
+	player.Position, player.Heading = w.Player.Position, w.Player.Heading
+	player.Trigger, player.triggerHeld, player.attackCooldown = false, false, 0.0
+	w.Player = player
+}
+
+// Spawn adds an actor to the world.
+func (w *World) Spawn(actor *Actor) *Actor {
+	actor.ID = len(w.Actors)
+	w.Actors = append(w.Actors, actor)
+	w.indexActor(actor)
+	return actor
+}
+
+// Update runs the simulation for one tick of dt seconds: the player (if alive) picks up the items it walks over and
+// attacks while the trigger is pulled (see Player.Trigger), then the actors act.
+func (w *World) Update(dt float64) {
+	if w.Player.IsAlive() {
+		w.pickUpItems()
+		w.updateWeapon(dt)
+	}
+
+	for _, actor := range w.Actors {
+		actor.Update(w, dt)
+	}
+
+	w.indexActors()
+}
+
+// ActorsAt gives the actors (dead ones too) with their middle in map cell x, y.
+// The actors are indexed by cell at spawning and after each update.
+func (w *World) ActorsAt(x, y int) []*Actor {
+	if x < 0 || y < 0 || x >= w.Map.Width() || y >= w.Map.Height() {
+		return nil
+	}
+
+	return w.cells[y*w.Map.Width()+x]
+}
+
+// Occupied reports if the player or a living actor is (partly) inside map cell x, y, like in the way of a closing door.
+func (w *World) Occupied(x, y int) bool {
+	if w.Player.InCell(x, y) {
+		return true
+	}
+
+	// Actors are smaller than a cell, so only actors in the cell and the cells around it can be in the cell
+	for cellY := y - 1; cellY <= y+1; cellY++ {
+		for cellX := x - 1; cellX <= x+1; cellX++ {
+			for _, actor := range w.ActorsAt(cellX, cellY) {
+				if actor.IsAlive() && actor.InCell(x, y) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// MakeNoise makes a noise in map cell x, y (like a gunshot). The actors that hear it notice the player: the actors
+// in the map cells the noise reaches without passing walls or closed doors.
+func (w *World) MakeNoise(x, y int) {
+	width, height := w.Map.Width(), w.Map.Height()
+	heard := make([]bool, width*height)
+	cells := [][2]int{{x, y}}
+
+	for len(cells) > 0 {
+		cell := cells[len(cells)-1]
+		cells = cells[:len(cells)-1]
+
+		cellX, cellY := cell[0], cell[1]
+		if cellX < 0 || cellY < 0 || cellX >= width || cellY >= height || heard[cellY*width+cellX] {
+			continue
+		}
+		if door := w.Map.DoorAt(cellX, cellY); door != nil {
+			if door.State == raycastmap.DoorClosed {
+				continue
+			}
+		} else if w.Map.WallAt(cellX, cellY) {
+			continue
+		}
+
+		heard[cellY*width+cellX] = true
+		for _, actor := range w.ActorsAt(cellX, cellY) {
+			actor.notice(w)
+		}
+
+		cells = append(cells, [2]int{cellX + 1, cellY}, [2]int{cellX - 1, cellY}, [2]int{cellX, cellY + 1}, [2]int{cellX, cellY - 1})
+	}
+}
+
+// Sprites gives the sprites to render: the sprites of the map, the actors (and their corpses) and the items not taken.
+func (w *World) Sprites() []Sprite {
+	sprites := make([]Sprite, len(w.statics), len(w.statics)+len(w.Actors)+len(w.Items))
+	copy(sprites, w.statics)
+
+	for _, item := range w.Items {
+		if !item.Taken && item.Structure != nil && item.Structure.Texture != nil {
+			sprites = append(sprites, Sprite{Position: &item.Position, Structure: item.Structure})
+		}
+	}
+
+	for _, actor := range w.Actors {
+		if actor.Structure != nil && actor.Structure.Texture != nil {
+			sprites = append(sprites, Sprite{Position: &actor.Position, Structure: actor.Structure})
+		}
+	}
+
+	return sprites
+}
+
+// indexActors indexes all actors by map cell, see ActorsAt.
+func (w *World) indexActors() {
+	for i := range w.cells {
+		w.cells[i] = w.cells[i][:0]
+	}
+
+	for _, actor := range w.Actors {
+		w.indexActor(actor)
+	}
+}
+
+func (w *World) indexActor(actor *Actor) {
+	x, y := actor.Cell()
+	if x < 0 || y < 0 || x >= w.Map.Width() || y >= w.Map.Height() {
+		return
+	}
+
+	index := y*w.Map.Width() + x
+	w.cells[index] = append(w.cells[index], actor)
+}
+
+// ChaseField gives the flow field with the ways to the player (the map cell of the player) from every map cell, the
+// way chasing actors walk. The actors walk like the player (see pathfinding.Options): along x and y only, and through
+// doors.
+func (w *World) ChaseField() *pathfinding.FlowField {
+	if w.chaseField == nil {
+		w.chaseField = pathfinding.NewFlowField(pathfinding.NewGrid(w.Map, pathfinding.Options{}))
+	}
+
+	x, y := w.Player.Cell()
+	if target := (pathfinding.Cell{X: x, Y: y}); len(w.chaseField.Targets()) == 0 || w.chaseField.Targets()[0] != target {
+		w.chaseField.Update(target)
+	}
+
+	return w.chaseField
+}
+
+// random gives a random number, range [0, 255], like the original game.
+func (w *World) random() int {
+	return w.Random.IntN(256)
+}
@@ -0,0 +1,168 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"maze/internal/pkg/wolf3d"
+	"testing"
+)
+
+// objectTestMap is a SliceMap with Wolfenstein 3D objects, like guards and dogs.
+type objectTestMap struct {
+	raycastmap.SliceMap
+	objects map[raycastmap.Cell]wolf3d.Object
+}
+
+func (m objectTestMap) ObjectAt(x, y int) wolf3d.Object {
+	if object, ok := m.objects[raycastmap.Cell{X: x, Y: y}]; ok {
+		object.X, object.Y = x, y
+		return object
+	}
+	return wolf3d.Object{X: x, Y: y, Kind: wolf3d.ObjectNone}
+}
+
+// newObjectTestMap creates an object test map of a room (x 1-6, y 1-4) with the start point at 1.5, 1.5 facing north.
+func newObjectTestMap(objects map[raycastmap.Cell]wolf3d.Object) objectTestMap {
+	room := make([][]int, 8)
+	for x := range room {
+		room[x] = []int{1, 0, 0, 0, 0, 1}
+		if x == 0 || x == len(room)-1 {
+			room[x] = []int{1, 1, 1, 1, 1, 1}
+		}
+	}
+
+	return objectTestMap{SliceMap: raycastmap.NewSliceMap(room, 1.5, 1.5, math.Pi/2.0, wallValueToStructure), objects: objects}
+}
+
+func TestNewWorld(t *testing.T) {
+	roomMap := newObjectTestMap(map[raycastmap.Cell]wolf3d.Object{
+		{X: 3, Y: 2}: {Kind: wolf3d.ObjectEnemy, Enemy: wolf3d.EnemyGuard, Direction: wolf3d.DirectionWest},
+		{X: 5, Y: 3}: {Kind: wolf3d.ObjectEnemy, Enemy: wolf3d.EnemyDog, Direction: wolf3d.DirectionSouth, Patrol: true},
+		{X: 6, Y: 4}: {Kind: wolf3d.ObjectEnemy, Enemy: wolf3d.EnemySS, Difficulty: wolf3d.DifficultyHard},
+		{X: 2, Y: 4}: {Kind: wolf3d.ObjectPickup},
+	})
+
+	world := NewWorld(roomMap, wolf3d.DifficultyMedium)
+	assert.Equal(t, Vector{X: 1.5, Y: 1.5}, world.Player.Position)
+	assert.Equal(t, math.Pi/2.0, world.Player.Heading)
+	assert.Equal(t, PlayerRadius, world.Player.Radius)
+
+	assert.Len(t, world.Actors, 2, "the SS is only present in the hard difficulty")
+
+	guard := world.Actors[0]
+	assert.Equal(t, 0, guard.ID)
+	assert.Equal(t, wolf3d.EnemyGuard, guard.Kind)
+	assert.Equal(t, ActorStanding, guard.State)
+	assert.Equal(t, Vector{X: 3.5, Y: 2.5}, guard.Position)
+	assert.Equal(t, math.Pi, guard.Heading)
+	assert.Equal(t, ActorRadius, guard.Radius)
+
+	dog := world.Actors[1]
+	assert.Equal(t, 1, dog.ID)
+	assert.Equal(t, wolf3d.EnemyDog, dog.Kind)
+	assert.Equal(t, ActorPatrolling, dog.State)
+
+	assert.Equal(t, []*Actor{guard}, world.ActorsAt(3, 2))
+	assert.Empty(t, world.ActorsAt(6, 4))
+	assert.Empty(t, world.ActorsAt(-1, 2), "outside the map")
+
+	assert.Len(t, NewWorld(roomMap, wolf3d.DifficultyHard).Actors, 3)
+	assert.Empty(t, NewWorld(roomMap.SliceMap, wolf3d.DifficultyHard).Actors, "a map without objects")
+}
+
+func TestWorldKeepPlayer(t *testing.T) {
+	roomMap := newObjectTestMap(nil)
+	previous := NewWorld(roomMap, wolf3d.DifficultyHard)
+	player := previous.Player
+	player.Position = Vector{X: 5.5, Y: 3.5}
+	player.Health = 40
+	player.GiveWeapon(WeaponMachineGun)
+	player.Ammo = 20
+	player.Trigger = true
+	previous.Update(testTick)
+
+	world := NewWorld(roomMap, wolf3d.DifficultyHard)
+	world.KeepPlayer(player)
+	assert.Same(t, player, world.Player)
+	assert.Equal(t, Vector{X: 1.5, Y: 1.5}, player.Position, "at the start point")
+	assert.Equal(t, math.Pi/2.0, player.Heading)
+	assert.Equal(t, 40, player.Health)
+	assert.Equal(t, WeaponMachineGun, player.Weapon)
+	assert.Equal(t, WeaponMachineGun, player.BestWeapon)
+	assert.Equal(t, 19, player.Ammo)
+	assert.False(t, player.Trigger, "the trigger is let go")
+
+	player.Trigger = true
+	world.Update(testTick)
+	assert.Equal(t, 18, player.Ammo, "shoots right away")
+}
+
+func TestWorldUpdate(t *testing.T) {
+	roomMap := newObjectTestMap(map[raycastmap.Cell]wolf3d.Object{
+		{X: 3, Y: 2}: {Kind: wolf3d.ObjectEnemy, Enemy: wolf3d.EnemyGuard, Direction: wolf3d.DirectionEast},
+		{X: 3, Y: 3}: {Kind: wolf3d.ObjectEnemy, Enemy: wolf3d.EnemyGuard, Direction: wolf3d.DirectionEast, Patrol: true},
+	})
+	world := NewWorld(roomMap, wolf3d.DifficultyHard) // The player is behind the guards
+	standing, patrolling := world.Actors[0], world.Actors[1]
+
+	const dt = 1.0 / 70.0
+	for tick := 0; tick < 140; tick++ {
+		world.Update(dt)
+	}
+
+	assert.Equal(t, Vector{X: 3.5, Y: 2.5}, standing.Position, "standing still")
+	assert.InDelta(t, 3.5+2.0*patrolSpeed, patrolling.Position.X, 0.000001, "two seconds of patrolling east")
+	assert.InDelta(t, 3.5, patrolling.Position.Y, 0.000001)
+
+	assert.Empty(t, world.ActorsAt(3, 3))
+	assert.Equal(t, []*Actor{patrolling}, world.ActorsAt(4, 3), "indexed in the cell moved to")
+
+	for tick := 0; tick < 700; tick++ {
+		world.Update(dt)
+	}
+	assert.Equal(t, Vector{X: 6.5, Y: 3.5}, patrolling.Position, "stopped in the middle of the cell before the wall")
+	assert.Equal(t, ActorPatrolling, patrolling.State)
+}
+
+func TestWorldOccupied(t *testing.T) {
+	roomMap := newObjectTestMap(map[raycastmap.Cell]wolf3d.Object{
+		{X: 4, Y: 2}: {Kind: wolf3d.ObjectEnemy, Enemy: wolf3d.EnemyGuard},
+		{X: 6, Y: 4}: {Kind: wolf3d.ObjectEnemy, Enemy: wolf3d.EnemyGuard},
+	})
+	world := NewWorld(roomMap, wolf3d.DifficultyHard)
+	guard := world.Actors[0]
+	guard.Position = Vector{X: 4.9, Y: 2.5}
+	world.Actors[1].State = ActorDead
+	world.Update(0.0)
+
+	assert.True(t, world.Occupied(1, 1), "the player")
+	assert.True(t, world.Occupied(4, 2))
+	assert.True(t, world.Occupied(5, 2), "partly in the cell")
+	assert.False(t, world.Occupied(3, 2))
+	assert.False(t, world.Occupied(6, 4), "corpses are not in the way")
+}
+
+func TestWorldSprites(t *testing.T) {
+	levelMap, err := raycastmap.NewWolfensteinMap(0)
+	assert.NoError(t, err)
+
+	world := NewWorld(levelMap, wolf3d.DifficultyEasy)
+	assert.NotEmpty(t, world.Actors)
+
+	assert.NotEmpty(t, world.Items, "the weapons and ammo of the map")
+	sprites := world.Sprites()
+	assert.Len(t, sprites, len(world.statics)+len(world.Items)+len(world.Actors))
+
+	guard := world.Actors[0]
+	guard.Position.X += 0.25
+	assert.Contains(t, world.Sprites(), Sprite{Position: &guard.Position, Structure: guard.Structure}, "the sprite moves with the actor")
+
+	all := CollectSprites(levelMap)
+	for _, sprite := range world.statics {
+		object := levelMap.ObjectAt(int(sprite.Position.X), int(sprite.Position.Y))
+		assert.NotEqual(t, wolf3d.ObjectEnemy, object.Kind)
+		assert.False(t, isWeaponOrAmmo(object.Pickup), "the weapons and ammo are items")
+	}
+	assert.Less(t, len(world.statics), len(all), "the enemies of all difficulties are left out of the map sprites")
+}
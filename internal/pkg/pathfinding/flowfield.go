@@ -0,0 +1,94 @@
+package pathfinding
+
+import (
+	"container/heap"
+	"math"
+)
+
+// FlowField gives the cheapest way to a target from every map cell, like for many enemies chasing the player. The
+// flow field is computed once for a target (with Dijkstra), after that the way from any map cell is a lookup.
+// Update it when the target moves.
+type FlowField struct {
+	grid    *Grid
+	targets []Cell
+	costs   []float64 // Cost of the cheapest path to a target, per map cell
+	next    []int     // Next map cell (index) on the cheapest path to a target, per map cell. -1 if none.
+}
+
+// NewFlowField creates a flow field on a grid, without target: no map cell has a way to a target until Update.
+func NewFlowField(grid *Grid) *FlowField {
+	f := &FlowField{
+		grid:  grid,
+		costs: make([]float64, grid.Width()*grid.Height()),
+		next:  make([]int, grid.Width()*grid.Height()),
+	}
+	f.Update()
+
+	return f
+}
+
+// Update computes the cheapest way from every map cell to the closest (cheapest) of the targets.
+func (f *FlowField) Update(targets ...Cell) {
+	f.targets = append(f.targets[:0], targets...)
+	for i := range f.costs {
+		f.costs[i] = math.Inf(1)
+		f.next[i] = -1
+	}
+
+	queue := &priorityQueue{}
+	for _, target := range targets {
+		if f.grid.inside(target.X, target.Y) {
+			index := f.grid.index(target)
+			f.costs[index] = 0.0
+			heap.Push(queue, queueItem{index: index, priority: 0.0})
+		}
+	}
+
+	// Going backwards from the targets: the cost of a step from a neighbour into the current cell
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(queueItem)
+		current := item.index
+		if item.priority > f.costs[current] {
+			continue // Already reached cheaper
+		}
+
+		cell := f.grid.cell(current)
+		enterCost := f.grid.enterCost(cell.X, cell.Y)
+		f.grid.steps(cell, func(neighbour Cell, length float64) {
+			index := f.grid.index(neighbour)
+			cost := f.costs[current] + length + enterCost
+			if cost < f.costs[index] {
+				f.costs[index] = cost
+				f.next[index] = current
+				heap.Push(queue, queueItem{index: index, priority: cost})
+			}
+		})
+	}
+}
+
+// Targets gives the targets of the flow field.
+func (f *FlowField) Targets() []Cell {
+	return f.targets
+}
+
+// Cost gives the cost of the cheapest way from map cell x, y to a target. It is +Inf if no target can be reached.
+func (f *FlowField) Cost(x, y int) float64 {
+	if !f.grid.inside(x, y) {
+		return math.Inf(1)
+	}
+	return f.costs[f.grid.index(Cell{X: x, Y: y})]
+}
+
+// Next gives the next map cell on the cheapest way from map cell x, y to a target. Gives false for a target cell, and
+// for a map cell without a way to a target.
+func (f *FlowField) Next(x, y int) (Cell, bool) {
+	if !f.grid.inside(x, y) {
+		return Cell{}, false
+	}
+
+	next := f.next[f.grid.index(Cell{X: x, Y: y})]
+	if next == -1 {
+		return Cell{}, false
+	}
+	return f.grid.cell(next), true
+}
@@ -0,0 +1,68 @@
+package pathfinding
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"testing"
+)
+
+func TestFlowField(t *testing.T) {
+	rooms := raycastmap.NewGridMap(
+		"########",
+		"#......#",
+		"#.####.#",
+		"#..|...#",
+		"#####..#",
+		"#.#....#",
+		"########",
+	)
+	grid := NewGrid(rooms, Options{})
+
+	field := NewFlowField(grid)
+	assert.Empty(t, field.Targets())
+	assert.True(t, math.IsInf(field.Cost(1, 1), 1), "no target yet")
+
+	target := Cell{X: 6, Y: 1}
+	field.Update(target)
+	assert.Equal(t, []Cell{target}, field.Targets())
+	assert.Zero(t, field.Cost(6, 1))
+	_, ok := field.Next(6, 1)
+	assert.False(t, ok, "at the target")
+
+	// Following the flow from any cell is the cheapest path
+	for y := 0; y < grid.Height(); y++ {
+		for x := 0; x < grid.Width(); x++ {
+			path, cost, found := grid.FindPath(Cell{X: x, Y: y}, target)
+			if !found || !grid.Passable(x, y) {
+				assert.True(t, math.IsInf(field.Cost(x, y), 1), "no way from %d, %d", x, y)
+				continue
+			}
+
+			assert.InDelta(t, cost, field.Cost(x, y), 0.000001, "cost from %d, %d", x, y)
+
+			steps := 0
+			for cell, ok := (Cell{X: x, Y: y}), true; ok; cell, ok = field.Next(cell.X, cell.Y) {
+				assert.LessOrEqual(t, steps, len(path), "flow from %d, %d", x, y)
+				steps++
+				if cell == target {
+					break
+				}
+			}
+			assert.Equal(t, len(path), steps, "flow from %d, %d", x, y)
+		}
+	}
+
+	assert.True(t, math.IsInf(field.Cost(1, 1), 1), "walled in")
+	_, ok = field.Next(1, 1)
+	assert.False(t, ok)
+	assert.True(t, math.IsInf(field.Cost(-1, 1), 1), "outside the map")
+
+	t.Run("closest of more targets", func(t *testing.T) {
+		field.Update(Cell{X: 1, Y: 3}, Cell{X: 6, Y: 5})
+		assert.Equal(t, 1.0, field.Cost(2, 3))
+		assert.Equal(t, 1.0, field.Cost(6, 4))
+		assert.Equal(t, 2.0, field.Cost(3, 3), "leaving a door cell costs nothing extra")
+		assert.Equal(t, 4.0, field.Cost(4, 3), "around instead of through the door")
+	})
+}
@@ -0,0 +1,152 @@
+// Package pathfinding finds the way through a map: the cheapest path from one map cell to another (A*), and flow fields
+// that give the cheapest way to a target from every map cell (Dijkstra).
+package pathfinding
+
+import (
+	"math"
+	"maze/internal/pkg/raycastmap"
+)
+
+// DefaultDoorCost is the extra cost of stepping into a door cell, for waiting for the door to open.
+const DefaultDoorCost = 2.0
+
+// Neighbours tells which neighbour cells a step from a map cell can go to.
+type Neighbours int
+
+const (
+	FourNeighbours  Neighbours = iota // Steps along x and y
+	EightNeighbours                   // Steps along x and y, and diagonal steps
+)
+
+// Options are the rules of stepping from map cell to map cell.
+type Options struct {
+	Neighbours Neighbours
+
+	// DoorCost is the extra cost of stepping into a door cell, whether the door is open or not.
+	// Zero means DefaultDoorCost, a negative cost makes doors impassable.
+	DoorCost float64
+
+	// CutCorners allows diagonal steps past the corner of a blocked map cell (one of the two cells beside the step).
+	// Diagonal steps past two blocked cells are never possible, and neither are diagonal steps into or out of door cells.
+	CutCorners bool
+}
+
+// Cell is a map cell.
+type Cell struct {
+	X, Y int
+}
+
+// Grid is the map cells of a map to find the way through. A step to a neighbour cell costs its length (1 along x
+// or y, √2 diagonally), plus the door cost for a door cell. Doors can only be passed through straight, like they are
+// walked through: a vertical door along x, and a horizontal door along y.
+//
+// The grid looks at the map for each query, so a changed map (like a moved pushwall) needs no new grid.
+type Grid struct {
+	worldMap   raycastmap.Map
+	neighbours Neighbours
+	doorCost   float64
+	cutCorners bool
+}
+
+// NewGrid creates the grid of a map, with the rules of stepping from map cell to map cell.
+func NewGrid(worldMap raycastmap.Map, options Options) *Grid {
+	doorCost := options.DoorCost
+	if doorCost == 0.0 {
+		doorCost = DefaultDoorCost
+	}
+
+	return &Grid{worldMap: worldMap, neighbours: options.Neighbours, doorCost: doorCost, cutCorners: options.CutCorners}
+}
+
+func (g *Grid) Width() int {
+	return g.worldMap.Width()
+}
+
+func (g *Grid) Height() int {
+	return g.worldMap.Height()
+}
+
+// Passable reports if map cell x, y can be stepped into: inside the map, and no wall or obstacle.
+// Door cells are passable (unless the door cost is negative), open or closed.
+func (g *Grid) Passable(x, y int) bool {
+	if !g.inside(x, y) {
+		return false
+	}
+	if g.worldMap.DoorAt(x, y) != nil {
+		return g.doorCost >= 0.0
+	}
+
+	return !g.worldMap.ObstacleAt(x, y)
+}
+
+// steps calls step for each possible step from a map cell to a neighbour cell, with the length of the step. Steps
+// are the same both ways: a step from a to b is possible if (and only if) the step from b to a is possible.
+func (g *Grid) steps(cell Cell, step func(to Cell, length float64)) {
+	door := g.worldMap.DoorAt(cell.X, cell.Y)
+
+	for _, offset := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		to := Cell{X: cell.X + offset[0], Y: cell.Y + offset[1]}
+		alongX := offset[0] != 0
+		if door != nil && door.Vertical != alongX {
+			continue // Not through the door
+		}
+		if !g.Passable(to.X, to.Y) {
+			continue
+		}
+		if toDoor := g.worldMap.DoorAt(to.X, to.Y); toDoor != nil && toDoor.Vertical != alongX {
+			continue // Not into the door
+		}
+
+		step(to, 1.0)
+	}
+
+	if g.neighbours != EightNeighbours || door != nil {
+		return
+	}
+
+	for _, offset := range [4][2]int{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}} {
+		to := Cell{X: cell.X + offset[0], Y: cell.Y + offset[1]}
+		if !g.open(to.X, to.Y) {
+			continue
+		}
+
+		besideX, besideY := g.open(to.X, cell.Y), g.open(cell.X, to.Y)
+		if (besideX && besideY) || (g.cutCorners && (besideX || besideY)) {
+			step(to, math.Sqrt2)
+		}
+	}
+}
+
+// open reports if map cell x, y is passable, and not a door cell.
+func (g *Grid) open(x, y int) bool {
+	return g.Passable(x, y) && g.worldMap.DoorAt(x, y) == nil
+}
+
+// enterCost gives the extra cost of stepping into map cell x, y.
+func (g *Grid) enterCost(x, y int) float64 {
+	if g.worldMap.DoorAt(x, y) != nil {
+		return g.doorCost
+	}
+	return 0.0
+}
+
+// estimate gives the cost of the cheapest possible path between two map cells, for a map without walls and doors.
+func (g *Grid) estimate(from, to Cell) float64 {
+	dx, dy := math.Abs(float64(to.X-from.X)), math.Abs(float64(to.Y-from.Y))
+	if g.neighbours == EightNeighbours {
+		return max(dx, dy) + (math.Sqrt2-1.0)*min(dx, dy)
+	}
+	return dx + dy
+}
+
+func (g *Grid) inside(x, y int) bool {
+	return x >= 0 && y >= 0 && x < g.Width() && y < g.Height()
+}
+
+func (g *Grid) index(cell Cell) int {
+	return cell.Y*g.Width() + cell.X
+}
+
+func (g *Grid) cell(index int) Cell {
+	return Cell{X: index % g.Width(), Y: index / g.Width()}
+}
@@ -0,0 +1,59 @@
+package pathfinding
+
+import (
+	"container/heap"
+	"math"
+	"slices"
+)
+
+// FindPath finds the cheapest path from one map cell to another (with A*). The path starts with from and ends with to.
+// Gives false if there is no path, like when to is walled in or not passable.
+func (g *Grid) FindPath(from, to Cell) (path []Cell, cost float64, found bool) {
+	if !g.inside(from.X, from.Y) || !g.Passable(to.X, to.Y) {
+		return nil, 0.0, false
+	}
+
+	costs := make([]float64, g.Width()*g.Height())
+	for i := range costs {
+		costs[i] = math.Inf(1)
+	}
+	previous := make([]int, len(costs))
+	done := make([]bool, len(costs))
+
+	start, end := g.index(from), g.index(to)
+	costs[start] = 0.0
+	previous[start] = -1
+	queue := &priorityQueue{{index: start, priority: g.estimate(from, to)}}
+
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(queueItem).index
+		if current == end {
+			break
+		}
+		if done[current] {
+			continue // Already reached cheaper
+		}
+		done[current] = true
+
+		g.steps(g.cell(current), func(next Cell, length float64) {
+			index := g.index(next)
+			cost := costs[current] + length + g.enterCost(next.X, next.Y)
+			if cost < costs[index] {
+				costs[index] = cost
+				previous[index] = current
+				heap.Push(queue, queueItem{index: index, priority: cost + g.estimate(next, to)})
+			}
+		})
+	}
+
+	if math.IsInf(costs[end], 1) {
+		return nil, 0.0, false
+	}
+
+	for index := end; index != -1; index = previous[index] {
+		path = append(path, g.cell(index))
+	}
+	slices.Reverse(path)
+
+	return path, costs[end], true
+}
@@ -0,0 +1,119 @@
+package pathfinding
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"maze/internal/pkg/raycastmap"
+	"testing"
+)
+
+func TestGridPassable(t *testing.T) {
+	grid := NewGrid(raycastmap.NewGridMap(
+		"#####",
+		"#.|.#",
+		"#####",
+	), Options{})
+
+	assert.True(t, grid.Passable(1, 1))
+	assert.True(t, grid.Passable(2, 1), "door")
+	assert.False(t, grid.Passable(0, 1), "wall")
+	assert.False(t, grid.Passable(-1, 1), "outside the map")
+	assert.False(t, grid.Passable(1, 3), "outside the map")
+
+	closedDoors := NewGrid(raycastmap.NewGridMap("#####", "#.|.#", "#####"), Options{DoorCost: -1.0})
+	assert.False(t, closedDoors.Passable(2, 1))
+}
+
+func TestFindPath(t *testing.T) {
+	room := raycastmap.NewGridMap(
+		"#######",
+		"#.....#",
+		"#.###.#",
+		"#...#.#",
+		"#######",
+	)
+
+	t.Run("four neighbours", func(t *testing.T) {
+		path, cost, found := NewGrid(room, Options{}).FindPath(Cell{X: 1, Y: 1}, Cell{X: 5, Y: 1})
+		assert.True(t, found)
+		assert.Equal(t, []Cell{{1, 1}, {1, 2}, {1, 3}, {2, 3}, {3, 3}, {4, 3}, {5, 3}, {5, 2}, {5, 1}}, path)
+		assert.Equal(t, 8.0, cost)
+	})
+
+	t.Run("eight neighbours", func(t *testing.T) {
+		path, cost, found := NewGrid(room, Options{Neighbours: EightNeighbours}).FindPath(Cell{X: 1, Y: 1}, Cell{X: 5, Y: 1})
+		assert.True(t, found)
+		assert.Equal(t, []Cell{{1, 1}, {1, 2}, {1, 3}, {2, 3}, {3, 3}, {4, 3}, {5, 3}, {5, 2}, {5, 1}}, path, "no corner cutting")
+		assert.Equal(t, 8.0, cost)
+	})
+
+	t.Run("eight neighbours cutting corners", func(t *testing.T) {
+		path, cost, found := NewGrid(room, Options{Neighbours: EightNeighbours, CutCorners: true}).FindPath(Cell{X: 1, Y: 1}, Cell{X: 5, Y: 1})
+		assert.True(t, found)
+		assert.Equal(t, []Cell{{1, 1}, {1, 2}, {2, 3}, {3, 3}, {4, 3}, {5, 2}, {5, 1}}, path)
+		assert.InDelta(t, 4.0+2.0*math.Sqrt2, cost, 0.000001)
+	})
+
+	t.Run("start is the end", func(t *testing.T) {
+		path, cost, found := NewGrid(room, Options{}).FindPath(Cell{X: 3, Y: 1}, Cell{X: 3, Y: 1})
+		assert.True(t, found)
+		assert.Equal(t, []Cell{{3, 1}}, path)
+		assert.Zero(t, cost)
+	})
+
+	t.Run("no path", func(t *testing.T) {
+		_, _, found := NewGrid(room, Options{}).FindPath(Cell{X: 1, Y: 1}, Cell{X: 3, Y: 2})
+		assert.False(t, found, "into a wall")
+
+		walledIn := raycastmap.NewGridMap(
+			"#####",
+			"#.#.#",
+			"#####",
+		)
+		_, _, found = NewGrid(walledIn, Options{Neighbours: EightNeighbours, CutCorners: true}).FindPath(Cell{X: 1, Y: 1}, Cell{X: 3, Y: 1})
+		assert.False(t, found)
+	})
+}
+
+func TestFindPathDoors(t *testing.T) {
+	// A short way through a door, and a long way around
+	rooms := raycastmap.NewGridMap(
+		"#######",
+		"#.....#",
+		"#.###.#",
+		"#..|..#",
+		"#######",
+	)
+
+	path, cost, found := NewGrid(rooms, Options{}).FindPath(Cell{X: 1, Y: 1}, Cell{X: 5, Y: 1})
+	assert.True(t, found)
+	assert.Equal(t, []Cell{{1, 1}, {2, 1}, {3, 1}, {4, 1}, {5, 1}}, path, "through the door")
+	assert.Equal(t, 4.0+DefaultDoorCost, cost)
+
+	path, cost, found = NewGrid(rooms, Options{DoorCost: 10.0}).FindPath(Cell{X: 1, Y: 1}, Cell{X: 5, Y: 1})
+	assert.True(t, found)
+	assert.Len(t, path, 9, "around the expensive door")
+	assert.Equal(t, 8.0, cost)
+
+	_, _, found = NewGrid(rooms, Options{DoorCost: -1.0}).FindPath(Cell{X: 1, Y: 1}, Cell{X: 3, Y: 1})
+	assert.False(t, found, "impassable door")
+
+	t.Run("doors are passed straight", func(t *testing.T) {
+		corridor := raycastmap.NewGridMap(
+			"#####",
+			"#...#",
+			"#.|.#",
+			"#...#",
+			"#####",
+		)
+		grid := NewGrid(corridor, Options{Neighbours: EightNeighbours, CutCorners: true})
+
+		path, _, found := grid.FindPath(Cell{X: 2, Y: 3}, Cell{X: 2, Y: 2})
+		assert.True(t, found)
+		assert.Equal(t, 2, path[len(path)-2].Y, "into the vertical door along x")
+
+		path, _, found = grid.FindPath(Cell{X: 1, Y: 1}, Cell{X: 3, Y: 3})
+		assert.True(t, found)
+		assert.NotContains(t, path, Cell{X: 2, Y: 2}, "not through the door diagonally")
+	})
+}
@@ -0,0 +1,33 @@
+package pathfinding
+
+// queueItem is a map cell (index) in a priority queue, with its priority (lowest first).
+type queueItem struct {
+	index    int
+	priority float64
+}
+
+// priorityQueue is a priority queue of map cells, see container/heap.
+type priorityQueue []queueItem
+
+func (q priorityQueue) Len() int {
+	return len(q)
+}
+
+func (q priorityQueue) Less(i, j int) bool {
+	return q[i].priority < q[j].priority
+}
+
+func (q priorityQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *priorityQueue) Push(item any) {
+	*q = append(*q, item.(queueItem))
+}
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
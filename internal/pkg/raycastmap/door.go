@@ -0,0 +1,155 @@
+package raycastmap
+
+type DoorState int
+
+const (
+	DoorClosed DoorState = iota
+	DoorOpening
+	DoorOpen
+	DoorClosing
+)
+
+const (
+	doorSlideDuration = 1.0 // Seconds it takes for a door to slide fully open (or closed)
+	doorOpenDuration  = 4.0 // Seconds a door stays open before it tries to close by itself
+)
+
+func (s DoorState) String() string {
+	switch s {
+	case DoorClosed:
+		return "closed"
+	case DoorOpening:
+		return "opening"
+	case DoorOpen:
+		return "open"
+	case DoorClosing:
+		return "closing"
+	default:
+		return "unknown"
+	}
+}
+
+// Door is a sliding door in a map cell.
+//
+// The door is rendered as a thin wall recessed to the middle of the cell.
+// A vertical door has its door plane along the y-axis (in the middle of the cell), and you pass through it moving along the x-axis.
+// A horizontal door has its door plane along the x-axis, and you pass through it moving along the y-axis.
+type Door struct {
+	X, Y     int
+	Vertical bool
+	State    DoorState
+	Offset   float64    // How far the door has slid open, range [0.0, 1.0] where 0.0 is closed and 1.0 is fully open
+	Frame    *Structure // Structure used to render the door jambs (the wall sides facing the door cell). Can be nil.
+
+	openTime float64 // Seconds the door has been fully open
+}
+
+func NewDoor(x, y int, vertical bool, frame *Structure) *Door {
+	return &Door{X: x, Y: y, Vertical: vertical, Frame: frame}
+}
+
+// Use is the "use" action of a door. A closed (or closing) door starts to open, and an open (or opening) door starts to close.
+// A door never closes on something occupying the door cell, see Update.
+func (d *Door) Use() {
+	switch d.State {
+	case DoorClosed, DoorClosing:
+		d.State = DoorOpening
+	case DoorOpen, DoorOpening:
+		d.State = DoorClosing
+	}
+}
+
+// Open is the action of something that wants to pass through the door, like an enemy. A closed (or closing) door
+// starts to open, and an open door stays open for longer. Unlike Use, Open never closes the door.
+func (d *Door) Open() {
+	switch d.State {
+	case DoorClosed, DoorClosing:
+		d.State = DoorOpening
+	case DoorOpen:
+		d.openTime = 0.0
+	}
+}
+
+// Update animates the door for a time step of dt seconds.
+// The occupied argument tells if something (like the observer) currently is in the door cell.
+// An occupied door refuses to close. If it is closing, it opens up again.
+func (d *Door) Update(dt float64, occupied bool) {
+	const slideSpeed = 1.0 / doorSlideDuration
+
+	switch d.State {
+	case DoorOpening:
+		d.Offset += dt * slideSpeed
+		if d.Offset >= 1.0 {
+			d.Offset = 1.0
+			d.State = DoorOpen
+			d.openTime = 0.0
+		}
+
+	case DoorOpen:
+		d.openTime += dt
+		if d.openTime >= doorOpenDuration && !occupied {
+			d.State = DoorClosing
+		}
+
+	case DoorClosing:
+		if occupied {
+			d.State = DoorOpening
+			return
+		}
+
+		d.Offset -= dt * slideSpeed
+		if d.Offset <= 0.0 {
+			d.Offset = 0.0
+			d.State = DoorClosed
+		}
+	}
+}
+
+// IsBlocking reports if the door is in the way of anything trying to pass through the door cell.
+// Only a fully open door can be passed through.
+func (d *Door) IsBlocking() bool {
+	return d.Offset < 1.0
+}
+
+// Doors keeps track of all doors in a map.
+type Doors struct {
+	width, height int
+	cells         []*Door
+	doors         []*Door
+}
+
+func NewDoors(width, height int) *Doors {
+	return &Doors{width: width, height: height, cells: make([]*Door, width*height)}
+}
+
+// Add adds a door to the map cell of the door.
+func (ds *Doors) Add(door *Door) {
+	if door.X < 0 || door.Y < 0 || door.X >= ds.width || door.Y >= ds.height {
+		return
+	}
+
+	ds.cells[door.Y*ds.width+door.X] = door
+	ds.doors = append(ds.doors, door)
+}
+
+// At gives the door at map cell x, y. Nil is returned if there is no door in the cell.
+func (ds *Doors) At(x, y int) *Door {
+	if x < 0 || y < 0 || x >= ds.width || y >= ds.height {
+		return nil
+	}
+
+	return ds.cells[y*ds.width+x]
+}
+
+// All gives all doors in the map.
+func (ds *Doors) All() []*Door {
+	return ds.doors
+}
+
+// Update animates all doors for a time step of dt seconds.
+// The occupied function tells if something (like the observer) currently is in a map cell, and thus is in the way of a closing door.
+func (ds *Doors) Update(dt float64, occupied func(x, y int) bool) {
+	for _, door := range ds.doors {
+		door.Update(dt, occupied(door.X, door.Y))
+	}
+}
@@ -0,0 +1,81 @@
+package raycastmap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDoorOpenAndAutoClose(t *testing.T) {
+	door := NewDoor(1, 1, true, nil)
+	assert.Equal(t, DoorClosed, door.State)
+	assert.True(t, door.IsBlocking())
+
+	door.Use()
+	assert.Equal(t, DoorOpening, door.State)
+
+	door.Update(doorSlideDuration/2.0, false)
+	assert.Equal(t, DoorOpening, door.State)
+	assert.InDelta(t, 0.5, door.Offset, 0.000001)
+	assert.True(t, door.IsBlocking())
+
+	door.Update(doorSlideDuration/2.0, false)
+	assert.Equal(t, DoorOpen, door.State)
+	assert.False(t, door.IsBlocking())
+
+	door.Update(doorOpenDuration, false)
+	assert.Equal(t, DoorClosing, door.State)
+
+	door.Update(doorSlideDuration, false)
+	assert.Equal(t, DoorClosed, door.State)
+	assert.Equal(t, 0.0, door.Offset)
+}
+
+func TestDoorRefusesToCloseWhenOccupied(t *testing.T) {
+	door := NewDoor(1, 1, false, nil)
+
+	door.Use()
+	door.Update(doorSlideDuration, true)
+	assert.Equal(t, DoorOpen, door.State)
+
+	door.Update(doorOpenDuration*2.0, true)
+	assert.Equal(t, DoorOpen, door.State, "door should not close on something in the door cell")
+
+	door.Use()
+	assert.Equal(t, DoorClosing, door.State)
+	door.Update(0.1, true)
+	assert.Equal(t, DoorOpening, door.State, "closing door should open up again when something is in the door cell")
+}
+
+func TestDoorOpen(t *testing.T) {
+	door := NewDoor(1, 1, true, nil)
+
+	door.Open()
+	assert.Equal(t, DoorOpening, door.State)
+	door.Open()
+	assert.Equal(t, DoorOpening, door.State, "an opening door keeps opening")
+
+	door.Update(doorSlideDuration, false)
+	door.Update(doorOpenDuration*0.75, false)
+	door.Open()
+	door.Update(doorOpenDuration*0.75, false)
+	assert.Equal(t, DoorOpen, door.State, "stays open for longer")
+
+	door.Update(doorOpenDuration*0.25, false)
+	assert.Equal(t, DoorClosing, door.State)
+	door.Open()
+	assert.Equal(t, DoorOpening, door.State)
+}
+
+func TestDoors(t *testing.T) {
+	doors := NewDoors(3, 3)
+	door := NewDoor(1, 2, true, nil)
+	doors.Add(door)
+
+	assert.Equal(t, door, doors.At(1, 2))
+	assert.Nil(t, doors.At(2, 1))
+	assert.Nil(t, doors.At(-1, 5))
+
+	door.Use()
+	doors.Update(doorSlideDuration, func(x, y int) bool { return false })
+	assert.Equal(t, DoorOpen, door.State)
+}
@@ -0,0 +1,73 @@
+package raycastmap
+
+import (
+	"fmt"
+	"math"
+)
+
+// FogMode is how the fog thickens with the distance.
+type FogMode string
+
+const (
+	FogLinear             FogMode = "linear" // No fog up to Start, thickening evenly to full fog at End
+	FogExponential        FogMode = "exp"    // Visibility e^-(density * distance)
+	FogExponentialSquared FogMode = "exp2"   // Visibility e^-(density * distance)², clear nearby and thickening fast further away
+)
+
+// fogInvisible is the visibility where the fog is full: less than half a step of an 8-bit color channel.
+const fogInvisible = 1.0 / 512.0
+
+// Fog fades what is seen into the fog color with the distance ("depth cueing").
+type Fog struct {
+	Mode    FogMode    `json:"mode"`
+	Color   [3]float64 `json:"color"`             // R, G, B of the fog, range [0.0, 1.0]
+	Density float64    `json:"density,omitempty"` // Thickness of exponential fog, per cell
+	Start   float64    `json:"start,omitempty"`   // Distance (in cells) where linear fog starts
+	End     float64    `json:"end,omitempty"`     // Distance (in cells) where linear fog is full
+}
+
+// Visibility gives how much is seen through the fog at a distance, range [0.0, 1.0]: 1.0 is no fog, 0.0 is only fog.
+func (f *Fog) Visibility(distance float64) float64 {
+	switch f.Mode {
+	case FogLinear:
+		return min(1.0, max(0.0, (f.End-distance)/(f.End-f.Start)))
+	case FogExponential:
+		return math.Exp(-f.Density * distance)
+	case FogExponentialSquared:
+		d := f.Density * distance
+		return math.Exp(-d * d)
+	default:
+		return 1.0
+	}
+}
+
+// Distance gives the distance where the fog is full, nothing further away can be seen.
+func (f *Fog) Distance() float64 {
+	switch f.Mode {
+	case FogLinear:
+		return f.End
+	case FogExponential:
+		return -math.Log(fogInvisible) / f.Density
+	case FogExponentialSquared:
+		return math.Sqrt(-math.Log(fogInvisible)) / f.Density
+	default:
+		return math.Inf(1)
+	}
+}
+
+func (f *Fog) validate() error {
+	switch f.Mode {
+	case FogLinear:
+		if f.Start < 0.0 || f.End <= f.Start {
+			return fmt.Errorf("linear fog from %g to %g, expected 0 <= start < end", f.Start, f.End)
+		}
+	case FogExponential, FogExponentialSquared:
+		if f.Density <= 0.0 {
+			return fmt.Errorf("%s fog with density %g, expected a positive density", f.Mode, f.Density)
+		}
+	default:
+		return fmt.Errorf("unknown fog mode %q, expected %q, %q or %q", f.Mode, FogLinear, FogExponential, FogExponentialSquared)
+	}
+
+	return nil
+}
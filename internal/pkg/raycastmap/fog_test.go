@@ -0,0 +1,33 @@
+package raycastmap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestFogVisibility(t *testing.T) {
+	linear := &Fog{Mode: FogLinear, Start: 2.0, End: 6.0}
+	assert.Equal(t, 1.0, linear.Visibility(0.0))
+	assert.Equal(t, 1.0, linear.Visibility(2.0))
+	assert.Equal(t, 0.5, linear.Visibility(4.0))
+	assert.Zero(t, linear.Visibility(6.0))
+	assert.Zero(t, linear.Visibility(math.Inf(1)))
+	assert.Equal(t, 6.0, linear.Distance())
+
+	exponential := &Fog{Mode: FogExponential, Density: 0.5}
+	assert.Equal(t, 1.0, exponential.Visibility(0.0))
+	assert.InDelta(t, math.Exp(-1.0), exponential.Visibility(2.0), 1e-12)
+	assert.Zero(t, exponential.Visibility(math.Inf(1)))
+
+	exponentialSquared := &Fog{Mode: FogExponentialSquared, Density: 0.5}
+	assert.Equal(t, 1.0, exponentialSquared.Visibility(0.0))
+	assert.InDelta(t, math.Exp(-4.0), exponentialSquared.Visibility(4.0), 1e-12)
+	assert.Greater(t, exponentialSquared.Visibility(1.0), exponential.Visibility(1.0), "clearer nearby")
+	assert.Less(t, exponentialSquared.Visibility(4.0), exponential.Visibility(4.0), "thicker further away")
+
+	for _, fog := range []*Fog{linear, exponential, exponentialSquared} {
+		assert.LessOrEqual(t, fog.Visibility(fog.Distance()), fogInvisible, "full fog at the fog distance (%s)", fog.Mode)
+		assert.Greater(t, fog.Visibility(fog.Distance()*0.9), fogInvisible, "not full fog before the fog distance (%s)", fog.Mode)
+	}
+}
@@ -3,6 +3,7 @@ package raycastmap
 import (
 	"github.com/anthonynsimon/bild/blend"
 	"image"
+	"maze/internal/pkg/wolf3d"
 )
 
 type Cell struct {
@@ -20,17 +21,47 @@ type Map interface {
 	ObstacleAt(x, y int) bool
 	StructureAt(x, y int) *Structure
 	SpecialAt(x, y int) *Structure
+	DoorAt(x, y int) *Door
 }
 
+// SurfaceMap is a map with floor and ceiling textures. The textures are nil for cells with a flat colored floor or ceiling.
+type SurfaceMap interface {
+	FloorAt(x, y int) *Texture
+	CeilingAt(x, y int) *Texture
+}
+
+// ObjectMap is a map with Wolfenstein 3D objects (the object plane): enemies, patrol turning points, pickups...
+type ObjectMap interface {
+	// ObjectAt gives the object in map cell x, y, with X and Y the map cell. An object of kind wolf3d.ObjectNone is
+	// returned for cells without an object (or outside the map).
+	ObjectAt(x, y int) wolf3d.Object
+}
+
+// StructureNone is an empty structure: nothing, void, "waste of empty space".
+var StructureNone = &Structure{}
+
 type Structure struct {
 	Texture  *Texture
 	Texture2 *Texture
 	Overlay  *Texture
 
+	FloorTexture   *Texture // Floor of the cell, nil to use the floor of the level
+	CeilingTexture *Texture // Ceiling of the cell, nil to use the ceiling of the level
+	Light          *Light   // Light given by the structure (lamps), nil if none
+	Fog            *Fog     // Fog of a level (see TileTable.Level), nil if none
+
 	Item       bool // Something you can pick up (keys, ammo clip, treasures, extra life...)
 	Decoration bool // Something that decorates the cell (skeleton bones, large flower pot, bowl of food...)
 	Obstacle   bool // Something you cannot move through (wall, large flower pot, floor light... Not doors though)
 	Wall       bool // Some cell 100% covered (walls, like doors, walls). Used to render walls.
+	Sprite     bool // Something rendered as a camera facing billboard (barrels, lamps, guards, treasures...)
+}
+
+// Light is a point light in the middle of a map cell, like a lamp.
+type Light struct {
+	Color   [3]float64 `json:"color"`             // R, G, B at the light, range [0.0, 1.0] (or brighter)
+	Radius  float64    `json:"radius"`            // Distance (in cells) where the light has faded to nothing
+	Falloff float64    `json:"falloff,omitempty"` // Exponent of the fading with the distance, 1.0 is linear. Zero means 2.0.
 }
 
 func NewStructure(texture image.Image) *Structure {
@@ -50,15 +81,35 @@ func (s *Structure) WithTexture(texture image.Image) *Structure {
 	return s
 }
 
+func (s *Structure) WithFloorTexture(texture image.Image) *Structure {
+	s.FloorTexture = NewTexture(texture)
+	return s
+}
+
+func (s *Structure) WithCeilingTexture(texture image.Image) *Structure {
+	s.CeilingTexture = NewTexture(texture)
+	return s
+}
+
+func (s *Structure) WithFog(fog *Fog) *Structure {
+	s.Fog = fog
+	return s
+}
+
+func (s *Structure) WithLight(light *Light) *Structure {
+	s.Light = light
+	return s
+}
+
 func (s *Structure) WithOverlayTexture(overlay image.Image) *Structure {
 	s.Overlay = NewTexture(overlay)
 
 	if overlay != nil {
 		if s.Texture != nil {
-			s.Texture.img = blend.Normal(s.Texture.img, overlay)
+			s.Texture.setImage(blend.Normal(s.Texture.img, overlay))
 		}
 		if s.Texture2 != nil {
-			s.Texture2.img = blend.Normal(s.Texture2.img, overlay)
+			s.Texture2.setImage(blend.Normal(s.Texture2.img, overlay))
 		}
 	}
 
@@ -100,3 +151,12 @@ func (s *Structure) WithDecoration(decoration bool) *Structure {
 	s.Decoration = decoration
 	return s
 }
+
+func (s *Structure) IsSprite() bool {
+	return s.Sprite
+}
+
+func (s *Structure) WithSprite(sprite bool) *Structure {
+	s.Sprite = sprite
+	return s
+}
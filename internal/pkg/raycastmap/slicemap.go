@@ -34,12 +34,19 @@ func (sm SliceMap) SpecialAt(_, _ int) *Structure {
 	return &Structure{}
 }
 
+func (sm SliceMap) DoorAt(_, _ int) *Door {
+	return nil
+}
+
 func (sm SliceMap) WallAt(x, y int) bool {
 	return sm.StructureAt(x, y) != StructureNone
 }
 
+// ObstacleAt reports if map cell x, y has a wall that is an obstacle. Like in the tile tables, door structures are
+// walls but not obstacles.
 func (sm SliceMap) ObstacleAt(x, y int) bool {
-	return sm.WallAt(x, y) && sm.StructureAt(x, y) != StructureDoor
+	structure := sm.StructureAt(x, y)
+	return structure != StructureNone && structure.IsObstacle()
 }
 
 func (sm SliceMap) Width() int {
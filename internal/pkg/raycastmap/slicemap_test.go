@@ -0,0 +1,44 @@
+package raycastmap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSliceMapObstacleAt(t *testing.T) {
+	wall := &Structure{Wall: true, Obstacle: true}
+	door := &Structure{Wall: true}
+	valueToStructure := func(value int) *Structure {
+		return []*Structure{StructureNone, wall, door}[value]
+	}
+
+	sliceMap := NewSliceMap([][]int{{1, 0, 2}}, 0.5, 0.5, 0.0, valueToStructure)
+	assert.True(t, sliceMap.ObstacleAt(0, 0), "wall")
+	assert.False(t, sliceMap.ObstacleAt(0, 1), "nothing")
+	assert.True(t, sliceMap.WallAt(0, 2))
+	assert.False(t, sliceMap.ObstacleAt(0, 2), "a door is a wall, but not an obstacle")
+
+	tiles := DefaultTileTable()
+	defaultMap := NewSliceMap([][]int{{1, 2}}, 0.5, 0.5, 0.0, func(value int) *Structure {
+		return []*Structure{StructureNone, tiles.StructureNamed("GreyStoneWall1"), tiles.StructureNamed("Door")}[value]
+	})
+	assert.True(t, defaultMap.ObstacleAt(0, 0))
+	assert.False(t, defaultMap.ObstacleAt(0, 1))
+}
+
+func TestGridMapObstacleAt(t *testing.T) {
+	gridMap := NewGridMap(
+		"#####",
+		"#.|.#",
+		"#####",
+	)
+
+	assert.True(t, gridMap.ObstacleAt(0, 1), "wall")
+	assert.False(t, gridMap.ObstacleAt(1, 1), "nothing")
+	assert.True(t, gridMap.ObstacleAt(2, 1), "closed door")
+
+	gridMap.DoorAt(2, 1).Offset = 0.5
+	assert.True(t, gridMap.ObstacleAt(2, 1), "partly open door")
+	gridMap.DoorAt(2, 1).Offset = 1.0
+	assert.False(t, gridMap.ObstacleAt(2, 1), "open door")
+}
@@ -80,28 +80,30 @@ var (
 )
 
 func mapValueToStructure(mapValue int) *Structure {
+	tiles := DefaultTileTable()
+
 	switch mapValue {
 	case 0:
 		return StructureNone
 
 	case 1:
-		return StructureGreyStoneWall1
+		return tiles.StructureNamed("GreyStoneWall1")
 	case 2:
-		return StructureBlueStoneWall1
+		return tiles.StructureNamed("BlueStoneWall1")
 	case 3:
-		return StructureWoodWall
+		return tiles.StructureNamed("WoodWall")
 	case 4:
-		return StructureElevatorDoor
+		return tiles.StructureNamed("ElevatorDoor")
 	case 5:
-		return StructureExitDoor
+		return tiles.StructureNamed("ExitDoor")
 	case 6:
-		return StructureSkeletonCellClosed
+		return tiles.StructureNamed("SkeletonCellClosed")
 	case 7:
-		return StructureEmptyCellClosed
+		return tiles.StructureNamed("EmptyCellClosed")
 	case 8:
-		return StructureGreyStoneWall1
+		return tiles.StructureNamed("GreyStoneWall1")
 	default:
-		return StructureGreyStoneWall1
+		return tiles.StructureNamed("GreyStoneWall1")
 	}
 }
 
@@ -144,3 +146,63 @@ func mapValueToStructure(mapValue int) *Structure {
 	return NewSliceMap(levelData, 30, 58, 0.0), nil
 }
 */
+
+// GridMap is a SliceMap with doors, drawn as rows of text. It is made for tests.
+type GridMap struct {
+	SliceMap
+	doors *Doors
+}
+
+// NewGridMap creates a grid map from rows of text, the first row is the top (north) row of the map. A '#' is a wall, a
+// '|' is a (closed) vertical door, passed through along x, a '-' is a (closed) horizontal door, passed through along
+// y, anything else is free. The start point is 1.5, 1.5 facing east.
+func NewGridMap(rows ...string) GridMap {
+	width, height := len(rows[0]), len(rows)
+	mapData := make([][]int, width)
+	doors := NewDoors(width, height)
+	for x := range mapData {
+		mapData[x] = make([]int, height)
+		for y := range rows {
+			switch rows[height-1-y][x] {
+			case '#':
+				mapData[x][y] = 1
+			case '|', '-':
+				doors.Add(NewDoor(x, y, rows[height-1-y][x] == '|', nil))
+			}
+		}
+	}
+
+	wall := DefaultTileTable().StructureNamed("GreyStoneWall1")
+	return GridMap{
+		SliceMap: NewSliceMap(mapData, 1.5, 1.5, 0.0, func(value int) *Structure {
+			if value == 0 {
+				return StructureNone
+			}
+			return wall
+		}),
+		doors: doors,
+	}
+}
+
+// Doors gives all doors of the map.
+func (m GridMap) Doors() *Doors {
+	return m.doors
+}
+
+func (m GridMap) DoorAt(x, y int) *Door {
+	return m.doors.At(x, y)
+}
+
+// WallAt reports if map cell x, y has a wall or a door, like the door cells of a WolfensteinMap.
+func (m GridMap) WallAt(x, y int) bool {
+	return m.SliceMap.WallAt(x, y) || m.doors.At(x, y) != nil
+}
+
+// ObstacleAt reports if map cell x, y has a wall or a door that is not fully open, like WolfensteinMap.ObstacleAt.
+func (m GridMap) ObstacleAt(x, y int) bool {
+	if door := m.doors.At(x, y); door != nil {
+		return door.IsBlocking()
+	}
+
+	return m.SliceMap.ObstacleAt(x, y)
+}
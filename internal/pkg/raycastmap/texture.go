@@ -6,12 +6,25 @@ import (
 	"image/color"
 	"image/png"
 	"log"
+	"math"
 	"os"
 )
 
+const (
+	bytesPerTexel   = 4  // R, G, B, A
+	fixedPointShift = 16 // Fractional bits of the fixed-point texel stepping
+)
+
+// Texture is a wall or sprite image.
+//
+// The image is converted once into texels: packed RGBA bytes stored column by column (as the original game stored
+// its walls), so that a pixel column is read from consecutive bytes without going through the image.Image interface.
 type Texture struct {
 	img           image.Image
 	dominantColor color.Color
+
+	texels        []byte // Column-major RGBA (alpha premultiplied, like image.RGBA), height*4 bytes per column
+	width, height int
 }
 
 func NewTextureWithOverlay(imageFilename string, overlayFilename string) *Texture {
@@ -20,10 +33,10 @@ func NewTextureWithOverlay(imageFilename string, overlayFilename string) *Textur
 	textureImage, _ := readImage(imageFilename)
 	overlayImage, _ := readImage(overlayFilename)
 
-	texture.img = textureImage
+	texture.setImage(textureImage)
 
 	if overlayImage != nil {
-		texture.img = blend.Normal(textureImage, overlayImage)
+		texture.setImage(blend.Normal(textureImage, overlayImage))
 	}
 
 	if texture.img != nil {
@@ -34,7 +47,8 @@ func NewTextureWithOverlay(imageFilename string, overlayFilename string) *Textur
 }
 
 func NewTexture(image image.Image) *Texture {
-	texture := &Texture{img: image}
+	texture := &Texture{}
+	texture.setImage(image)
 
 	if image != nil {
 		texture.dominantColor = averageColor(image)
@@ -70,10 +84,44 @@ func averageColor(textureImage image.Image) color.RGBA {
 	return dominantColor
 }
 
+// setImage makes img the texture image, and converts it into texels.
+func (t *Texture) setImage(img image.Image) {
+	t.img = img
+	t.texels, t.width, t.height = nil, 0, 0
+	if img == nil {
+		return
+	}
+
+	bounds := img.Bounds()
+	t.width, t.height = bounds.Dx(), bounds.Dy()
+	t.texels = make([]byte, t.width*t.height*bytesPerTexel)
+
+	for x := 0; x < t.width; x++ {
+		column := t.texels[x*t.height*bytesPerTexel:]
+		for y := 0; y < t.height; y++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			column[y*bytesPerTexel+0] = byte(r >> 8)
+			column[y*bytesPerTexel+1] = byte(g >> 8)
+			column[y*bytesPerTexel+2] = byte(b >> 8)
+			column[y*bytesPerTexel+3] = byte(a >> 8)
+		}
+	}
+}
+
 func (t *Texture) DominantColor() color.Color {
 	return t.dominantColor
 }
 
+// Width gives the width of the texture image in pixels.
+func (t *Texture) Width() int {
+	return t.width
+}
+
+// Height gives the height of the texture image in pixels.
+func (t *Texture) Height() int {
+	return t.height
+}
+
 // ReadScaledPixelColumn is a low level very specific function to read a pixel column from an image.
 //
 //	The pixel column to be read from the image is located at offset xOffset [0.0, 1.0] from the left in the image.
@@ -83,29 +131,42 @@ func (t *Texture) DominantColor() color.Color {
 //
 // Thus, the data slice is four times larger than the count pixel to store.
 //
-//	The image is considered to be in color model RGBA.
+//	The pixel colors are in color model RGBA (alpha premultiplied).
+//	Offsets outside the image are clamped to the first or the last pixel column or row.
 func (t *Texture) ReadScaledPixelColumn(xOffset float64, yOffset float64, yLength float64, data []byte) {
-	srcImageWidth := t.img.Bounds().Dx()
-	srcImageHeight := t.img.Bounds().Dy()
-	srcPixelX := int(float64(srcImageWidth) * xOffset)
-	destPixelCount := len(data) / 4 // four bytes per pixel R,G,B,A
+	destPixelCount := len(data) / bytesPerTexel
+	if t.width == 0 || t.height == 0 || destPixelCount == 0 {
+		return
+	}
 
-	for destPixelIndex := 0; destPixelIndex < destPixelCount; destPixelIndex++ {
-		progress := float64(destPixelIndex) / float64(destPixelCount)
-		srcPixelY := int(float64(srcImageHeight) * (yLength*progress + yOffset))
-		if srcPixelY > srcImageHeight {
-			srcPixelY = srcImageHeight - 1
-		}
+	srcPixelX := min(max(int(float64(t.width)*xOffset), 0), t.width-1)
+	column := t.texels[srcPixelX*t.height*bytesPerTexel : (srcPixelX+1)*t.height*bytesPerTexel]
 
-		r, g, b, a := t.img.At(srcPixelX, srcPixelY).RGBA()
+	// Step through the source pixel rows in fixed-point, the integer part is the source pixel row
+	srcPixelY := int64(math.Floor(float64(t.height) * yOffset * (1 << fixedPointShift)))
+	srcPixelYStep := int64(math.Floor(float64(t.height) * yLength / float64(destPixelCount) * (1 << fixedPointShift)))
+	lastSrcPixelY := int64(t.height - 1)
 
-		data[destPixelIndex*4+0] = byte(r >> 8)
-		data[destPixelIndex*4+1] = byte(g >> 8)
-		data[destPixelIndex*4+2] = byte(b >> 8)
-		data[destPixelIndex*4+3] = byte(a >> 8)
+	for destPixelIndex := 0; destPixelIndex < destPixelCount; destPixelIndex++ {
+		srcTexel := min(max(srcPixelY>>fixedPointShift, 0), lastSrcPixelY) * bytesPerTexel
+		copy(data[destPixelIndex*bytesPerTexel:destPixelIndex*bytesPerTexel+bytesPerTexel], column[srcTexel:srcTexel+bytesPerTexel])
+		srcPixelY += srcPixelYStep
 	}
 }
 
+// Texel gives the color of the texture pixel at offset xOffset, yOffset [0.0, 1.0] from the top left in the image,
+// as bytes R, G, B, A (alpha premultiplied). Offsets outside the image are clamped to the image edges.
+func (t *Texture) Texel(xOffset float64, yOffset float64) (r, g, b, a byte) {
+	if t.width == 0 || t.height == 0 {
+		return 0, 0, 0, 0
+	}
+
+	x := min(max(int(float64(t.width)*xOffset), 0), t.width-1)
+	y := min(max(int(float64(t.height)*yOffset), 0), t.height-1)
+	texel := t.texels[(x*t.height+y)*bytesPerTexel:]
+	return texel[0], texel[1], texel[2], texel[3]
+}
+
 func readImage(filename string) (image.Image, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -41,3 +41,178 @@ func TestReadScaledPixelColumn(t *testing.T) {
 	err = png.Encode(pngImageFile, dstImage)
 	assert.NoError(t, err)
 }
+
+// gradientImage gives an image with a unique color for each pixel: red is the column and green is the row.
+func gradientImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+
+	return img
+}
+
+// readScaledPixelColumnFromImage is the texture column sampling through the image.Image interface, as it was done
+// before the texels. Kept as reference for the tests and the benchmark.
+func readScaledPixelColumnFromImage(img image.Image, xOffset float64, yOffset float64, yLength float64, data []byte) {
+	srcImageWidth := img.Bounds().Dx()
+	srcImageHeight := img.Bounds().Dy()
+	srcPixelX := int(float64(srcImageWidth) * xOffset)
+	destPixelCount := len(data) / 4
+
+	for destPixelIndex := 0; destPixelIndex < destPixelCount; destPixelIndex++ {
+		progress := float64(destPixelIndex) / float64(destPixelCount)
+		srcPixelY := int(float64(srcImageHeight) * (yLength*progress + yOffset))
+		if srcPixelY >= srcImageHeight {
+			srcPixelY = srcImageHeight - 1
+		}
+
+		r, g, b, a := img.At(srcPixelX, srcPixelY).RGBA()
+
+		data[destPixelIndex*4+0] = byte(r >> 8)
+		data[destPixelIndex*4+1] = byte(g >> 8)
+		data[destPixelIndex*4+2] = byte(b >> 8)
+		data[destPixelIndex*4+3] = byte(a >> 8)
+	}
+}
+
+// sampledRows gives the source pixel rows (green) of a read pixel column.
+func sampledRows(data []byte) []int {
+	rows := make([]int, len(data)/4)
+	for i := range rows {
+		rows[i] = int(data[i*4+1])
+	}
+
+	return rows
+}
+
+func TestTextureTexels(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 3))
+	img.SetNRGBA(1, 2, color.NRGBA{R: 0xff, G: 0x80, B: 0x00, A: 0x80})
+	texture := NewTexture(img)
+
+	assert.Equal(t, 2, texture.Width())
+	assert.Equal(t, 3, texture.Height())
+	assert.Len(t, texture.texels, 2*3*4)
+
+	// Column-major, with the alpha premultiplied like image.RGBA
+	assert.Equal(t, []byte{0x80, 0x40, 0x00, 0x80}, texture.texels[(1*3+2)*4:(1*3+2)*4+4])
+
+	empty := NewTexture(nil)
+	assert.Zero(t, empty.Width())
+	empty.ReadScaledPixelColumn(0.5, 0.0, 1.0, make([]byte, 8)) // Nothing to read, must not panic
+}
+
+func TestReadScaledPixelColumnSampling(t *testing.T) {
+	img := gradientImage(64, 64)
+	texture := NewTexture(img)
+
+	t.Run("unscaled column is the image column", func(t *testing.T) {
+		data := make([]byte, 64*4)
+		texture.ReadScaledPixelColumn(10.0/64.0, 0.0, 1.0, data)
+
+		expected := make([]byte, 64*4)
+		readScaledPixelColumnFromImage(img, 10.0/64.0, 0.0, 1.0, expected)
+		assert.Equal(t, expected, data)
+		assert.Equal(t, byte(10), data[0])
+	})
+
+	t.Run("scaled up repeats the rows", func(t *testing.T) {
+		data := make([]byte, 128*4)
+		texture.ReadScaledPixelColumn(0.0, 0.0, 1.0, data)
+
+		rows := sampledRows(data)
+		for i, row := range rows {
+			assert.Equal(t, i/2, row, "pixel %d", i)
+		}
+	})
+
+	t.Run("part of the column", func(t *testing.T) {
+		data := make([]byte, 32*4)
+		texture.ReadScaledPixelColumn(0.0, 0.25, 0.5, data)
+
+		rows := sampledRows(data)
+		assert.Equal(t, 16, rows[0])
+		assert.Equal(t, 47, rows[31])
+	})
+
+	t.Run("rows past the bottom are clamped to the last row", func(t *testing.T) {
+		data := make([]byte, 64*4)
+		texture.ReadScaledPixelColumn(0.0, 1.0/64.0, 1.0, data)
+
+		rows := sampledRows(data)
+		assert.Equal(t, 1, rows[0])
+		assert.Equal(t, 63, rows[63])
+		assert.Equal(t, byte(0xff), data[63*4+3], "the last pixel is not transparent")
+	})
+
+	t.Run("rows above the top are clamped to the first row", func(t *testing.T) {
+		data := make([]byte, 64*4)
+		texture.ReadScaledPixelColumn(0.0, -2.0/64.0, 1.0, data)
+
+		rows := sampledRows(data)
+		assert.Equal(t, []int{0, 0, 0, 1}, rows[:4])
+		assert.Equal(t, byte(0xff), data[3])
+	})
+
+	t.Run("columns outside the image are clamped", func(t *testing.T) {
+		data := make([]byte, 4*4)
+		texture.ReadScaledPixelColumn(1.0, 0.0, 1.0, data)
+		assert.Equal(t, byte(63), data[0])
+
+		texture.ReadScaledPixelColumn(-0.5, 0.0, 1.0, data)
+		assert.Equal(t, byte(0), data[0])
+	})
+}
+
+func TestTexel(t *testing.T) {
+	texture := NewTexture(gradientImage(64, 32))
+
+	r, g, b, a := texture.Texel(0.5, 0.25)
+	assert.Equal(t, []byte{32, 8, 0x80, 0xff}, []byte{r, g, b, a})
+
+	r, g, _, _ = texture.Texel(1.0, -0.1)
+	assert.Equal(t, []byte{63, 0}, []byte{r, g}, "clamped to the image edges")
+
+	_, _, _, a = NewTexture(nil).Texel(0.5, 0.5)
+	assert.Zero(t, a)
+}
+
+func benchmarkTexture(b *testing.B) *Texture {
+	texture := DefaultTileTable().StructureNamed("GreyStoneWall1").Texture
+	if texture == nil || texture.Height() == 0 {
+		b.Fatal("no wall texture")
+	}
+
+	return texture
+}
+
+// BenchmarkReadScaledPixelColumnFromImage reads 640 pixel columns 400 pixels high, through the image.Image interface.
+func BenchmarkReadScaledPixelColumnFromImage(b *testing.B) {
+	texture := benchmarkTexture(b)
+	data := make([]byte, 400*4)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for x := 0; x < 640; x++ {
+			readScaledPixelColumnFromImage(texture.img, float64(x)/640.0, 0.1, 0.8, data)
+		}
+	}
+}
+
+// BenchmarkReadScaledPixelColumn reads 640 pixel columns 400 pixels high, from the texels.
+func BenchmarkReadScaledPixelColumn(b *testing.B) {
+	texture := benchmarkTexture(b)
+	data := make([]byte, 400*4)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for x := 0; x < 640; x++ {
+			texture.ReadScaledPixelColumn(float64(x)/640.0, 0.1, 0.8, data)
+		}
+	}
+}
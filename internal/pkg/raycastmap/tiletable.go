@@ -0,0 +1,507 @@
+package raycastmap
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"maze/internal/pkg/wolf3d"
+	"maze/internal/pkg/wolf3d/resources"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultTileDefinitions is the Wolfenstein 3D tile mapping, used by DefaultTileTable.
+//
+//go:embed tiles.json
+var defaultTileDefinitions []byte
+
+//go:embed overlay
+var embeddedOverlays embed.FS
+
+const maxTileCode = 0xffff // Map values are uint16
+
+// TileDefinition defines the structure used for one or more wall plane (structure) or object plane (special) codes.
+//
+// Codes are numbers ("0x18", "24") or inclusive ranges ("0x6C-0x73").
+// Textures are one or two image names from the image source, the first for the light side and the second for the
+// dark side of walls. Overlay is an image file (in the overlay directory) that is blended on top of the textures,
+// or used as the texture if the definition has no textures.
+// Floor and Ceiling are image names for the floor and the ceiling of the cells with the structure, instead of the
+// floor and the ceiling of the level. Light makes the structure a point light (lamps).
+type TileDefinition struct {
+	Name     string   `json:"name"`
+	Codes    []string `json:"codes,omitempty"`
+	Textures []string `json:"textures,omitempty"`
+	Overlay  string   `json:"overlay,omitempty"`
+	Floor    string   `json:"floor,omitempty"`
+	Ceiling  string   `json:"ceiling,omitempty"`
+	Light    *Light   `json:"light,omitempty"`
+
+	Obstacle   bool `json:"obstacle,omitempty"`
+	Wall       bool `json:"wall,omitempty"`
+	Item       bool `json:"item,omitempty"`
+	Decoration bool `json:"decoration,omitempty"`
+	Sprite     bool `json:"sprite,omitempty"`
+}
+
+// TileDefinitions is the content of a tile definition file.
+type TileDefinitions struct {
+	Structures       []TileDefinition  `json:"structures"`
+	Specials         []TileDefinition  `json:"specials"`
+	DoorFrame        TileDefinition    `json:"doorFrame"`        // The wall sides next to a door
+	UnknownStructure TileDefinition    `json:"unknownStructure"` // Used for wall plane codes without a definition
+	UnknownSpecial   TileDefinition    `json:"unknownSpecial"`   // Used for object plane codes without a definition
+	Levels           []LevelDefinition `json:"levels"`
+}
+
+// LevelDefinition defines the floor, the ceiling and the fog of one or more levels.
+//
+// Levels are level numbers ("0", "0x0A") or inclusive ranges ("0-9"), like tile codes.
+// Floor and Ceiling are image names from the image source. Cells with a structure that has a floor or a ceiling use
+// that instead.
+type LevelDefinition struct {
+	Levels  []string `json:"levels"`
+	Floor   string   `json:"floor,omitempty"`
+	Ceiling string   `json:"ceiling,omitempty"`
+	Fog     *Fog     `json:"fog,omitempty"`
+}
+
+// TileTable maps wall plane and object plane codes to structures.
+type TileTable struct {
+	structures       []*Structure // Indexed by code, nil for codes without a definition
+	specials         []*Structure // Indexed by code, nil for codes without a definition
+	structuresByName map[string]*Structure
+	specialsByName   map[string]*Structure
+	doorFrame        *Structure
+	unknownStructure *Structure
+	unknownSpecial   *Structure
+	levels           []*Structure // Floor and ceiling, indexed by level, nil for levels without a definition
+
+	textures []structureTextures
+}
+
+// structureTextures are the names of the texture images of a structure, so the textures can be replaced by LoadTextures.
+type structureTextures struct {
+	structure      *Structure
+	names          []string
+	floor, ceiling string
+}
+
+// ImageSource gives wall and sprite images by name, like "WAL00012" for a wall or "SPR00003" for a sprite.
+// A wolf3d.VSwap is an ImageSource.
+type ImageSource interface {
+	Image(name string) (image.Image, error)
+}
+
+// EmbeddedImageSource gives the images extracted from the shareware version, embedded in the resources package.
+type EmbeddedImageSource struct{}
+
+func (EmbeddedImageSource) Image(name string) (image.Image, error) {
+	return resources.ImageResource("extracted/" + name)
+}
+
+var defaultTileTable = sync.OnceValue(func() *TileTable {
+	tiles, err := LoadTileTable(bytes.NewReader(defaultTileDefinitions), EmbeddedImageSource{})
+	if err != nil {
+		panic(err)
+	}
+	return tiles
+})
+
+// DefaultTileTable gives the Wolfenstein 3D tile table, using the embedded tile definitions and images.
+func DefaultTileTable() *TileTable {
+	return defaultTileTable()
+}
+
+// LoadTileTableFromFile reads a tile definition (JSON) file. Overlays are read from the directory of the file,
+// falling back to the embedded overlays.
+func LoadTileTableFromFile(filename string, images ImageSource) (*TileTable, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	tiles, err := LoadTileTable(file, images, os.DirFS(filepath.Dir(filename)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return tiles, nil
+}
+
+// LoadTileTable reads tile definitions (JSON) and creates the structures, with textures from the image source.
+// Overlays are read from the overlay file systems in order, and then from the embedded overlays.
+func LoadTileTable(definitions io.Reader, images ImageSource, overlays ...fs.FS) (*TileTable, error) {
+	decoder := json.NewDecoder(definitions)
+	decoder.DisallowUnknownFields()
+
+	var tileDefinitions TileDefinitions
+	if err := decoder.Decode(&tileDefinitions); err != nil {
+		return nil, fmt.Errorf("could not read tile definitions: %w", err)
+	}
+
+	return NewTileTable(tileDefinitions, images, overlays...)
+}
+
+// NewTileTable creates the structures of tile definitions, with textures from the image source.
+// Overlays are read from the overlay file systems in order, and then from the embedded overlays.
+func NewTileTable(definitions TileDefinitions, images ImageSource, overlays ...fs.FS) (*TileTable, error) {
+	embedded, _ := fs.Sub(embeddedOverlays, "overlay")
+	builder := tileTableBuilder{images: images, overlays: append(slices.Clone(overlays), embedded)}
+
+	t := &TileTable{
+		structuresByName: map[string]*Structure{},
+		specialsByName:   map[string]*Structure{},
+	}
+
+	var err error
+	if t.structures, err = builder.structures(definitions.Structures, t.structuresByName); err != nil {
+		return nil, fmt.Errorf("structures: %w", err)
+	}
+	if t.specials, err = builder.structures(definitions.Specials, t.specialsByName); err != nil {
+		return nil, fmt.Errorf("specials: %w", err)
+	}
+	if t.doorFrame, err = builder.structure(definitions.DoorFrame); err != nil {
+		return nil, fmt.Errorf("door frame: %w", err)
+	}
+	if t.unknownStructure, err = builder.structure(definitions.UnknownStructure); err != nil {
+		return nil, fmt.Errorf("unknown structure: %w", err)
+	}
+	if t.unknownSpecial, err = builder.structure(definitions.UnknownSpecial); err != nil {
+		return nil, fmt.Errorf("unknown special: %w", err)
+	}
+	if t.levels, err = builder.levels(definitions.Levels); err != nil {
+		return nil, fmt.Errorf("levels: %w", err)
+	}
+
+	t.textures = builder.textures
+	return t, nil
+}
+
+// Structure gives the structure for a wall plane code, or the unknown structure if the code has no definition.
+func (t *TileTable) Structure(code int) *Structure {
+	if isDefined(t.structures, code) {
+		return t.structures[code]
+	}
+	return t.unknownStructure
+}
+
+// Special gives the structure for an object plane code, or the unknown special if the code has no definition.
+func (t *TileTable) Special(code int) *Structure {
+	if isDefined(t.specials, code) {
+		return t.specials[code]
+	}
+	return t.unknownSpecial
+}
+
+func isDefined(structures []*Structure, code int) bool {
+	return code >= 0 && code < len(structures) && structures[code] != nil
+}
+
+// StructureNamed gives the structure with a name, or nil if there is no structure with the name.
+func (t *TileTable) StructureNamed(name string) *Structure {
+	return t.structuresByName[name]
+}
+
+// SpecialNamed gives the special with a name, or nil if there is no special with the name.
+func (t *TileTable) SpecialNamed(name string) *Structure {
+	return t.specialsByName[name]
+}
+
+// DoorFrame gives the structure of the wall sides next to a door (door jamb).
+func (t *TileTable) DoorFrame() *Structure {
+	return t.doorFrame
+}
+
+// Level gives the structure with the floor and the ceiling textures, and the fog, of a level.
+// Levels without a definition have an empty structure (no floor and ceiling textures, no fog).
+func (t *TileTable) Level(level int) *Structure {
+	if isDefined(t.levels, level) {
+		return t.levels[level]
+	}
+	return StructureNone
+}
+
+// UndefinedCodes gives the wall plane (structure) and object plane (special) codes used in a level that have no
+// definition in the tile table, in increasing order.
+func (t *TileTable) UndefinedCodes(levelMap *wolf3d.LevelMap) (structureCodes, specialCodes []int) {
+	const wallPlane, objectPlane = 0, 1
+
+	for y := 0; y < levelMap.Height; y++ {
+		for x := 0; x < levelMap.Width; x++ {
+			if code := levelMap.Value(wallPlane, x, y); !isDefined(t.structures, code) && !slices.Contains(structureCodes, code) {
+				structureCodes = append(structureCodes, code)
+			}
+			if code := levelMap.Value(objectPlane, x, y); !isDefined(t.specials, code) && !slices.Contains(specialCodes, code) {
+				specialCodes = append(specialCodes, code)
+			}
+		}
+	}
+
+	slices.Sort(structureCodes)
+	slices.Sort(specialCodes)
+	return structureCodes, specialCodes
+}
+
+// LoadTextures replaces the textures of the structures with images from an image source,
+// for example the walls and sprites of a VSWAP file. Overlays are applied to the new textures.
+// The textures are left unchanged if an image is missing in the image source.
+func (t *TileTable) LoadTextures(source ImageSource) error {
+	type loadedImages struct {
+		textures       []image.Image
+		floor, ceiling image.Image
+	}
+
+	// Read all images before changing any texture, so the textures are unchanged if an image is missing
+	images := make([]loadedImages, len(t.textures))
+	for i, st := range t.textures {
+		for _, name := range st.names {
+			img, err := source.Image(name)
+			if err != nil {
+				return err
+			}
+			images[i].textures = append(images[i].textures, img)
+		}
+
+		var err error
+		if st.floor != "" {
+			if images[i].floor, err = source.Image(st.floor); err != nil {
+				return err
+			}
+		}
+		if st.ceiling != "" {
+			if images[i].ceiling, err = source.Image(st.ceiling); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, st := range t.textures {
+		if len(images[i].textures) > 0 {
+			st.structure.WithTexture(images[i].textures[0])
+			if len(images[i].textures) > 1 {
+				st.structure.WithSecondTexture(images[i].textures[1])
+			}
+			if st.structure.Overlay != nil && st.structure.Overlay.img != nil {
+				st.structure.WithOverlayTexture(st.structure.Overlay.img)
+			}
+		}
+		if images[i].floor != nil {
+			st.structure.WithFloorTexture(images[i].floor)
+		}
+		if images[i].ceiling != nil {
+			st.structure.WithCeilingTexture(images[i].ceiling)
+		}
+	}
+
+	return nil
+}
+
+// LoadTextures replaces the textures of the default tile table, see TileTable.LoadTextures.
+func LoadTextures(source ImageSource) error {
+	return DefaultTileTable().LoadTextures(source)
+}
+
+// tileTableBuilder creates structures from tile definitions, and keeps track of their texture names.
+type tileTableBuilder struct {
+	images   ImageSource
+	overlays []fs.FS
+	textures []structureTextures
+}
+
+// structures creates the structures of definitions, as a slice indexed by code. Each structure is added to byName.
+func (b *tileTableBuilder) structures(definitions []TileDefinition, byName map[string]*Structure) ([]*Structure, error) {
+	var structures []*Structure
+
+	for _, definition := range definitions {
+		if _, exists := byName[definition.Name]; exists {
+			return nil, fmt.Errorf("%q is defined more than once", definition.Name)
+		}
+
+		s, err := b.structure(definition)
+		if err != nil {
+			return nil, err
+		}
+		byName[definition.Name] = s
+
+		for _, codeText := range definition.Codes {
+			first, last, err := parseCodeRange(codeText)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", definition.Name, err)
+			}
+
+			if last >= len(structures) {
+				structures = append(structures, make([]*Structure, last+1-len(structures))...)
+			}
+			for code := first; code <= last; code++ {
+				if structures[code] != nil {
+					return nil, fmt.Errorf("%q: code 0x%02X is already defined", definition.Name, code)
+				}
+				structures[code] = s
+			}
+		}
+	}
+
+	return structures, nil
+}
+
+// structure creates the structure of a definition.
+func (b *tileTableBuilder) structure(definition TileDefinition) (*Structure, error) {
+	if len(definition.Textures) > 2 {
+		return nil, fmt.Errorf("%q has %d textures, expected at most 2 (light and dark side)", definition.Name, len(definition.Textures))
+	}
+	if definition.Light != nil && (definition.Light.Radius <= 0.0 || definition.Light.Falloff < 0.0) {
+		return nil, fmt.Errorf("%q light has radius %g and falloff %g, expected a positive radius and a falloff of at least 0", definition.Name, definition.Light.Radius, definition.Light.Falloff)
+	}
+
+	var overlay image.Image
+	if definition.Overlay != "" {
+		var err error
+		if overlay, err = b.overlay(definition.Overlay); err != nil {
+			return nil, fmt.Errorf("%q: %w", definition.Name, err)
+		}
+	}
+
+	s := &Structure{}
+	if len(definition.Textures) > 0 {
+		for i, name := range definition.Textures {
+			img, err := b.images.Image(name)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", definition.Name, err)
+			}
+			if i == 0 {
+				s.WithTexture(img)
+			} else {
+				s.WithSecondTexture(img)
+			}
+		}
+		if overlay != nil {
+			s.WithOverlayTexture(overlay)
+		}
+	} else if overlay != nil {
+		s.WithTexture(overlay)
+	}
+
+	if definition.Floor != "" {
+		img, err := b.images.Image(definition.Floor)
+		if err != nil {
+			return nil, fmt.Errorf("%q floor: %w", definition.Name, err)
+		}
+		s.WithFloorTexture(img)
+	}
+	if definition.Ceiling != "" {
+		img, err := b.images.Image(definition.Ceiling)
+		if err != nil {
+			return nil, fmt.Errorf("%q ceiling: %w", definition.Name, err)
+		}
+		s.WithCeilingTexture(img)
+	}
+
+	if len(definition.Textures) > 0 || definition.Floor != "" || definition.Ceiling != "" {
+		b.textures = append(b.textures, structureTextures{structure: s, names: definition.Textures, floor: definition.Floor, ceiling: definition.Ceiling})
+	}
+
+	return s.
+		WithLight(definition.Light).
+		WithObstacle(definition.Obstacle).
+		WithWall(definition.Wall).
+		WithItem(definition.Item).
+		WithDecoration(definition.Decoration).
+		WithSprite(definition.Sprite), nil
+}
+
+// levels creates the floor and ceiling structures of level definitions, as a slice indexed by level.
+func (b *tileTableBuilder) levels(definitions []LevelDefinition) ([]*Structure, error) {
+	var levels []*Structure
+
+	for _, definition := range definitions {
+		name := strings.Join(definition.Levels, ", ")
+		s, err := b.structure(TileDefinition{Name: name, Floor: definition.Floor, Ceiling: definition.Ceiling})
+		if err != nil {
+			return nil, err
+		}
+		if definition.Fog != nil {
+			if err := definition.Fog.validate(); err != nil {
+				return nil, fmt.Errorf("%q: %w", name, err)
+			}
+			s.WithFog(definition.Fog)
+		}
+
+		for _, levelText := range definition.Levels {
+			first, last, err := parseCodeRange(levelText)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", name, err)
+			}
+
+			if last >= len(levels) {
+				levels = append(levels, make([]*Structure, last+1-len(levels))...)
+			}
+			for level := first; level <= last; level++ {
+				if levels[level] != nil {
+					return nil, fmt.Errorf("%q: level %d is already defined", name, level)
+				}
+				levels[level] = s
+			}
+		}
+	}
+
+	return levels, nil
+}
+
+// overlay reads an overlay image from the first overlay file system that has it.
+func (b *tileTableBuilder) overlay(filename string) (image.Image, error) {
+	for _, overlays := range b.overlays {
+		file, err := overlays.Open(filename)
+		if err != nil {
+			continue
+		}
+
+		img, err := png.Decode(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not read overlay %s: %w", filename, err)
+		}
+		return img, nil
+	}
+
+	return nil, fmt.Errorf("overlay %s not found", filename)
+}
+
+// parseCodeRange parses a code ("0x18") or an inclusive code range ("0x6C-0x73").
+func parseCodeRange(text string) (first, last int, err error) {
+	firstText, lastText, isRange := strings.Cut(text, "-")
+
+	if first, err = parseCode(firstText); err != nil {
+		return 0, 0, err
+	}
+	last = first
+	if isRange {
+		if last, err = parseCode(lastText); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if first > last {
+		return 0, 0, fmt.Errorf("invalid code range %q, the first code is after the last code", text)
+	}
+
+	return first, last, nil
+}
+
+func parseCode(text string) (int, error) {
+	code, err := strconv.ParseInt(strings.TrimSpace(text), 0, 32)
+	if err != nil || code < 0 || code > maxTileCode {
+		return 0, fmt.Errorf("invalid code %q, expected a number from 0 to 0x%X", text, maxTileCode)
+	}
+	return int(code), nil
+}
@@ -0,0 +1,267 @@
+package raycastmap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"maze/internal/pkg/wolf3d"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDefaultTileTable(t *testing.T) {
+	tiles := DefaultTileTable()
+
+	t.Run("walls", func(t *testing.T) {
+		for code := 0x01; code <= 0x0C; code++ {
+			structure := tiles.Structure(code)
+			assert.True(t, structure.IsWall(), "code 0x%02X", code)
+			assert.True(t, structure.IsObstacle(), "code 0x%02X", code)
+			assert.NotNil(t, structure.Texture, "code 0x%02X", code)
+			assert.NotNil(t, structure.Texture2, "code 0x%02X", code)
+		}
+		assert.Same(t, tiles.StructureNamed("ExitDoor"), tiles.Structure(0x15))
+		assert.NotNil(t, tiles.StructureNamed("SwastikaFlagOnStoneWall").Overlay)
+	})
+
+	t.Run("doors are walls but not obstacles", func(t *testing.T) {
+		for _, code := range []int{0x5A, 0x5B, 0x5C, 0x5F, 0x64, 0x65} {
+			structure := tiles.Structure(code)
+			assert.True(t, structure.IsWall(), "code 0x%02X", code)
+			assert.False(t, structure.IsObstacle(), "code 0x%02X", code)
+		}
+		assert.True(t, tiles.DoorFrame().IsWall())
+		assert.True(t, tiles.DoorFrame().IsObstacle())
+	})
+
+	t.Run("floors are empty", func(t *testing.T) {
+		for _, code := range []int{0x00, 0x6A, 0x7C, 0x8F} {
+			structure := tiles.Structure(code)
+			assert.False(t, structure.IsWall(), "code 0x%02X", code)
+			assert.Nil(t, structure.Texture, "code 0x%02X", code)
+		}
+	})
+
+	t.Run("specials", func(t *testing.T) {
+		barrel := tiles.Special(0x18)
+		assert.Same(t, tiles.SpecialNamed("GreenBarrel"), barrel)
+		assert.True(t, barrel.IsSprite())
+		assert.True(t, barrel.IsObstacle())
+		assert.True(t, barrel.IsDecoration())
+
+		ammoClip := tiles.Special(0x31)
+		assert.True(t, ammoClip.IsItem())
+		assert.False(t, ammoClip.IsObstacle())
+
+		for _, code := range []int{0x6C, 0x73, 0x90, 0x97, 0xB4, 0xBB} {
+			assert.Same(t, tiles.SpecialNamed("BrownGuard"), tiles.Special(code), "code 0x%02X", code)
+		}
+		for _, code := range []int{0x86, 0x8D, 0xAA, 0xB1, 0xCE, 0xD5} {
+			assert.Same(t, tiles.SpecialNamed("BrownDog"), tiles.Special(code), "code 0x%02X", code)
+		}
+
+		deadDog := tiles.SpecialNamed("DeadDog")
+		assert.NotNil(t, deadDog.Texture, "a special without codes, only used by name")
+		assert.True(t, deadDog.IsSprite())
+
+		assert.Nil(t, tiles.Special(0x13).Texture)
+		assert.Nil(t, tiles.Special(0x5A).Texture)
+		assert.NotNil(t, tiles.Special(0x62).Texture)
+		assert.False(t, tiles.Special(0x62).IsSprite())
+	})
+
+	t.Run("undefined codes are unknown", func(t *testing.T) {
+		assert.NotNil(t, tiles.Structure(0x0D).Texture)
+		assert.Same(t, tiles.Structure(0x0D), tiles.Structure(0xffff))
+		assert.Same(t, tiles.Structure(0x0D), tiles.Structure(-1))
+		assert.NotNil(t, tiles.Special(0x1C).Texture)
+		assert.Nil(t, tiles.StructureNamed("NoSuchStructure"))
+	})
+}
+
+const testTileDefinitions = `{
+  "structures": [
+    {"name": "None", "codes": ["0x00"]},
+    {"name": "RedWall", "codes": ["0x01", "0x03-0x04"], "textures": ["RED", "DARKRED"], "overlay": "dots.png", "obstacle": true, "wall": true},
+    {"name": "Door", "codes": ["90"], "textures": ["DOOR"], "wall": true},
+    {"name": "Carpet", "codes": ["0x6A-0x8F"], "floor": "CARPET"}
+  ],
+  "specials": [
+    {"name": "Lamp", "codes": ["0x20"], "textures": ["LAMP"], "sprite": true, "decoration": true, "obstacle": true, "light": {"color": [1.0, 0.5, 0.0], "radius": 4, "falloff": 1}},
+    {"name": "Key", "codes": ["0x21"], "textures": ["KEY"], "sprite": true, "item": true}
+  ],
+  "doorFrame": {"name": "DoorFrame", "textures": ["FRAME"], "obstacle": true, "wall": true},
+  "unknownStructure": {"name": "Unknown", "overlay": "question-mark.png"},
+  "unknownSpecial": {"name": "Unknown"},
+  "levels": [
+    {"levels": ["0-1", "3"], "floor": "STONE", "ceiling": "WOOD", "fog": {"mode": "linear", "color": [0.5, 0.5, 0.5], "start": 2, "end": 10}}
+  ]
+}`
+
+func testOverlays() fstest.MapFS {
+	overlay, _ := os.ReadFile("overlay/cross.png")
+	return fstest.MapFS{"dots.png": {Data: overlay}}
+}
+
+func TestDefaultTileTableBlockingStatics(t *testing.T) {
+	// The obstacle flag of the object plane specials agrees with the statics that block movement in the original game
+	tiles := DefaultTileTable()
+	for code := 0; code < len(tiles.specials); code++ {
+		if !isDefined(tiles.specials, code) {
+			continue
+		}
+
+		object := wolf3d.ClassifyObject(0, 0, code)
+		assert.Equal(t, object.Blocking, tiles.Special(code).IsObstacle(), "code 0x%02X (%s)", code, object.Kind)
+	}
+}
+
+func TestLoadTileTable(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	tiles, err := LoadTileTable(strings.NewReader(testTileDefinitions), solidImageSource{color: red}, testOverlays())
+	assert.NoError(t, err)
+
+	redWall := tiles.StructureNamed("RedWall")
+	assert.NotNil(t, redWall)
+	assert.Same(t, redWall, tiles.Structure(0x01))
+	assert.Same(t, redWall, tiles.Structure(0x03))
+	assert.Same(t, redWall, tiles.Structure(0x04))
+	assert.True(t, redWall.IsWall())
+	assert.True(t, redWall.IsObstacle())
+	assert.NotNil(t, redWall.Texture2)
+	assert.NotNil(t, redWall.Overlay)
+
+	door := tiles.Structure(90)
+	assert.Same(t, tiles.StructureNamed("Door"), door)
+	assert.True(t, door.IsWall())
+	assert.False(t, door.IsObstacle())
+	assert.Nil(t, door.Texture2)
+
+	lamp := tiles.Special(0x20)
+	assert.True(t, lamp.IsSprite())
+	assert.True(t, lamp.IsDecoration())
+	assert.True(t, lamp.IsObstacle())
+	assert.Equal(t, red, lamp.Texture.DominantColor())
+	assert.Equal(t, &Light{Color: [3]float64{1.0, 0.5, 0.0}, Radius: 4.0, Falloff: 1.0}, lamp.Light)
+	assert.True(t, tiles.Special(0x21).IsItem())
+	assert.Nil(t, tiles.Special(0x21).Light)
+
+	assert.True(t, tiles.DoorFrame().IsWall())
+
+	carpet := tiles.StructureNamed("Carpet")
+	assert.NotNil(t, carpet.FloorTexture)
+	assert.Nil(t, carpet.CeilingTexture)
+	assert.Nil(t, carpet.Texture)
+
+	assert.Same(t, tiles.Level(0), tiles.Level(3))
+	assert.NotNil(t, tiles.Level(1).FloorTexture)
+	assert.NotNil(t, tiles.Level(1).CeilingTexture)
+	assert.Nil(t, tiles.Level(2).FloorTexture)
+	assert.Nil(t, tiles.Level(99).CeilingTexture)
+	assert.Equal(t, &Fog{Mode: FogLinear, Color: [3]float64{0.5, 0.5, 0.5}, Start: 2.0, End: 10.0}, tiles.Level(1).Fog)
+	assert.Nil(t, tiles.Level(2).Fog)
+
+	unknown := tiles.Structure(0x02)
+	assert.NotNil(t, unknown.Texture, "unknown structure overlay falls back to the embedded overlays")
+	assert.False(t, unknown.IsWall())
+	assert.Nil(t, tiles.Special(0x22).Texture)
+
+	t.Run("load textures", func(t *testing.T) {
+		blue := color.RGBA{B: 0xff, A: 0xff}
+		assert.NoError(t, tiles.LoadTextures(solidImageSource{color: blue}))
+		assert.Equal(t, blue, lamp.Texture.DominantColor())
+		assert.Equal(t, blue, door.Texture.DominantColor())
+		assert.Equal(t, blue, carpet.FloorTexture.DominantColor())
+		assert.Equal(t, blue, tiles.Level(0).CeilingTexture.DominantColor())
+	})
+}
+
+func TestLoadTileTableErrors(t *testing.T) {
+	images := solidImageSource{color: color.White}
+
+	tests := []struct {
+		name        string
+		definitions string
+	}{
+		{"invalid JSON", `{"structures": [`},
+		{"unknown field", `{"structures": [{"name": "Wall", "codes": ["0x01"], "solid": true}]}`},
+		{"invalid code", `{"structures": [{"name": "Wall", "codes": ["wall"]}]}`},
+		{"code out of range", `{"structures": [{"name": "Wall", "codes": ["0x10000"]}]}`},
+		{"reversed code range", `{"structures": [{"name": "Wall", "codes": ["0x05-0x01"]}]}`},
+		{"overlapping codes", `{"structures": [{"name": "Wall", "codes": ["0x01-0x05"]}, {"name": "Other", "codes": ["0x05"]}]}`},
+		{"duplicate name", `{"specials": [{"name": "Lamp", "codes": ["0x01"]}, {"name": "Lamp", "codes": ["0x02"]}]}`},
+		{"too many textures", `{"structures": [{"name": "Wall", "codes": ["0x01"], "textures": ["A", "B", "C"]}]}`},
+		{"missing image", `{"structures": [{"name": "Wall", "codes": ["0x01"], "textures": [""]}]}`},
+		{"missing overlay", `{"doorFrame": {"name": "DoorFrame", "overlay": "no-such-overlay.png"}}`},
+		{"invalid level", `{"levels": [{"levels": ["first"], "floor": "STONE"}]}`},
+		{"light without radius", `{"specials": [{"name": "Lamp", "codes": ["0x01"], "light": {"color": [1, 1, 1]}}]}`},
+		{"negative light falloff", `{"specials": [{"name": "Lamp", "codes": ["0x01"], "light": {"color": [1, 1, 1], "radius": 4, "falloff": -1}}]}`},
+		{"unknown fog mode", `{"levels": [{"levels": ["0"], "fog": {"mode": "smoke", "density": 0.1}}]}`},
+		{"linear fog without end", `{"levels": [{"levels": ["0"], "fog": {"mode": "linear", "start": 2}}]}`},
+		{"exponential fog without density", `{"levels": [{"levels": ["0"], "fog": {"mode": "exp2"}}]}`},
+		{"overlapping levels", `{"levels": [{"levels": ["0-9"], "floor": "STONE"}, {"levels": ["9"], "floor": "WOOD"}]}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := LoadTileTable(strings.NewReader(test.definitions), images)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadTileTableFromFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "tiles.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(testTileDefinitions), 0o644))
+	overlay, _ := os.ReadFile("overlay/cross.png")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "dots.png"), overlay, 0o644))
+
+	tiles, err := LoadTileTableFromFile(filename, solidImageSource{color: color.White})
+	assert.NoError(t, err)
+	assert.NotNil(t, tiles.StructureNamed("RedWall").Overlay)
+
+	_, err = LoadTileTableFromFile(filepath.Join(dir, "missing.json"), solidImageSource{color: color.White})
+	assert.Error(t, err)
+}
+
+func TestUndefinedCodes(t *testing.T) {
+	levelMaps, err := wolf3d.Wolfenstein3DMap()
+	assert.NoError(t, err)
+
+	structureCodes, specialCodes := DefaultTileTable().UndefinedCodes(&levelMaps[0])
+	assert.Empty(t, structureCodes)
+	assert.Empty(t, specialCodes)
+
+	structureCodes, specialCodes = DefaultTileTable().UndefinedCodes(&levelMaps[2])
+	assert.Equal(t, []int{0x0D, 0x0F}, structureCodes)
+	assert.Equal(t, []int{0x1E, 0x28, 0x29, 0x2B, 0x33, 0x7E, 0xA5, 0xC8}, specialCodes)
+}
+
+func TestWolfensteinMapWithTileTable(t *testing.T) {
+	levelMaps, err := wolf3d.Wolfenstein3DMap()
+	assert.NoError(t, err)
+
+	tiles, err := LoadTileTable(strings.NewReader(testTileDefinitions), solidImageSource{color: color.White}, testOverlays())
+	assert.NoError(t, err)
+
+	levelMap, err := NewWolfensteinMapFromLevelsAndTiles(levelMaps, tiles, 0)
+	assert.NoError(t, err)
+	assert.Same(t, tiles, levelMap.Tiles())
+	assert.Same(t, tiles.StructureNamed("RedWall"), levelMap.StructureAt(0, 0))
+	assert.Same(t, tiles.DoorFrame(), levelMap.Doors().All()[0].Frame)
+
+	// The floor cells have the carpet floor, the ceiling is the level ceiling
+	x, y := int(levelMap.StartX()), int(levelMap.StartY())
+	assert.Same(t, tiles.StructureNamed("Carpet"), levelMap.StructureAt(x, y))
+	assert.Same(t, tiles.StructureNamed("Carpet").FloorTexture, levelMap.FloorAt(x, y))
+	assert.Same(t, tiles.Level(0).CeilingTexture, levelMap.CeilingAt(x, y))
+	assert.Same(t, tiles.Level(0).FloorTexture, levelMap.FloorAt(0, 0))
+	assert.Same(t, tiles.Level(0).Fog, levelMap.Fog())
+
+	assert.NoError(t, levelMap.SetLevel(2))
+	assert.Nil(t, levelMap.CeilingAt(x, y), "level without floor and ceiling")
+	assert.Nil(t, levelMap.Fog())
+}
@@ -7,6 +7,7 @@ import (
 	"image"
 	"image/color"
 	"image/png"
+	"math"
 	"maze/internal/pkg/wolf3d"
 	"os"
 	"slices"
@@ -156,43 +157,37 @@ func TestPaintWolfensteinMap(t *testing.T) {
 
 	mapImage := image.NewRGBA(image.Rect(level, level, levelMap.Width()*(cellWidth+1)+1, levelMap.Height()*(cellWidth+1)+1))
 
-	UnknownTexture := NewTextureFromFile("overlay/question-mark.png")
-
-	startCell := Cell{Structure: StructureNone}
+	tiles := levelMap.Tiles()
+	exitDoor := tiles.StructureNamed("ExitDoor")
+	startCell := Cell{X: int(levelMap.StartX()), Y: int(levelMap.StartY()), Structure: StructureNone}
 
 	for y := level; y < levelMap.Height(); y++ {
 		for x := level; x < levelMap.Width(); x++ {
 			structure := levelMap.StructureAt(x, y)
 			special := levelMap.SpecialAt(x, y)
 
-			if special == SpecialStartPointFacingNorth || special == SpecialStartPointFacingSouth || special == SpecialStartPointFacingEast || special == SpecialStartPointFacingWest {
-				startCell = Cell{X: x, Y: y, Structure: special}
-			}
-
-			if structure == StructureExitDoor && structure.Texture2 != nil {
+			if structure == exitDoor && structure.Texture2 != nil {
 				// Exit door icon uses texture 2 (exit room sides are texture 1)
 				exitTexture := structure.Texture.img // Elevator handle bars walls
 
-				noWestWall := levelMap.StructureAt(x-1, y) == StructureNone
-				noEastWall := levelMap.StructureAt(x+1, y) == StructureNone
+				noWestWall := !levelMap.StructureAt(x-1, y).IsWall()
+				noEastWall := !levelMap.StructureAt(x+1, y).IsWall()
 				if noWestWall || noEastWall {
 					exitTexture = structure.Texture2.img // Elevator control panel wall
 				}
 
 				renderMapIcon(mapImage, x, levelMap.Height()-1-y, cellWidth, exitTexture)
-			} else if structure != StructureNone && structure.Texture != nil {
+			} else if structure.Texture != nil {
 				renderMapIcon(mapImage, x, levelMap.Height()-1-y, cellWidth, structure.Texture.img)
 			}
 
-			if special != SpecialNone && special.Texture != nil {
+			if special.Texture != nil {
 				renderMapIcon(mapImage, x, levelMap.Height()-1-y, cellWidth, special.Texture.img)
-			} else if special != SpecialNone {
-				renderMapIcon(mapImage, x, levelMap.Height()-1-y, cellWidth, UnknownTexture.img)
 			}
 		}
 	}
 
-	renderMapIcon(mapImage, startCell.X, levelMap.Height()-1-startCell.Y, cellWidth, SpecialBlueOrb.Texture.img)
+	renderMapIcon(mapImage, startCell.X, levelMap.Height()-1-startCell.Y, cellWidth, tiles.SpecialNamed("BlueOrb").Texture.img)
 
 	renderCellBorders(mapImage, levelMap.Width(), levelMap.Height(), cellWidth)
 
@@ -244,3 +239,254 @@ func renderMapIcon(mapImage *image.RGBA, cellX int, cellY int, cellSize int, tex
 		}
 	}
 }
+
+func TestWolfensteinMapStartPoint(t *testing.T) {
+	type testCase struct {
+		level            int
+		startX, startY   float64
+		startDir         float64
+		expectedErrorMsg string
+	}
+
+	testCases := []testCase{
+		{level: 0, startX: 29.5, startY: 6.5, startDir: 0.0},         // Facing east
+		{level: 1, startX: 16.5, startY: 2.5, startDir: math.Pi / 2}, // Facing north
+		{level: 2, startX: 20.5, startY: 62.5, startDir: math.Pi * 3 / 2},
+		{level: 4, startX: 32.5, startY: 29.5, startDir: math.Pi * 3 / 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("level %d", tc.level), func(t *testing.T) {
+			levelMap, err := NewWolfensteinMap(tc.level)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.startX, levelMap.StartX())
+			assert.Equal(t, tc.startY, levelMap.StartY())
+			assert.Equal(t, tc.startDir, levelMap.StartDir())
+		})
+	}
+}
+
+func TestWolfensteinMapStartPointErrors(t *testing.T) {
+	const width, height = 3, 3
+
+	noStartPoint := make([]uint16, width*height)
+	twoStartPoints := make([]uint16, width*height)
+	twoStartPoints[0] = 0x13
+	twoStartPoints[4] = 0x15
+	walls := make([]uint16, width*height)
+
+	_, err := NewWolfensteinMapFromLevels([]wolf3d.LevelMap{wolf3d.NewLevelMap("no start", width, height, walls, noStartPoint, nil)}, 0)
+	assert.ErrorContains(t, err, "has no start point")
+
+	_, err = NewWolfensteinMapFromLevels([]wolf3d.LevelMap{wolf3d.NewLevelMap("two starts", width, height, walls, twoStartPoints, nil)}, 0)
+	assert.ErrorContains(t, err, "has 2 start points")
+
+	_, err = NewWolfensteinMap(10)
+	assert.ErrorContains(t, err, "level 10 does not exist")
+}
+
+func TestWolfensteinMapObjectAt(t *testing.T) {
+	const width, height = 3, 3
+
+	objects := make([]uint16, width*height)
+	objects[0] = 0x13 // Start point facing north, level x=0, y=0
+	objects[5] = 0x6D // Standing guard facing north, level x=2, y=1
+	levelMap, err := NewWolfensteinMapFromLevels([]wolf3d.LevelMap{wolf3d.NewLevelMap("objects", width, height, make([]uint16, width*height), objects, nil)}, 0)
+	assert.NoError(t, err)
+
+	guard := levelMap.ObjectAt(2, 1)
+	assert.Equal(t, wolf3d.ObjectEnemy, guard.Kind)
+	assert.Equal(t, wolf3d.EnemyGuard, guard.Enemy)
+	assert.Equal(t, wolf3d.DirectionNorth, guard.Direction)
+	assert.Equal(t, 2, guard.X)
+	assert.Equal(t, 1, guard.Y, "map cell, y up")
+
+	assert.Equal(t, wolf3d.ObjectStartPoint, levelMap.ObjectAt(0, 2).Kind)
+	assert.Equal(t, wolf3d.ObjectNone, levelMap.ObjectAt(1, 1).Kind)
+}
+
+func TestWolfensteinMapObstacleAt(t *testing.T) {
+	const width, height = 3, 3
+
+	walls := make([]uint16, width*height)
+	objects := make([]uint16, width*height)
+	walls[2] = 0x01   // Wall, level x=2, y=0
+	objects[0] = 0x13 // Start point facing north, level x=0, y=0
+	objects[3] = 0x3A // Barrel, level x=0, y=1
+	objects[4] = 0x31 // Ammo clip, level x=1, y=1
+	objects[5] = 0x17 // Puddle, level x=2, y=1
+	objects[6] = 0x1A // Floor lamp, level x=0, y=2
+	levels := []wolf3d.LevelMap{wolf3d.NewLevelMap("obstacles", width, height, walls, objects, nil)}
+	levelMap, err := NewWolfensteinMapFromLevels(levels, 0)
+	assert.NoError(t, err)
+
+	assert.True(t, levelMap.ObstacleAt(2, 2), "wall")
+	assert.True(t, levelMap.ObstacleAt(0, 1), "barrel")
+	assert.True(t, levelMap.ObstacleAt(0, 0), "floor lamp")
+	assert.False(t, levelMap.ObstacleAt(1, 1), "ammo clip")
+	assert.False(t, levelMap.ObstacleAt(2, 1), "puddle")
+	assert.False(t, levelMap.ObstacleAt(0, 2), "start point")
+
+	// Each object that blocks movement in the original game is an obstacle
+	for y := 0; y < levelMap.Height(); y++ {
+		for x := 0; x < levelMap.Width(); x++ {
+			if levelMap.ObjectAt(x, y).Blocking {
+				assert.True(t, levelMap.ObstacleAt(x, y), "x %d, y %d", x, y)
+			}
+		}
+	}
+
+	// The obstacle flag of a special makes any object an obstacle, like the puddle of a mod
+	tiles, err := LoadTileTable(strings.NewReader(`{
+  "structures": [{"name": "Wall", "codes": ["0x01"], "textures": ["WALL"], "obstacle": true, "wall": true}],
+  "specials": [{"name": "Puddle", "codes": ["0x17"], "textures": ["PUDDLE"], "sprite": true, "obstacle": true}]
+}`), solidImageSource{color: color.White})
+	assert.NoError(t, err)
+	moddedMap, err := NewWolfensteinMapFromLevelsAndTiles(levels, tiles, 0)
+	assert.NoError(t, err)
+	assert.True(t, moddedMap.ObstacleAt(2, 1), "puddle")
+	assert.True(t, moddedMap.ObstacleAt(0, 1), "barrel")
+	assert.False(t, moddedMap.ObstacleAt(1, 1), "ammo clip")
+}
+
+func TestWolfensteinMapLevelProgression(t *testing.T) {
+	levelMap, err := NewWolfensteinMap(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, levelMap.LevelCount())
+	assert.Equal(t, "Wolf1 Map1", levelMap.LevelName())
+
+	assert.NoError(t, levelMap.NextLevel())
+	assert.Equal(t, 1, levelMap.Level())
+	assert.Equal(t, 16.5, levelMap.StartX())
+
+	assert.NoError(t, levelMap.SecretLevel())
+	assert.Equal(t, 9, levelMap.Level())
+	assert.Equal(t, "Wolf1 Secret", levelMap.LevelName())
+
+	assert.NoError(t, levelMap.NextLevel())
+	assert.Equal(t, 1, levelMap.Level(), "completing the secret level should continue at level 1")
+
+	assert.Error(t, levelMap.SetLevel(-1))
+	assert.Equal(t, 1, levelMap.Level())
+
+	assert.NoError(t, levelMap.SetLevel(8))
+	assert.ErrorIs(t, levelMap.NextLevel(), ErrGameCompleted, "the boss level of the last episode ends the game")
+	assert.Equal(t, 8, levelMap.Level(), "not on to the secret level")
+}
+
+func TestWolfensteinMapNextEpisode(t *testing.T) {
+	levelMaps, err := wolf3d.Wolfenstein3DMap()
+	assert.NoError(t, err)
+
+	// Two episodes, the second one a copy of the first one
+	levelMap, err := NewWolfensteinMapFromLevels(append(levelMaps[:len(levelMaps):len(levelMaps)], levelMaps...), 8)
+	assert.NoError(t, err)
+
+	assert.NoError(t, levelMap.NextLevel())
+	assert.Equal(t, 10, levelMap.Level(), "the boss level continues at the first level of the next episode")
+
+	assert.NoError(t, levelMap.SecretLevel())
+	assert.Equal(t, 19, levelMap.Level())
+	assert.NoError(t, levelMap.NextLevel())
+	assert.Equal(t, 11, levelMap.Level(), "the secret level continues in its own episode")
+}
+
+func TestWolfensteinMapUseElevatorSwitch(t *testing.T) {
+	levelMap, err := NewWolfensteinMap(0)
+	assert.NoError(t, err)
+
+	// Find all elevator switches and the floor cells in front of them
+	type elevatorSwitch struct {
+		x, y           int
+		fromX, fromY   int
+		secretElevator bool
+	}
+	var elevatorSwitches []elevatorSwitch
+	for y := 0; y < levelMap.Height(); y++ {
+		for x := 0; x < levelMap.Width(); x++ {
+			if levelMap.levelMaps[0].Tile(x, levelMap.Height()-1-y).Kind != wolf3d.TileElevatorSwitch {
+				continue
+			}
+			for _, d := range [][]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+				fromX, fromY := x+d[0], y+d[1]
+				if !levelMap.StructureAt(fromX, fromY).IsWall() {
+					secretElevator := levelMap.levelMaps[0].Tile(fromX, levelMap.Height()-1-fromY).IsSecretElevatorFloor()
+					elevatorSwitches = append(elevatorSwitches, elevatorSwitch{x: x, y: y, fromX: fromX, fromY: fromY, secretElevator: secretElevator})
+				}
+			}
+		}
+	}
+
+	assert.True(t, slices.ContainsFunc(elevatorSwitches, func(s elevatorSwitch) bool { return s.secretElevator }), "level 0 should have a secret elevator")
+	assert.True(t, slices.ContainsFunc(elevatorSwitches, func(s elevatorSwitch) bool { return !s.secretElevator }), "level 0 should have a regular elevator")
+
+	for _, s := range elevatorSwitches {
+		assert.NoError(t, levelMap.SetLevel(0))
+
+		levelChanged, err := levelMap.Use(s.x, s.y, s.fromX, s.fromY)
+		assert.NoError(t, err)
+		assert.True(t, levelChanged)
+
+		if s.secretElevator {
+			assert.Equal(t, 9, levelMap.Level())
+		} else {
+			assert.Equal(t, 1, levelMap.Level())
+		}
+	}
+
+	// Using a regular wall does nothing
+	assert.NoError(t, levelMap.SetLevel(0))
+	levelChanged, err := levelMap.Use(0, 0, 1, 1)
+	assert.NoError(t, err)
+	assert.False(t, levelChanged)
+	assert.Equal(t, 0, levelMap.Level())
+}
+
+type solidImageSource struct {
+	color color.Color
+}
+
+func (s solidImageSource) Image(name string) (image.Image, error) {
+	if name == "" {
+		return nil, fmt.Errorf("no image name")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, s.color)
+		}
+	}
+	return img, nil
+}
+
+type failingImageSource struct{}
+
+func (failingImageSource) Image(name string) (image.Image, error) {
+	return nil, fmt.Errorf("image %s not found", name)
+}
+
+func TestLoadTextures(t *testing.T) {
+	defer func() {
+		assert.NoError(t, LoadTextures(EmbeddedImageSource{}))
+	}()
+
+	greyStoneWall := DefaultTileTable().StructureNamed("GreyStoneWall1")
+	originalColor := greyStoneWall.Texture.DominantColor()
+
+	err := LoadTextures(failingImageSource{})
+	assert.Error(t, err)
+	assert.Equal(t, originalColor, greyStoneWall.Texture.DominantColor())
+
+	red := color.RGBA{R: 0xff, A: 0xff}
+	err = LoadTextures(solidImageSource{color: red})
+	assert.NoError(t, err)
+	assert.Equal(t, red, greyStoneWall.Texture.DominantColor())
+	assert.Equal(t, red, DefaultTileTable().SpecialNamed("GreenBarrel").Texture.DominantColor())
+	assert.NotNil(t, DefaultTileTable().StructureNamed("SwastikaFlagOnStoneWall").Overlay)
+
+	err = LoadTextures(EmbeddedImageSource{})
+	assert.NoError(t, err)
+	assert.Equal(t, originalColor, greyStoneWall.Texture.DominantColor())
+}
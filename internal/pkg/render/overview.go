@@ -0,0 +1,70 @@
+package render
+
+import (
+	"golang.org/x/image/colornames"
+	"image"
+	"image/color"
+	"maze/internal/pkg/raycastmap"
+)
+
+// RenderMap paints an overview map of the maze around the camera, with the camera position in the middle.
+// Each map cell is 2x2 pixels, obstacles are painted with the dominant color of their texture.
+func RenderMap(dst *image.RGBA, m raycastmap.Map, cam Camera) {
+	mapImage := zeroOrigin(dst)
+	colorBorder := color.NRGBA{R: 128, G: 16, B: 16, A: 128}
+
+	w := mapImage.Rect.Dx()
+	h := mapImage.Rect.Dy()
+	hw := w / 2
+	hh := h / 2
+
+	// Clear with black opaque (non-transparent) color
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			mapImage.Set(x, y, color.RGBA{A: 255})
+		}
+	}
+
+	xOffset := int(cam.Position.X) - hw/2
+	yOffset := int(cam.Position.Y) - hh/2
+
+	// Draw walls
+	for iy := 0; iy < hh; iy++ {
+		for ix := 0; ix < hw; ix++ {
+			mapx := ix + xOffset
+			mapy := iy + yOffset
+
+			c := color.Color(color.RGBA{A: 196})
+			if m.ObstacleAt(mapx, mapy) {
+				structure := m.StructureAt(mapx, mapy)
+				special := m.SpecialAt(mapx, mapy)
+				if structure != nil && structure.Texture != nil {
+					c = structure.Texture.DominantColor()
+				} else if special != nil && special.Texture != nil {
+					c = special.Texture.DominantColor()
+				}
+			}
+
+			mapImage.Set(2*ix, h-2*iy, c)
+			mapImage.Set(2*ix+1, h-2*iy, c)
+			mapImage.Set(2*ix, h-(2*iy+1), c)
+			mapImage.Set(2*ix+1, h-(2*iy+1), c)
+		}
+	}
+
+	// Observer (in the middle of map)
+	mapImage.Set(hw, hh, colornames.White)
+	mapImage.Set(hw+1, hh, colornames.White)
+	mapImage.Set(hw, hh+1, colornames.White)
+	mapImage.Set(hw+1, hh+1, colornames.White)
+
+	// Draw borders
+	for x := 0; x < w; x++ {
+		mapImage.Set(x, 0, colorBorder)
+		mapImage.Set(x, h-1, colorBorder)
+	}
+	for y := 0; y < h; y++ {
+		mapImage.Set(0, y, colorBorder)
+		mapImage.Set(w-1, y, colorBorder)
+	}
+}
@@ -0,0 +1,57 @@
+package render
+
+import (
+	"image/color"
+	"maze/internal/pkg/maze"
+)
+
+// colorPipeline converts the colors of textures (sRGB) to the colors the lighting is computed in, and the lit colors
+// to image bytes.
+//
+// With a tone mapper the lighting is computed in linear light and tone mapped, see maze.ToneMapper. Without one, the
+// lighting is computed on the sRGB colors directly and clamped, like the original rendering.
+type colorPipeline struct {
+	toneMapper *maze.ToneMapper
+}
+
+func newColorPipeline(opts RenderOptions) colorPipeline {
+	return colorPipeline{toneMapper: opts.ToneMapper}
+}
+
+// fromBytes gives the color of sRGB bytes, like texture pixels.
+func (p colorPipeline) fromBytes(r, g, b byte) maze.Color {
+	if p.toneMapper == nil {
+		return maze.NewColorFromByte(r, g, b)
+	}
+	return maze.NewColorFromSRGBByte(r, g, b)
+}
+
+// fromColor gives the color of an sRGB color, like a texture dominant color.
+func (p colorPipeline) fromColor(c color.Color) maze.Color {
+	if p.toneMapper == nil {
+		return maze.NewColorFromColor(c)
+	}
+	return maze.NewColorFromSRGBColor(c)
+}
+
+// fromSRGB gives the color of an sRGB surface color, like the flat roof and floor colors.
+func (p colorPipeline) fromSRGB(c maze.Color) maze.Color {
+	if p.toneMapper == nil {
+		return c
+	}
+	return c.SRGBToLinear()
+}
+
+// bytes gives the image bytes of a lit color.
+func (p colorPipeline) bytes(c maze.Color) (r, g, b byte) {
+	if p.toneMapper == nil {
+		return c.Bytes()
+	}
+	return p.toneMapper.Bytes(c)
+}
+
+// rgba gives the image color of a lit color.
+func (p colorPipeline) rgba(c maze.Color) color.RGBA {
+	r, g, b := p.bytes(c)
+	return color.RGBA{R: r, G: g, B: b, A: 255}
+}
@@ -0,0 +1,167 @@
+// Package render paints the observer view of a raycast map into an image, without any window or user interface.
+package render
+
+import (
+	"image"
+	"math"
+	"maze/internal/pkg/maze"
+	"maze/internal/pkg/raycastmap"
+)
+
+// attenuationFalloff is the distance falloff setting of the observer light (torch) attenuation.
+const attenuationFalloff = 15.0
+
+var (
+	AmbientLightOff  = maze.Color{}                       // No ambient light, only the observer light lights up the maze
+	AmbientLightFull = maze.Color{R: 1.0, G: 1.0, B: 1.0} // Full ambient light, like in the original game
+	AmbientLightDark = maze.Color{R: 0.2, G: 0.2, B: 0.3} // Dark (bluish) ambient light
+)
+
+// Camera is the observer point of view, see maze.Camera.
+type Camera = maze.Camera
+
+// RenderOptions are the rendering settings.
+type RenderOptions struct {
+	Textures      bool            // Paint walls and sprites with their textures, otherwise with the texture dominant colors
+	Surfaces      bool            // Paint the floor and the roof with the textures of the map cells (see raycastmap.SurfaceMap), otherwise with flat colors
+	AmbientLight  maze.Color      // Light everywhere in the maze, regardless of distance
+	ObserverLight bool            // Light from the observer (torch), fading with distance. Off uses the darker texture on East-West wall sides.
+	TorchLight    maze.Color      // Color of the observer light
+	LightMap      *maze.LightMap  // Light of the point lights (lamps) in the map, nil for no point lights, see maze.NewLightMap
+	Fog           *raycastmap.Fog // Fog fading walls, floor, roof and sprites into the fog color with the distance, nil for no fog
+
+	// ToneMapper makes the lighting computed in linear light, tone mapped to the image colors. Nil computes the lighting
+	// on the sRGB texture colors and clamps bright light to white, like the original rendering.
+	ToneMapper *maze.ToneMapper
+	AimLine    bool          // Show aim line ("cross-hair") in the middle pixel column
+	Sprites    []maze.Sprite // Sprites to paint, see maze.CollectSprites
+}
+
+// Renderer renders frame after frame, reusing the pixel column infos and the pixel buffers between the frames,
+// with the rays cast in parallel by a maze.Raycaster.
+//
+// A Renderer renders one frame at a time, it is not safe for concurrent use.
+type Renderer struct {
+	raycaster        *maze.Raycaster
+	pixelColumnInfos []maze.IntersectionInfo
+	scaledPixelData  []byte // Scaled texture pixel column, RGBA
+}
+
+// NewRenderer creates a renderer casting the rays with a number of worker goroutines, see maze.NewRaycaster.
+// Close the renderer when done.
+func NewRenderer(workers int) *Renderer {
+	return &Renderer{raycaster: maze.NewRaycaster(workers)}
+}
+
+// Close stops the raycasting worker goroutines.
+func (r *Renderer) Close() {
+	r.raycaster.Close()
+}
+
+// Render paints the view of the camera in map m: the roof and floor, the walls (and doors), and the sprites.
+// The field of view is adjusted to the aspect ratio of dst, see maze.Camera.
+func (r *Renderer) Render(dst *image.RGBA, m raycastmap.Map, cam Camera, opts RenderOptions) {
+	img := zeroOrigin(dst)
+	projection := cam.Projection(img.Rect.Dx(), img.Rect.Dy())
+
+	r.pixelColumnInfos = r.raycaster.Raycast(r.pixelColumnInfos, cam, img.Rect.Dx(), img.Rect.Dy(), m)
+	if len(r.scaledPixelData) < img.Rect.Dy()*4 {
+		r.scaledPixelData = make([]byte, img.Rect.Dy()*4) // A painted pixel column is never higher than the image
+	}
+
+	if surfaces, ok := m.(raycastmap.SurfaceMap); ok && opts.Surfaces {
+		paintSurfaces(img, &projection, &cam.Position, surfaces, opts)
+	} else if opts.LightMap != nil || opts.Fog != nil {
+		paintSurfaces(img, &projection, &cam.Position, flatSurfaces{}, opts) // Point lights and fog change the floor and the roof per pixel
+	} else {
+		paintBackground(img, &projection, opts)
+	}
+	if opts.Textures {
+		paintWallsTexturized(img, &projection, r.pixelColumnInfos, r.scaledPixelData, opts)
+	} else {
+		paintWallsColorized(img, &projection, r.pixelColumnInfos, opts)
+	}
+
+	projectedSprites := maze.ProjectSprites(opts.Sprites, cam, img.Rect.Dx(), img.Rect.Dy())
+	paintSprites(img, projectedSprites, r.pixelColumnInfos, r.scaledPixelData, &cam.Position, opts)
+}
+
+// Render paints the view of the camera in map m, see Renderer.Render.
+// The rays are cast serially and nothing is reused, use a Renderer for rendering frame after frame.
+func Render(dst *image.RGBA, m raycastmap.Map, cam Camera, opts RenderOptions) {
+	renderer := NewRenderer(1)
+	defer renderer.Close()
+
+	renderer.Render(dst, m, cam, opts)
+}
+
+// lights gives the ambient light and the observer light (black if the observer light is off).
+func lights(opts RenderOptions) (ambientLight maze.Color, torchLight maze.Color) {
+	ambientLight = opts.AmbientLight
+	torchLight = opts.TorchLight
+	if !opts.ObserverLight {
+		torchLight = maze.Color{}
+	}
+
+	return ambientLight, torchLight
+}
+
+// pointLight gives the light of the point lights at a map position (black without a light map).
+func pointLight(opts RenderOptions, position *maze.Vector) maze.Color {
+	if opts.LightMap == nil {
+		return maze.Color{}
+	}
+
+	return opts.LightMap.At(position)
+}
+
+// wallPointLight gives the light of the point lights on the wall side hit by a ray: the light of the map cell in front
+// of the wall side, towards the observer.
+func wallPointLight(opts RenderOptions, pixelColumnInfo *maze.IntersectionInfo) maze.Color {
+	if opts.LightMap == nil {
+		return maze.Color{}
+	}
+
+	const nudge = 0.01 // Move the intersection point (on the cell border) just off the wall
+	intersection := pixelColumnInfo.IntersectionPoint
+	return opts.LightMap.At(&maze.Vector{
+		X: intersection.X + math.Copysign(nudge, pixelColumnInfo.ObserverPoint.X-intersection.X),
+		Y: intersection.Y + math.Copysign(nudge, pixelColumnInfo.ObserverPoint.Y-intersection.Y),
+	})
+}
+
+// fogColor gives the color of the fog, black without fog.
+func fogColor(opts RenderOptions) maze.Color {
+	if opts.Fog == nil {
+		return maze.Color{}
+	}
+
+	return maze.NewColor(opts.Fog.Color[0], opts.Fog.Color[1], opts.Fog.Color[2])
+}
+
+// visibility gives how much is seen through the fog at a distance, range [0.0, 1.0]. It is 1.0 without fog.
+func visibility(opts RenderOptions, distance float64) float64 {
+	if opts.Fog == nil {
+		return 1.0
+	}
+
+	return opts.Fog.Visibility(distance)
+}
+
+// distanceAttenuation gives the observer light attenuation at a distance, range [0.0, 1.0].
+func distanceAttenuation(distance float64) float64 {
+	return min(1.0, max(0.0, attenuationFalloff/(distance*distance)))
+}
+
+// zeroOrigin gives an image using the same pixels as img, with the bounds moved to start at 0, 0.
+func zeroOrigin(img *image.RGBA) *image.RGBA {
+	if img.Rect.Min == (image.Point{}) {
+		return img
+	}
+
+	return &image.RGBA{
+		Pix:    img.Pix, // Pix starts at the pixel at Rect.Min
+		Stride: img.Stride,
+		Rect:   image.Rect(0, 0, img.Rect.Dx(), img.Rect.Dy()),
+	}
+}
@@ -0,0 +1,405 @@
+package render
+
+import (
+	"flag"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"math"
+	"maze/internal/pkg/maze"
+	"maze/internal/pkg/raycastmap"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden images in testdata/golden")
+
+const (
+	goldenWidth  = 320
+	goldenHeight = 200
+
+	goldenChannelTolerance = 2     // Largest difference in a color channel for a pixel to be considered equal
+	goldenPixelTolerance   = 0.002 // Largest fraction of pixels that may differ (floating point differences between platforms)
+)
+
+// assertGolden compares an image with the golden image testdata/golden/<name>.png, or updates the golden image if the
+// -update flag is given. On a mismatch, the rendered image is written to testdata/failed/<name>.png for inspection.
+func assertGolden(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+
+	goldenFilename := filepath.Join("testdata", "golden", name+".png")
+	if *update {
+		assert.NoError(t, writePNG(goldenFilename, img))
+		return
+	}
+
+	golden, err := readPNG(goldenFilename)
+	if !assert.NoError(t, err, "run the tests with -update to create the golden image") {
+		return
+	}
+	if !assert.Equal(t, golden.Bounds(), img.Bounds()) {
+		return
+	}
+
+	differentPixels := 0
+	firstDifference := ""
+	for y := golden.Bounds().Min.Y; y < golden.Bounds().Max.Y; y++ {
+		for x := golden.Bounds().Min.X; x < golden.Bounds().Max.X; x++ {
+			gr, gg, gb, ga := golden.At(x, y).RGBA()
+			r, g, b, a := img.At(x, y).RGBA()
+			if channelDifference(gr, r) > goldenChannelTolerance || channelDifference(gg, g) > goldenChannelTolerance ||
+				channelDifference(gb, b) > goldenChannelTolerance || channelDifference(ga, a) > goldenChannelTolerance {
+				if differentPixels == 0 {
+					firstDifference = fmt.Sprintf("first at %d, %d: expected %v, got %v", x, y, golden.At(x, y), img.At(x, y))
+				}
+				differentPixels++
+			}
+		}
+	}
+
+	maxDifferentPixels := int(goldenPixelTolerance * float64(img.Bounds().Dx()*img.Bounds().Dy()))
+	if differentPixels > maxDifferentPixels {
+		failedFilename := filepath.Join("testdata", "failed", name+".png")
+		_ = writePNG(failedFilename, img)
+		t.Errorf("%s: %d pixels differ from the golden image (%s), rendered image written to %s", name, differentPixels, firstDifference, failedFilename)
+	}
+}
+
+func channelDifference(a, b uint32) uint32 {
+	a, b = a>>8, b>>8
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func readPNG(filename string) (image.Image, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return png.Decode(file)
+}
+
+func writePNG(filename string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return png.Encode(file, img)
+}
+
+var torchLight = maze.Color{R: 1.0, G: 1.0, B: 0.9}
+
+func TestRenderTestMaps(t *testing.T) {
+	tests := []struct {
+		name string
+		m    raycastmap.Map
+		cam  Camera
+		opts RenderOptions
+	}{
+		{
+			name: "testmap1-start-textured",
+			m:    raycastmap.TestMap1,
+			cam:  Camera{Position: maze.Vector{X: raycastmap.TestMap1.StartX(), Y: raycastmap.TestMap1.StartY()}, Heading: raycastmap.TestMap1.StartDir()},
+			opts: RenderOptions{Textures: true, AmbientLight: AmbientLightFull},
+		},
+		{
+			name: "testmap1-corner-torch",
+			m:    raycastmap.TestMap1,
+			cam:  Camera{Position: maze.Vector{X: 3.5, Y: 12.5}, Heading: math.Pi / 4.0},
+			opts: RenderOptions{Textures: true, AmbientLight: AmbientLightDark, ObserverLight: true, TorchLight: torchLight},
+		},
+		{
+			name: "testmap2-start-colorized",
+			m:    raycastmap.TestMap2,
+			cam:  Camera{Position: maze.Vector{X: raycastmap.TestMap2.StartX(), Y: raycastmap.TestMap2.StartY()}, Heading: raycastmap.TestMap2.StartDir()},
+			opts: RenderOptions{AmbientLight: AmbientLightFull},
+		},
+		{
+			name: "testmap2-start-aim-line",
+			m:    raycastmap.TestMap2,
+			cam:  Camera{Position: maze.Vector{X: raycastmap.TestMap2.StartX(), Y: raycastmap.TestMap2.StartY()}, Heading: raycastmap.TestMap2.StartDir()},
+			opts: RenderOptions{Textures: true, AmbientLight: AmbientLightFull, AimLine: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, goldenWidth, goldenHeight))
+			Render(img, test.m, test.cam, test.opts)
+			assertGolden(t, test.name, img)
+		})
+	}
+}
+
+func TestRenderWolfensteinLevel0(t *testing.T) {
+	levelMap, err := raycastmap.NewWolfensteinMap(0)
+	assert.NoError(t, err)
+
+	start := Camera{Position: maze.Vector{X: levelMap.StartX(), Y: levelMap.StartY()}, Heading: levelMap.StartDir()}
+	sprites := maze.CollectSprites(levelMap)
+	lightMap := maze.NewLightMap(levelMap, maze.CollectLights(levelMap))
+	corridor := Camera{Position: maze.Vector{X: 2.5, Y: 15.5}, Heading: math.Pi / 2.0} // Long view (25 cells) along a corridor
+	lamps := Camera{Position: maze.Vector{X: 10.5, Y: 26.5}, Heading: math.Pi / 2.0}   // Room with green lamps, and wall shadows
+
+	tests := []struct {
+		name string
+		cam  Camera
+		opts RenderOptions
+	}{
+		{
+			name: "wl1-level0-start",
+			cam:  start,
+			opts: RenderOptions{Textures: true, AmbientLight: AmbientLightFull, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-start-torch",
+			cam:  start,
+			opts: RenderOptions{Textures: true, AmbientLight: AmbientLightDark, ObserverLight: true, TorchLight: torchLight, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-start-colorized",
+			cam:  start,
+			opts: RenderOptions{AmbientLight: AmbientLightFull, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-surfaces",
+			cam:  start,
+			opts: RenderOptions{Textures: true, Surfaces: true, AmbientLight: AmbientLightFull, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-surfaces-torch",
+			cam:  start,
+			opts: RenderOptions{Textures: true, Surfaces: true, AmbientLight: AmbientLightDark, ObserverLight: true, TorchLight: torchLight, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-surfaces-colorized",
+			cam:  start,
+			opts: RenderOptions{Surfaces: true, AmbientLight: AmbientLightFull, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-lamps",
+			cam:  lamps,
+			opts: RenderOptions{Textures: true, Surfaces: true, AmbientLight: AmbientLightDark, LightMap: lightMap, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-lamps-flat",
+			cam:  lamps,
+			opts: RenderOptions{Textures: true, AmbientLight: AmbientLightDark, LightMap: lightMap, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-fog-linear",
+			cam:  corridor,
+			opts: RenderOptions{Textures: true, Surfaces: true, AmbientLight: AmbientLightFull, Fog: &raycastmap.Fog{Mode: raycastmap.FogLinear, Color: [3]float64{0.6, 0.6, 0.6}, Start: 2.0, End: 12.0}, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-fog-exp",
+			cam:  corridor,
+			opts: RenderOptions{Textures: true, Surfaces: true, AmbientLight: AmbientLightFull, Fog: &raycastmap.Fog{Mode: raycastmap.FogExponential, Color: [3]float64{0.1, 0.1, 0.15}, Density: 0.15}, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-fog-exp2",
+			cam:  corridor,
+			opts: RenderOptions{Textures: true, Surfaces: true, AmbientLight: AmbientLightFull, Fog: &raycastmap.Fog{Mode: raycastmap.FogExponentialSquared, Color: [3]float64{0.1, 0.1, 0.15}, Density: 0.1}, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-fog-colorized",
+			cam:  Camera{Position: corridor.Position, Heading: corridor.Heading, MaxDistance: 10.0},
+			opts: RenderOptions{AmbientLight: AmbientLightFull, Fog: &raycastmap.Fog{Mode: raycastmap.FogLinear, Color: [3]float64{0.3, 0.3, 0.4}, Start: 1.0, End: 10.0}, Sprites: sprites},
+		},
+		{
+			name: "wl1-level0-start-torch-linear",
+			cam:  start,
+			opts: RenderOptions{Textures: true, AmbientLight: AmbientLightDark, ObserverLight: true, TorchLight: torchLight, Sprites: sprites, ToneMapper: maze.NewToneMapper(maze.ToneMappingClamp, 1.0, 0.0)},
+		},
+		{
+			name: "wl1-level0-start-torch-reinhard",
+			cam:  start,
+			opts: RenderOptions{Textures: true, AmbientLight: AmbientLightDark, ObserverLight: true, TorchLight: torchLight, Sprites: sprites, ToneMapper: maze.NewToneMapper(maze.ToneMappingReinhard, 1.5, 0.0)},
+		},
+		{
+			name: "wl1-level0-surfaces-torch-aces",
+			cam:  start,
+			opts: RenderOptions{Textures: true, Surfaces: true, AmbientLight: AmbientLightDark, ObserverLight: true, TorchLight: torchLight, Sprites: sprites, ToneMapper: maze.NewToneMapper(maze.ToneMappingACES, 1.0, 0.0)},
+		},
+		{
+			name: "wl1-level0-lamps-aces-gamma",
+			cam:  lamps,
+			opts: RenderOptions{Textures: true, Surfaces: true, AmbientLight: AmbientLightOff, LightMap: lightMap, Sprites: sprites, ToneMapper: maze.NewToneMapper(maze.ToneMappingACES, 2.0, 2.2)},
+		},
+		{
+			name: "wl1-level0-angled",
+			cam:  Camera{Position: maze.Vector{X: start.Position.X - 1.2, Y: start.Position.Y + 1.0}, Heading: start.Heading - math.Pi/5.0},
+			opts: RenderOptions{Textures: true, AmbientLight: AmbientLightFull, Sprites: sprites},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, goldenWidth, goldenHeight))
+			Render(img, levelMap, test.cam, test.opts)
+			assertGolden(t, test.name, img)
+		})
+	}
+
+	t.Run("wl1-level0-map", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+		RenderMap(img, levelMap, start)
+		assertGolden(t, "wl1-level0-map", img)
+	})
+}
+
+func TestRenderCamera(t *testing.T) {
+	levelMap, err := raycastmap.NewWolfensteinMap(0)
+	assert.NoError(t, err)
+
+	start := maze.NewCamera(maze.Vector{X: levelMap.StartX(), Y: levelMap.StartY()}, levelMap.StartDir())
+	opts := RenderOptions{Textures: true, AmbientLight: AmbientLightFull, Sprites: maze.CollectSprites(levelMap)}
+
+	withCamera := func(change func(cam *Camera)) Camera {
+		cam := start
+		change(&cam)
+		return cam
+	}
+
+	tests := []struct {
+		name          string
+		width, height int
+		cam           Camera
+	}{
+		{name: "wl1-level0-widescreen", width: 356, height: 200, cam: start},
+		{name: "wl1-level0-fov90", width: goldenWidth, height: goldenHeight, cam: withCamera(func(cam *Camera) { cam.FOV = 90.0 })},
+		{name: "wl1-level0-look-up", width: goldenWidth, height: goldenHeight, cam: withCamera(func(cam *Camera) { cam.Pitch = 0.3 })},
+		{name: "wl1-level0-look-down", width: goldenWidth, height: goldenHeight, cam: withCamera(func(cam *Camera) { cam.Pitch = -0.3 })},
+		{name: "wl1-level0-crouch", width: goldenWidth, height: goldenHeight, cam: withCamera(func(cam *Camera) { cam.EyeHeight = 0.2 })},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, test.width, test.height))
+			Render(img, levelMap, test.cam, opts)
+			assertGolden(t, test.name, img)
+		})
+	}
+}
+
+func TestRenderFogMaxDistance(t *testing.T) {
+	levelMap, err := raycastmap.NewWolfensteinMap(0)
+	assert.NoError(t, err)
+
+	for _, fog := range []*raycastmap.Fog{
+		{Mode: raycastmap.FogLinear, Color: [3]float64{0.6, 0.6, 0.6}, Start: 2.0, End: 8.0},
+		{Mode: raycastmap.FogExponential, Color: [3]float64{0.1, 0.1, 0.15}, Density: 0.5},
+		{Mode: raycastmap.FogExponentialSquared, Color: [3]float64{0.1, 0.1, 0.15}, Density: 0.3},
+	} {
+		t.Run(string(fog.Mode), func(t *testing.T) {
+			cam := Camera{Position: maze.Vector{X: 2.5, Y: 15.5}, Heading: math.Pi / 2.0}
+			opts := RenderOptions{Textures: true, Surfaces: true, AmbientLight: AmbientLightFull, Fog: fog, Sprites: maze.CollectSprites(levelMap)}
+
+			expected := image.NewRGBA(image.Rect(0, 0, goldenWidth, goldenHeight))
+			Render(expected, levelMap, cam, opts)
+
+			cam.MaxDistance = fog.Distance()
+			actual := image.NewRGBA(image.Rect(0, 0, goldenWidth, goldenHeight))
+			Render(actual, levelMap, cam, opts)
+
+			// Nothing can be seen where the fog is full, so stopping the rays there does not change the view
+			for i := range expected.Pix {
+				if channelDifference(uint32(expected.Pix[i])<<8, uint32(actual.Pix[i])<<8) > 1 {
+					assert.Fail(t, "rendered differently with the max distance", "at pixel %d, %d", (i%expected.Stride)/4, i/expected.Stride)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestRenderSubImage(t *testing.T) {
+	cam := Camera{Position: maze.Vector{X: raycastmap.TestMap1.StartX(), Y: raycastmap.TestMap1.StartY()}, Heading: raycastmap.TestMap1.StartDir()}
+	opts := RenderOptions{Textures: true, AmbientLight: AmbientLightFull}
+
+	img := image.NewRGBA(image.Rect(0, 0, goldenWidth, goldenHeight))
+	Render(img, raycastmap.TestMap1, cam, opts)
+
+	// Rendering into a part of a larger image gives the same pixels, and leaves the rest of the larger image untouched
+	larger := image.NewRGBA(image.Rect(0, 0, goldenWidth+20, goldenHeight+20))
+	part := larger.SubImage(image.Rect(10, 10, goldenWidth+10, goldenHeight+10)).(*image.RGBA)
+	Render(part, raycastmap.TestMap1, cam, opts)
+
+	for y := 0; y < goldenHeight; y++ {
+		for x := 0; x < goldenWidth; x++ {
+			if img.RGBAAt(x, y) != larger.RGBAAt(x+10, y+10) {
+				t.Fatalf("pixel %d, %d differs", x, y)
+			}
+		}
+	}
+	assert.Zero(t, larger.RGBAAt(5, 5).A)
+	assert.Zero(t, larger.RGBAAt(goldenWidth+15, goldenHeight+15).A)
+}
+
+func TestRendererIdenticalToRender(t *testing.T) {
+	levelMap, err := raycastmap.NewWolfensteinMap(0)
+	assert.NoError(t, err)
+
+	cam := maze.NewCamera(maze.Vector{X: levelMap.StartX() - 1.2, Y: levelMap.StartY() + 1.0}, levelMap.StartDir()-math.Pi/5.0)
+	opts := RenderOptions{Textures: true, AmbientLight: AmbientLightDark, ObserverLight: true, TorchLight: torchLight, Sprites: maze.CollectSprites(levelMap)}
+
+	expected := image.NewRGBA(image.Rect(0, 0, goldenWidth, goldenHeight))
+	Render(expected, levelMap, cam, opts)
+
+	renderer := NewRenderer(4)
+	defer renderer.Close()
+
+	// Render twice, the second frame reuses the buffers of the first
+	for frame := 0; frame < 2; frame++ {
+		img := image.NewRGBA(image.Rect(0, 0, goldenWidth, goldenHeight))
+		renderer.Render(img, levelMap, cam, opts)
+		assert.Equal(t, expected.Pix, img.Pix, "frame %d", frame)
+	}
+}
+
+func benchmarkRender(b *testing.B, render func(img *image.RGBA, m raycastmap.Map, cam Camera, opts RenderOptions), toneMapper *maze.ToneMapper) {
+	levelMap, err := raycastmap.NewWolfensteinMap(0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	cam := maze.NewCamera(maze.Vector{X: levelMap.StartX() - 1.2, Y: levelMap.StartY() + 1.0}, levelMap.StartDir()-math.Pi/5.0)
+	opts := RenderOptions{Textures: true, AmbientLight: AmbientLightDark, ObserverLight: true, TorchLight: torchLight, Sprites: maze.CollectSprites(levelMap), ToneMapper: toneMapper}
+	img := image.NewRGBA(image.Rect(0, 0, 640, 400))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		render(img, levelMap, cam, opts)
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	benchmarkRender(b, Render, nil)
+}
+
+func BenchmarkRenderer(b *testing.B) {
+	renderer := NewRenderer(0)
+	defer renderer.Close()
+
+	benchmarkRender(b, renderer.Render, nil)
+}
+
+func BenchmarkRendererToneMapped(b *testing.B) {
+	renderer := NewRenderer(0)
+	defer renderer.Close()
+
+	benchmarkRender(b, renderer.Render, maze.NewToneMapper(maze.ToneMappingACES, 1.0, 0.0))
+}
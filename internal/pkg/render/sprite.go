@@ -0,0 +1,72 @@
+package render
+
+import (
+	"image"
+	"maze/internal/pkg/maze"
+)
+
+// paintSprites paints the sprites (barrels, lamps, guards, treasures...) on top of the already painted walls.
+// The sprites are expected to be sorted back-to-front, and each sprite pixel column is clipped against the wall depth of that pixel column.
+func paintSprites(img *image.RGBA, projectedSprites []maze.ProjectedSprite, pixelColumnInfos []maze.IntersectionInfo, scaledPixelData []byte, observer *maze.Vector, opts RenderOptions) {
+	h := img.Rect.Dy()
+
+	ambientLight, torchLight := lights(opts)
+	pipeline := newColorPipeline(opts)
+	fog := pipeline.fromSRGB(fogColor(opts))
+
+	for _, projectedSprite := range projectedSprites {
+		texture := projectedSprite.Structure.Texture
+
+		imageYStart := max(0, projectedSprite.Top)
+		imageYEnd := min(h, projectedSprite.Top+projectedSprite.Height)
+		actualPixelColumnHeight := imageYEnd - imageYStart
+		if actualPixelColumnHeight <= 0 {
+			continue
+		}
+
+		yLength := float64(actualPixelColumnHeight) / float64(projectedSprite.Height)
+		yOffset := float64(imageYStart-projectedSprite.Top) / float64(projectedSprite.Height)
+
+		distance := projectedSprite.Position.Sub(observer).Length()
+		light := ambientLight.Add(pointLight(opts, projectedSprite.Position)).Add(torchLight.Scale(distanceAttenuation(distance)))
+
+		fogFactor := 1.0 - visibility(opts, distance)
+
+		columnPixelData := scaledPixelData[:actualPixelColumnHeight*4]
+		dominantColor := pipeline.rgba(pipeline.fromColor(texture.DominantColor()).Mul(light).FadeTo(fog, fogFactor))
+
+		for x := max(0, projectedSprite.StartColumn); x < min(len(pixelColumnInfos), projectedSprite.EndColumn); x++ {
+			if !projectedSprite.VisibleInColumn(pixelColumnInfos[x]) {
+				continue // Sprite pixel column is behind the wall
+			}
+
+			texture.ReadScaledPixelColumn(projectedSprite.TextureOffset(x), yOffset, yLength, columnPixelData)
+
+			imgDataOffset := img.PixOffset(x, imageYStart)
+			for pixelYIndex := 0; pixelYIndex < actualPixelColumnHeight; pixelYIndex++ {
+				imageDataIndex := imgDataOffset + pixelYIndex*img.Stride
+				alpha := columnPixelData[pixelYIndex*4+3]
+				if alpha == 0 {
+					continue // Transparent sprite pixel
+				}
+
+				rb, gb, bb := dominantColor.R, dominantColor.G, dominantColor.B
+				if opts.Textures {
+					pixelColor := pipeline.fromBytes(columnPixelData[pixelYIndex*4+0], columnPixelData[pixelYIndex*4+1], columnPixelData[pixelYIndex*4+2])
+					litColor := pixelColor.Mul(light)
+					if fogFactor > 0.0 {
+						litColor = litColor.FadeTo(fog, fogFactor)
+					}
+					rb, gb, bb = pipeline.bytes(litColor)
+				}
+
+				// Blend sprite pixel over the wall (or background) using the sprite alpha channel
+				a := uint16(alpha)
+				img.Pix[imageDataIndex+0] = byte((uint16(rb)*a + uint16(img.Pix[imageDataIndex+0])*(255-a)) / 255)
+				img.Pix[imageDataIndex+1] = byte((uint16(gb)*a + uint16(img.Pix[imageDataIndex+1])*(255-a)) / 255)
+				img.Pix[imageDataIndex+2] = byte((uint16(bb)*a + uint16(img.Pix[imageDataIndex+2])*(255-a)) / 255)
+				img.Pix[imageDataIndex+3] = 255
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"maze/internal/pkg/maze"
+	"maze/internal/pkg/raycastmap"
+)
+
+// paintSurfaces paints the roof and the floor pixel by pixel ("floor casting"): each pixel is cast back onto the roof
+// or the floor in the map, and painted with the texture of the map cell there. Map cells without a roof or floor
+// texture are painted with the flat colors of paintBackground.
+//
+// The lighting and the fog are the same as for walls: ambient light and the point lights of the map cell, plus observer
+// light fading with the distance, faded into the fog color with the distance.
+func paintSurfaces(img *image.RGBA, projection *maze.Projection, observer *maze.Vector, surfaces raycastmap.SurfaceMap, opts RenderOptions) {
+	ambientLight, torchLight := lights(opts)
+	pipeline := newColorPipeline(opts)
+	fog := pipeline.fromSRGB(fogColor(opts))
+	roofColor, floorColor := pipeline.fromSRGB(colorRoof), pipeline.fromSRGB(colorFloor)
+
+	width := img.Rect.Dx()
+	leftRayDir := projection.RayDirection(0)
+	rightRayDir := projection.RayDirection(width)
+
+	for y := 0; y < img.Rect.Dy(); y++ {
+		roof := float64(y)+0.5 < projection.Horizon
+		flatColor := floorColor
+		if roof {
+			flatColor = roofColor
+		}
+
+		distance := projection.RowDistance(y)
+		if math.IsInf(distance, 1) {
+			drawHorizontalLine(img, y, pipeline.rgba(flatColor.Mul(ambientLight).FadeTo(fog, 1.0-visibility(opts, distance)))) // The horizon, infinitely far away
+			continue
+		}
+		if projection.MaxDistance > 0.0 && distance > projection.MaxDistance {
+			drawHorizontalLine(img, y, pipeline.rgba(fog)) // Further away than the walls are seen, only fog
+			continue
+		}
+
+		// Map position seen in the leftmost pixel of the row, and the step to the next pixel
+		positionX := observer.X + distance*leftRayDir.X
+		positionY := observer.Y + distance*leftRayDir.Y
+		stepX := distance * (rightRayDir.X - leftRayDir.X) / float64(width)
+		stepY := distance * (rightRayDir.Y - leftRayDir.Y) / float64(width)
+
+		imageDataIndex := img.PixOffset(0, y)
+		for x := 0; x < width; x, imageDataIndex = x+1, imageDataIndex+4 {
+			cellX, cellY := int(math.Floor(positionX)), int(math.Floor(positionY))
+
+			texture := surfaces.FloorAt(cellX, cellY)
+			if roof {
+				texture = surfaces.CeilingAt(cellX, cellY)
+			}
+
+			c := flatColor
+			if texture != nil {
+				if opts.Textures {
+					// Map y is pointing north, texture y is pointing down (south when looking north)
+					r, g, b, _ := texture.Texel(positionX-float64(cellX), float64(cellY)+1.0-positionY)
+					c = pipeline.fromBytes(r, g, b)
+				} else {
+					c = pipeline.fromColor(texture.DominantColor())
+				}
+			}
+
+			// Same as paintBackground, but with the distance to the pixel instead of to the row
+			dx, dy := positionX-observer.X, positionY-observer.Y
+			pixelDistance := math.Sqrt(dx*dx + dy*dy)
+			cosAngle := 1.0 / math.Sqrt(pixelDistance*pixelDistance+1.0)
+
+			light := ambientLight
+			if opts.LightMap != nil {
+				light = light.Add(opts.LightMap.CellAt(cellX, cellY))
+			}
+
+			litColor := c.Mul(light).Add(c.Mul(torchLight).Scale(cosAngle).Scale(distanceAttenuation(pixelDistance)))
+			if fogFactor := 1.0 - visibility(opts, pixelDistance); fogFactor > 0.0 {
+				litColor = litColor.FadeTo(fog, fogFactor)
+			}
+			rb, gb, bb := pipeline.bytes(litColor)
+			img.Pix[imageDataIndex+0] = rb
+			img.Pix[imageDataIndex+1] = gb
+			img.Pix[imageDataIndex+2] = bb
+			img.Pix[imageDataIndex+3] = 255
+
+			positionX += stepX
+			positionY += stepY
+		}
+	}
+}
+
+// flatSurfaces is a surface map without any floor or roof textures, painted with the flat colors by paintSurfaces.
+type flatSurfaces struct{}
+
+func (flatSurfaces) FloorAt(_, _ int) *raycastmap.Texture {
+	return nil
+}
+
+func (flatSurfaces) CeilingAt(_, _ int) *raycastmap.Texture {
+	return nil
+}
+
+func drawHorizontalLine(img *image.RGBA, y int, c color.RGBA) {
+	for x := 0; x < img.Rect.Dx(); x++ {
+		img.SetRGBA(x, y, c)
+	}
+}
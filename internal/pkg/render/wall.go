@@ -0,0 +1,162 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"maze/internal/pkg/maze"
+)
+
+// Flat colors of the roof (ceiling) and the floor, like in the original game
+var (
+	colorRoof  = maze.NewColor(0.23, 0.23, 0.23)
+	colorFloor = maze.NewColor(0.42, 0.42, 0.42)
+)
+
+// paintBackground paints the "background" of the game. That is, the roof and the floor.
+func paintBackground(img *image.RGBA, projection *maze.Projection, opts RenderOptions) {
+	ambientLight, torchLight := lights(opts)
+	pipeline := newColorPipeline(opts)
+
+	for y := 0; y < img.Rect.Dy(); y++ {
+		c := pipeline.fromSRGB(colorRoof)
+		if float64(y)+0.5 >= projection.Horizon {
+			c = pipeline.fromSRGB(colorFloor)
+		}
+
+		distance := projection.RowDistance(y)
+
+		cosAngle := maze.NewVector(distance, 1.0).Normalized().Y // Height above ground should really be 0.5 i.e. half a wall height up from the ground, not 1.0 (but 1.0 yields better result)
+
+		c = c.Mul(ambientLight).Add(c.Mul(torchLight).Scale(cosAngle).Scale(distanceAttenuation(distance)))
+		rgba := pipeline.rgba(c)
+
+		for x := 0; x < img.Rect.Dx(); x++ {
+			img.SetRGBA(x, y, rgba)
+		}
+	}
+}
+
+// paintWallsTexturized paints the wall pixel columns with scaled texture pixel columns.
+// The scaledPixelData is used for the scaled texture pixel columns, it must have room for a pixel column as high as the image.
+func paintWallsTexturized(img *image.RGBA, projection *maze.Projection, pixelColumnInfos []maze.IntersectionInfo, scaledPixelData []byte, opts RenderOptions) {
+	ambientLight, torchLight := lights(opts)
+	pipeline := newColorPipeline(opts)
+	fog := pipeline.fromSRGB(fogColor(opts))
+
+	for x, pixelColumnInfo := range pixelColumnInfos {
+		top, bottom, startY, endY := wallRows(img, projection, pixelColumnInfo.PerpendicularDistance)
+		actualPixelColumnHeight := endY - startY
+
+		if !pixelColumnInfo.Hit {
+			drawVerticalLine(img, x, startY, endY, pipeline.rgba(fog)) // Nothing within the max distance, only fog
+			continue
+		}
+
+		// Draw scaled texture pixel column
+		texture := pixelColumnInfo.Wall.Structure.Texture
+		if pixelColumnInfo.Side == 0 && pixelColumnInfo.Wall.Structure.Texture2 != nil && !opts.ObserverLight {
+			texture = pixelColumnInfo.Wall.Structure.Texture2 // Use darker texture on East-West facing wall sides of a cell
+		}
+		if texture == nil || actualPixelColumnHeight <= 0 {
+			continue
+		}
+
+		xOffset := pixelColumnInfo.WallSideIntersectionOffset
+		yLength := float64(actualPixelColumnHeight) / (bottom - top)
+		yOffset := (float64(startY) - top) / (bottom - top)
+		columnPixelData := scaledPixelData[:actualPixelColumnHeight*4]
+		texture.ReadScaledPixelColumn(xOffset, yOffset, yLength, columnPixelData)
+
+		distance := pixelColumnInfo.IntersectionPoint.Sub(&pixelColumnInfo.ObserverPoint).Length()
+		cosIntersectionAngle := 1.0
+		attenuation := 1.0
+		if opts.ObserverLight {
+			cosIntersectionAngle = pixelColumnInfo.IntersectionCosAngle
+			attenuation = distanceAttenuation(distance)
+		}
+
+		light := ambientLight.Add(wallPointLight(opts, &pixelColumnInfo))
+		fogFactor := 1.0 - visibility(opts, distance)
+
+		imgDataOffset := img.PixOffset(x, startY)
+		for pixelYIndex := 0; pixelYIndex < actualPixelColumnHeight; pixelYIndex++ {
+			imageDataIndex := imgDataOffset + pixelYIndex*img.Stride
+
+			r := columnPixelData[pixelYIndex*4+0]
+			g := columnPixelData[pixelYIndex*4+1]
+			b := columnPixelData[pixelYIndex*4+2]
+			pixelColor := pipeline.fromBytes(r, g, b)
+
+			litColor := pixelColor.Mul(light).Add(pixelColor.Mul(torchLight).Scale(cosIntersectionAngle).Scale(attenuation))
+			if fogFactor > 0.0 {
+				litColor = litColor.FadeTo(fog, fogFactor)
+			}
+			rb, gb, bb := pipeline.bytes(litColor)
+
+			img.Pix[imageDataIndex+0] = rb
+			img.Pix[imageDataIndex+1] = gb
+			img.Pix[imageDataIndex+2] = bb
+			img.Pix[imageDataIndex+3] = columnPixelData[pixelYIndex*4+3] // A (alpha)
+
+			// Middle aim line ("cross-hair")
+			if opts.AimLine && x == len(pixelColumnInfos)/2 {
+				img.Pix[imageDataIndex+0] = 255
+			}
+		}
+	}
+}
+
+// paintWallsColorized paints the wall pixel columns with the dominant color of the wall textures.
+func paintWallsColorized(img *image.RGBA, projection *maze.Projection, pixelColumnInfos []maze.IntersectionInfo, opts RenderOptions) {
+	ambientLight, torchLight := lights(opts)
+	pipeline := newColorPipeline(opts)
+	fog := pipeline.fromSRGB(fogColor(opts))
+
+	for x, pixelColumnInfo := range pixelColumnInfos {
+		_, _, startY, endY := wallRows(img, projection, pixelColumnInfo.PerpendicularDistance)
+
+		if !pixelColumnInfo.Hit {
+			drawVerticalLine(img, x, startY, endY, pipeline.rgba(fog)) // Nothing within the max distance, only fog
+			continue
+		}
+
+		// Color index from what kind of wall plus brightness index from what side of wall
+		texture := pixelColumnInfo.Wall.Structure.Texture
+		if pixelColumnInfo.Side == 1 && pixelColumnInfo.Wall.Structure.Texture2 != nil {
+			texture = pixelColumnInfo.Wall.Structure.Texture2
+		}
+		if texture == nil {
+			continue
+		}
+
+		distance := pixelColumnInfo.IntersectionPoint.Sub(&pixelColumnInfo.ObserverPoint).Length()
+		cosIntersectionAngle := pixelColumnInfo.IntersectionCosAngle
+		attenuation := distanceAttenuation(distance)
+
+		light := ambientLight.Add(wallPointLight(opts, &pixelColumnInfo))
+
+		nc := pipeline.fromColor(texture.DominantColor())
+		c := nc.Mul(light).Add(nc.Mul(torchLight).Scale(cosIntersectionAngle).Scale(attenuation))
+		if fogFactor := 1.0 - visibility(opts, distance); fogFactor > 0.0 {
+			c = c.FadeTo(fog, fogFactor)
+		}
+
+		drawVerticalLine(img, x, startY, endY, pipeline.rgba(c))
+	}
+}
+
+// wallRows gives the screen rows of the top and the bottom of a wall at a perpendicular distance, and the
+// rows painted for the wall: from startY up to (not including) endY, clipped to the image.
+func wallRows(img *image.RGBA, projection *maze.Projection, perpendicularDistance float64) (top, bottom float64, startY, endY int) {
+	top, bottom = projection.WallSpan(perpendicularDistance)
+	startY = int(math.Max(0.0, math.Floor(top)))
+	endY = int(math.Min(float64(img.Rect.Dy()), math.Floor(bottom)))
+	return top, bottom, startY, max(startY, endY)
+}
+
+func drawVerticalLine(img *image.RGBA, x int, y1 int, y2 int, c color.RGBA) {
+	for y := y1; y < y2; y++ {
+		img.SetRGBA(x, y, c)
+	}
+}
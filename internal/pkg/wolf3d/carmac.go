@@ -3,6 +3,7 @@ package wolf3d
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 )
 
 // https://moddingwiki.shikadi.net/wiki/Carmack_compression
@@ -10,69 +11,246 @@ import (
 // CarmackDecodeWithLengthPrefix decodes (decompresses) a Carmack compressed uint16 stream.
 // This function should be used if the compressed stream is prefixed with uint16, little endian (two bytes) with the expected decompressed length.
 // This function does not perform any consistency checks that the decompressed is of expected length.
+// Invalid (like truncated) data is decoded as far as possible, use CarmackDecodeWithLengthPrefixChecked to detect invalid data.
 func CarmackDecodeWithLengthPrefix(source []byte) (expectedSize int, data []byte) {
-	size := readUint16(source, 0)
+	size, err := readUint16(source, 0)
+	if err != nil {
+		return 0, nil
+	}
 	return int(size), CarmackDecode(source[2:])
 }
 
+// CarmackDecode decodes (decompresses) a Carmack compressed uint16 stream.
+// Invalid (like truncated) data is decoded as far as possible, use CarmackDecodeChecked to detect invalid data.
 func CarmackDecode(source []byte) []byte {
+	data, _ := carmackDecode(source, -1)
+	return data
+}
+
+// CarmackDecodeWithLengthPrefixChecked decodes (decompresses) a Carmack compressed uint16 stream, prefixed with
+// uint16, little endian (two bytes) with the expected decompressed length.
+// An error is returned if the compressed stream is truncated, has a pointer outside the data decoded so far,
+// or if the decompressed length is not the expected length.
+func CarmackDecodeWithLengthPrefixChecked(source []byte) ([]byte, error) {
+	size, err := readUint16(source, 0)
+	if err != nil {
+		return nil, fmt.Errorf("carmack length prefix: %w", err)
+	}
+
+	data, err := carmackDecode(source[2:], int(size))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) != int(size) {
+		return nil, fmt.Errorf("carmack decoded %d bytes, expected %d", len(data), size)
+	}
+
+	return data, nil
+}
+
+// CarmackDecodeChecked decodes (decompresses) a Carmack compressed uint16 stream.
+// An error is returned if the compressed stream is truncated, or has a pointer outside the data decoded so far.
+func CarmackDecodeChecked(source []byte) ([]byte, error) {
+	return carmackDecode(source, -1)
+}
+
+// carmackDecode decodes a Carmack compressed uint16 stream. The data decoded so far is returned together with any error.
+// If maxSize is zero or positive, decoding stops when maxSize bytes are decoded (like the original decoder, any
+// data after that is ignored), and fails if more than maxSize bytes would be decoded.
+//
+// A pointer may refer to words it is producing itself (count larger than the distance back), which repeats the
+// referred words, just like the original decoder copying word by word.
+func carmackDecode(source []byte, maxSize int) ([]byte, error) {
 	const nearPointerMarker = byte(0xA7)
 	const farPointerMarker = byte(0xA8)
 
-	destBuffer := bytes.Buffer{}
+	dest := make([]byte, 0, len(source)*2)
 	sourceOffset := 0
 
-	for sourceOffset < len(source)-1 {
+	truncated := func(what string) error {
+		return fmt.Errorf("carmack data truncated at offset %d: incomplete %s", sourceOffset, what)
+	}
 
-		possibleNearPointer := false
-		possibleFarPointer := false
+	// copyWords copies count words, starting at byte position readPos in the decoded data
+	copyWords := func(readPos int, count int) error {
+		if maxSize >= 0 && len(dest)+count*2 > maxSize {
+			return fmt.Errorf("carmack data at offset %d decodes to more than the expected %d bytes", sourceOffset, maxSize)
+		}
+
+		for i := 0; i < count*2; i++ {
+			dest = append(dest, dest[readPos+i])
+		}
+		return nil
+	}
+
+	for sourceOffset < len(source) && (maxSize < 0 || len(dest) < maxSize) {
+		if sourceOffset+2 > len(source) {
+			return dest, truncated("word")
+		}
 
 		var sourceLookahead0 = source[sourceOffset]
 		var sourceLookahead1 = source[sourceOffset+1]
 
-		possibleNearPointer = sourceLookahead1 == nearPointerMarker
-		possibleFarPointer = sourceLookahead1 == farPointerMarker
+		possiblePointer := sourceLookahead1 == nearPointerMarker || sourceLookahead1 == farPointerMarker
 
-		nearPointer := sourceLookahead0 != 0x00 && possibleNearPointer
-		farPointer := sourceLookahead0 != 0x00 && possibleFarPointer
+		switch {
+		case possiblePointer && sourceLookahead0 == 0x00:
+			// Escaped word with high byte equal to a pointer marker
+			if sourceOffset+3 > len(source) {
+				return dest, truncated("escaped word")
+			}
+			if maxSize >= 0 && len(dest)+2 > maxSize {
+				return dest, fmt.Errorf("carmack data at offset %d decodes to more than the expected %d bytes", sourceOffset, maxSize)
+			}
 
-		if (possibleNearPointer || possibleFarPointer) && !(nearPointer || farPointer) {
-			destBuffer.WriteByte(source[sourceOffset+2])
-			destBuffer.WriteByte(source[sourceOffset+1])
+			dest = append(dest, source[sourceOffset+2], source[sourceOffset+1])
 			sourceOffset += 3
-		}
 
-		if nearPointer {
+		case sourceLookahead1 == nearPointerMarker:
+			if sourceOffset+3 > len(source) {
+				return dest, truncated("near pointer")
+			}
+
 			var pointerOffset = 2 * int(source[sourceOffset+2])
-			destBytes := destBuffer.Bytes()
-			destReadPos := len(destBytes) - pointerOffset
-			for i := 0; i < int(sourceLookahead0); i++ {
-				destBuffer.WriteByte(destBytes[destReadPos+i*2])
-				destBuffer.WriteByte(destBytes[destReadPos+i*2+1])
+			destReadPos := len(dest) - pointerOffset
+			if pointerOffset == 0 || destReadPos < 0 {
+				return dest, fmt.Errorf("carmack near pointer at offset %d refers %d words back, but only %d words are decoded", sourceOffset, pointerOffset/2, len(dest)/2)
+			}
+
+			if err := copyWords(destReadPos, int(sourceLookahead0)); err != nil {
+				return dest, err
 			}
 
 			sourceOffset += 3
-		}
 
-		if farPointer {
-			var wordOffset uint16
-			_ = binary.Read(bytes.NewBuffer(source[sourceOffset+2:sourceOffset+4]), binary.LittleEndian, &wordOffset)
-			var pointerOffset = 2 * int(wordOffset)
-			destBytes := destBuffer.Bytes()
-			for i := 0; i < int(sourceLookahead0); i++ {
-				destBuffer.WriteByte(destBytes[pointerOffset+2*i])
-				destBuffer.WriteByte(destBytes[pointerOffset+2*i+1])
+		case sourceLookahead1 == farPointerMarker:
+			if sourceOffset+4 > len(source) {
+				return dest, truncated("far pointer")
+			}
+
+			var pointerOffset = 2 * int(binary.LittleEndian.Uint16(source[sourceOffset+2:]))
+			if pointerOffset >= len(dest) {
+				return dest, fmt.Errorf("carmack far pointer at offset %d refers to word %d, but only %d words are decoded", sourceOffset, pointerOffset/2, len(dest)/2)
+			}
+
+			if err := copyWords(pointerOffset, int(sourceLookahead0)); err != nil {
+				return dest, err
 			}
 
 			sourceOffset += 4
-		}
 
-		if !(possibleNearPointer || possibleFarPointer) {
-			destBuffer.WriteByte(source[sourceOffset])
-			destBuffer.WriteByte(source[sourceOffset+1])
+		default:
+			if maxSize >= 0 && len(dest)+2 > maxSize {
+				return dest, fmt.Errorf("carmack data at offset %d decodes to more than the expected %d bytes", sourceOffset, maxSize)
+			}
+
+			dest = append(dest, sourceLookahead0, sourceLookahead1)
 			sourceOffset += 2
 		}
 	}
 
+	return dest, nil
+}
+
+// CarmackEncodeWithLengthPrefix encodes (compresses) a uint16 stream with Carmack compression.
+// The compressed stream is prefixed with uint16, little endian (two bytes) with the length of the uncompressed stream,
+// and can be decoded by CarmackDecodeWithLengthPrefix.
+func CarmackEncodeWithLengthPrefix(source []byte) []byte {
+	destBuffer := bytes.Buffer{}
+	_ = binary.Write(&destBuffer, binary.LittleEndian, uint16(len(source)))
+	destBuffer.Write(CarmackEncode(source))
+
+	return destBuffer.Bytes()
+}
+
+// CarmackEncode encodes (compresses) a uint16 stream with Carmack compression.
+// The source is expected to have an even length (a whole number of uint16 words).
+//
+// Repeated sequences of words are replaced by near pointers (a relative word offset back, up to 255 words) or
+// far pointers (an absolute word offset from the start of the stream).
+// A pointer never refers to words it is itself going to produce, since the decoder copies from the data decoded
+// before the pointer.
+// Words with high byte 0xA7 or 0xA8 (the pointer markers) are escaped.
+func CarmackEncode(source []byte) []byte {
+	const nearPointerMarker = byte(0xA7)
+	const farPointerMarker = byte(0xA8)
+	const maxCount = 0xff
+	const maxNearDistance = 0xff
+	const maxFarOffset = 0xffff
+	const minNearCount = 2    // A near pointer (3 bytes) is shorter than two literal words (4 bytes)
+	const minFarCount = 3     // A far pointer (4 bytes) is shorter than three literal words (6 bytes)
+	const maxCandidates = 256 // Only the most recent positions of a word are tried, or repetitive input gets quadratic
+
+	words := make([]uint16, len(source)/2)
+	for i := range words {
+		words[i] = uint16(source[i*2]) | uint16(source[i*2+1])<<8
+	}
+
+	destBuffer := bytes.Buffer{}
+
+	// Recent word positions of the words seen so far, used to find candidates for back-references
+	positions := make(map[uint16][]int)
+
+	for wordOffset := 0; wordOffset < len(words); {
+		bestCount := 0
+		bestStart := 0
+
+		// The newest candidates first, they are the ones that can be referred to by near pointers
+		candidates := positions[words[wordOffset]]
+		for i := len(candidates) - 1; i >= max(0, len(candidates)-maxCandidates); i-- {
+			start := candidates[i]
+			if start > maxFarOffset && wordOffset-start > maxNearDistance {
+				continue
+			}
+
+			// The referred words must all be decoded before the pointer
+			limit := min(maxCount, wordOffset-start, len(words)-wordOffset)
+			count := 0
+			for count < limit && words[start+count] == words[wordOffset+count] {
+				count++
+			}
+
+			near := wordOffset-start <= maxNearDistance
+			if (near && count >= minNearCount || count >= minFarCount) && (count > bestCount || count == bestCount && near) {
+				bestCount = count
+				bestStart = start
+			}
+			if near && count == min(maxCount, len(words)-wordOffset) {
+				break
+			}
+		}
+
+		count := 1
+		if bestCount > 0 {
+			count = bestCount
+
+			distance := wordOffset - bestStart
+			if distance <= maxNearDistance {
+				destBuffer.Write([]byte{byte(count), nearPointerMarker, byte(distance)})
+			} else {
+				destBuffer.Write([]byte{byte(count), farPointerMarker, byte(bestStart & 0xff), byte(bestStart >> 8)})
+			}
+		} else {
+			word := words[wordOffset]
+			low := byte(word & 0xff)
+			high := byte(word >> 8)
+			if high == nearPointerMarker || high == farPointerMarker {
+				destBuffer.Write([]byte{0x00, high, low})
+			} else {
+				destBuffer.Write([]byte{low, high})
+			}
+		}
+
+		for i := 0; i < count; i++ {
+			word := words[wordOffset+i]
+			if len(positions[word]) == 2*maxCandidates {
+				positions[word] = append(positions[word][:0], positions[word][maxCandidates:]...)
+			}
+			positions[word] = append(positions[word], wordOffset+i)
+		}
+		wordOffset += count
+	}
+
 	return destBuffer.Bytes()
 }
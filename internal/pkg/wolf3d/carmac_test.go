@@ -1,6 +1,7 @@
 package wolf3d
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -45,3 +46,163 @@ func TestCarmackDecodeWolf3dLevel1Plane0(t *testing.T) {
 	assert.Equal(t, size, len(decompressedData))
 	assert.Equal(t, expectedData, decompressedData)
 }
+
+func TestCarmackEncode(t *testing.T) {
+	type testCase struct {
+		name         string
+		decompressed []byte
+		compressed   []byte
+	}
+
+	tests := []testCase{
+		{name: "special case: 0xA7 and 0xA8 as high byte values", decompressed: []byte{0x12, 0xA7, 0xEE, 0xFF, 0x34, 0xA8, 0xCC, 0xDD}, compressed: []byte{0x00, 0xA7, 0x12, 0xEE, 0xFF, 0x00, 0xA8, 0x34, 0xCC, 0xDD}},
+		{name: "near pointer", decompressed: []byte{0x78, 0x56, 0x34, 0x12, 0x78, 0x56, 0x34, 0x12, 0x00, 0x01}, compressed: []byte{0x78, 0x56, 0x34, 0x12, 0x02, 0xA7, 0x02, 0x00, 0x01}},
+		{name: "pointer does not overlap itself", decompressed: []byte{0x01, 0x00, 0x01, 0x00, 0x01, 0x00}, compressed: []byte{0x01, 0x00, 0x01, 0x00, 0x01, 0x00}},
+		{name: "empty", decompressed: []byte{}, compressed: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed := CarmackEncode(tt.decompressed)
+
+			assert.Equal(t, tt.compressed, compressed)
+			assert.Equal(t, len(tt.decompressed), len(CarmackDecode(compressed)))
+		})
+	}
+}
+
+func TestCarmackEncodeFarPointer(t *testing.T) {
+	// A repeated sequence more than 255 words back can only be referred to by a far pointer
+	decompressed := make([]byte, 0, 600*2)
+	for i := 0; i < 300; i++ {
+		decompressed = append(decompressed, byte(i), byte(i>>8))
+	}
+	for i := 0; i < 300; i++ {
+		decompressed = append(decompressed, byte(i), byte(i>>8))
+	}
+
+	compressed := CarmackEncode(decompressed)
+
+	assert.Contains(t, string(compressed), string([]byte{0xFF, 0xA8, 0x00, 0x00}))
+	assert.Less(t, len(compressed), len(decompressed))
+	assert.Equal(t, decompressed, CarmackDecode(compressed))
+}
+
+func TestCarmackEncodeWolf3dLevel1Plane0(t *testing.T) {
+	decompressedData, err := readFile("testdata/wolfenstein_level1_plane0_RLEW_compressed.bin")
+	assert.NoError(t, err)
+
+	compressedData := CarmackEncodeWithLengthPrefix(decompressedData)
+
+	size, roundTripData := CarmackDecodeWithLengthPrefix(compressedData)
+	assert.Equal(t, len(decompressedData), size)
+	assert.Equal(t, decompressedData, roundTripData)
+
+	originalCompressedData, err := readFile("testdata/wolfenstein_level1_plane0_RLEW_Carmack_compressed.bin")
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(compressedData), len(originalCompressedData))
+}
+
+func TestCarmackEncodeRepetitive(t *testing.T) {
+	// Every word is a candidate for a back-reference of every later word
+	decompressed := make([]byte, 0x7fff*2)
+
+	compressed := CarmackEncode(decompressed)
+
+	assert.Less(t, len(compressed), len(decompressed)/100)
+	assert.Equal(t, decompressed, CarmackDecode(compressed))
+}
+
+func TestCarmackDecodeChecked(t *testing.T) {
+	type testCase struct {
+		name         string
+		compressed   []byte
+		decompressed []byte
+		wantErr      bool
+	}
+
+	tests := []testCase{
+		{name: "valid", compressed: []byte{0x08, 0x00, 0x78, 0x56, 0x34, 0x12, 0x02, 0xA7, 0x02}, decompressed: []byte{0x78, 0x56, 0x34, 0x12, 0x78, 0x56, 0x34, 0x12}},
+		{name: "near pointer repeating its own words", compressed: []byte{0x08, 0x00, 0x78, 0x56, 0x03, 0xA7, 0x01}, decompressed: []byte{0x78, 0x56, 0x78, 0x56, 0x78, 0x56, 0x78, 0x56}},
+		{name: "far pointer repeating its own words", compressed: []byte{0x06, 0x00, 0x78, 0x56, 0x02, 0xA8, 0x00, 0x00}, decompressed: []byte{0x78, 0x56, 0x78, 0x56, 0x78, 0x56}},
+		{name: "data after expected length is ignored", compressed: []byte{0x02, 0x00, 0x78, 0x56, 0x00}, decompressed: []byte{0x78, 0x56}},
+		{name: "missing length prefix", compressed: []byte{0x08}, wantErr: true},
+		{name: "half a word", compressed: []byte{0x04, 0x00, 0x78, 0x56, 0x34}, wantErr: true},
+		{name: "truncated escaped word", compressed: []byte{0x02, 0x00, 0x00, 0xA7}, wantErr: true},
+		{name: "truncated near pointer", compressed: []byte{0x04, 0x00, 0x78, 0x56, 0x01, 0xA7}, wantErr: true},
+		{name: "truncated far pointer", compressed: []byte{0x04, 0x00, 0x78, 0x56, 0x01, 0xA8, 0x00}, wantErr: true},
+		{name: "near pointer before start of data", compressed: []byte{0x04, 0x00, 0x78, 0x56, 0x01, 0xA7, 0x02}, wantErr: true},
+		{name: "near pointer with no distance", compressed: []byte{0x04, 0x00, 0x78, 0x56, 0x01, 0xA7, 0x00}, wantErr: true},
+		{name: "far pointer after end of data", compressed: []byte{0x04, 0x00, 0x78, 0x56, 0x01, 0xA8, 0x01, 0x00}, wantErr: true},
+		{name: "pointer longer than expected length", compressed: []byte{0x04, 0x00, 0x78, 0x56, 0xFF, 0xA7, 0x01}, wantErr: true},
+		{name: "shorter than expected length", compressed: []byte{0x08, 0x00, 0x78, 0x56}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decompressed, err := CarmackDecodeWithLengthPrefixChecked(tt.compressed)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.decompressed, decompressed)
+			}
+		})
+	}
+}
+
+func FuzzCarmackDecode(f *testing.F) {
+	compressedData, err := readFile("testdata/wolfenstein_level1_plane0_RLEW_Carmack_compressed.bin")
+	assert.NoError(f, err)
+
+	f.Add(compressedData)
+	f.Add([]byte{0x08, 0x00, 0x78, 0x56, 0x34, 0x12, 0x02, 0xA7, 0x02})
+	f.Add([]byte{0x08, 0x00, 0x78, 0x56, 0x34, 0x12, 0x02, 0xA8, 0x00, 0x00})
+
+	f.Fuzz(func(t *testing.T, compressed []byte) {
+		decompressed, err := CarmackDecodeWithLengthPrefixChecked(compressed)
+		if err == nil {
+			size, _ := readUint16(compressed, 0)
+			assert.Len(t, decompressed, int(size))
+		}
+
+		// Without an expected length, every pointer can expand to 255 words, so only decode small inputs
+		if len(compressed) <= 64 {
+			_, _ = CarmackDecodeChecked(compressed)
+			_, _ = CarmackDecodeWithLengthPrefix(compressed)
+		}
+	})
+}
+
+func FuzzCarmackEncodeDecode(f *testing.F) {
+	decompressedData, err := readFile("testdata/wolfenstein_level1_plane0_RLEW_compressed.bin")
+	assert.NoError(f, err)
+
+	f.Add(decompressedData)
+	f.Add([]byte{0x12, 0xA7, 0xEE, 0xFF, 0x34, 0xA8, 0xCC, 0xDD})
+	f.Add([]byte{0x78, 0x56, 0x34, 0x12, 0x78, 0x56, 0x34, 0x12})
+
+	f.Fuzz(func(t *testing.T, decompressed []byte) {
+		decompressed = decompressed[:len(decompressed)/2*2] // Whole words only
+		if len(decompressed) > 0xffff {
+			return
+		}
+
+		compressed := CarmackEncodeWithLengthPrefix(decompressed)
+
+		roundTrip, err := CarmackDecodeWithLengthPrefixChecked(compressed)
+		assert.NoError(t, err)
+		assert.True(t, bytes.Equal(decompressed, roundTrip))
+	})
+}
+
+func BenchmarkCarmackEncode(b *testing.B) {
+	decompressed := make([]byte, 0x7fff*2)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CarmackEncode(decompressed)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	_ "embed"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"os"
 )
 
 // https://archive.org/details/wolf3dsw
@@ -42,6 +44,12 @@ type LevelMap struct {
 	plane2 []uint16 // Wolfenstein 3D: empty
 }
 
+// NewLevelMap creates a level map from uncompressed plane data.
+// Each plane is expected to hold width*height values, stored row by row. A nil plane is a plane that does not exist.
+func NewLevelMap(name string, width, height int, plane0, plane1, plane2 []uint16) LevelMap {
+	return LevelMap{Name: name, Width: width, Height: height, plane0: plane0, plane1: plane1, plane2: plane2}
+}
+
 // Value gives the map value for coordinate x and y.
 // Valid values are 0 <= x < width, and 0 <= y < height.
 // -1 is returned if x or y is out of bounds.
@@ -97,31 +105,54 @@ func (h *mapHeader) LevelOffset(levelIndex int) int32 {
 	return h.levelOffset[levelIndex]
 }
 
+// Wolfenstein3DMap gives all levels of the (embedded) shareware version of Wolfenstein 3D.
 func Wolfenstein3DMap() ([]LevelMap, error) {
+	return LoadGameMaps(bytes.NewReader(wolfenstein3DMapHeaderData), bytes.NewReader(wolfenstein3DMapData))
+}
+
+// LoadGameMapsFromFiles reads all levels from a map header file (MAPHEAD) and a game maps file (GAMEMAPS or MAPTEMP).
+// Any game using the same map format as Wolfenstein 3D works, like the registered version (.WL6), Spear of Destiny (.SOD), and community mods.
+func LoadGameMapsFromFiles(mapHeaderFilename string, gameMapsFilename string) ([]LevelMap, error) {
+	mapHeaderFile, err := os.Open(mapHeaderFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer mapHeaderFile.Close()
+
+	gameMapsFile, err := os.Open(gameMapsFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer gameMapsFile.Close()
+
+	return LoadGameMaps(mapHeaderFile, gameMapsFile)
+}
+
+// LoadGameMaps reads all levels from map header data (MAPHEAD) and game maps data (GAMEMAPS or MAPTEMP).
+func LoadGameMaps(mapHeaderData io.ReaderAt, gameMapsData io.ReaderAt) ([]LevelMap, error) {
 	var levelMaps []LevelMap
 
-	mh, err := readMapHeader(wolfenstein3DMapHeaderData)
+	mh, err := readMapHeader(mapHeaderData)
 	if err != nil {
 		return nil, err
 	}
 
-	lhs, err := readLevelHeaders(mh, wolfenstein3DMapData)
+	lhs, err := readLevelHeaders(mh, gameMapsData)
 	if err != nil {
 		return nil, err
 	}
 
-	for levelIndex, lh := range lhs {
-		fmt.Printf("Reading level %d: %s\n", levelIndex, lh.name)
+	for _, lh := range lhs {
 		levelMap := LevelMap{Name: lh.name, Width: int(lh.width), Height: int(lh.height)}
 
-		expectedMapByteSize := lh.width * lh.height * 2
+		expectedMapByteSize := int(lh.width) * int(lh.height) * 2
 
 		plane0Exist := lh.offPlane0 > 0
 		plane1Exist := lh.offPlane1 > 0
 		plane2Exist := lh.offPlane2 > 0
 
 		if plane0Exist {
-			plane0MapData, err := readPlaneData(lh.offPlane0, lh.offPlane0+int32(lh.lenPlane0), expectedMapByteSize, mh.magic)
+			plane0MapData, err := readPlaneData(gameMapsData, lh.offPlane0, lh.lenPlane0, expectedMapByteSize, mh.magic)
 			if err != nil {
 				return levelMaps, err
 			}
@@ -129,7 +160,7 @@ func Wolfenstein3DMap() ([]LevelMap, error) {
 		}
 
 		if plane1Exist {
-			plane1MapData, err := readPlaneData(lh.offPlane1, lh.offPlane1+int32(lh.lenPlane1), expectedMapByteSize, mh.magic)
+			plane1MapData, err := readPlaneData(gameMapsData, lh.offPlane1, lh.lenPlane1, expectedMapByteSize, mh.magic)
 			if err != nil {
 				return levelMaps, err
 			}
@@ -137,7 +168,7 @@ func Wolfenstein3DMap() ([]LevelMap, error) {
 		}
 
 		if plane2Exist {
-			plane2MapData, err := readPlaneData(lh.offPlane2, lh.offPlane2+int32(lh.lenPlane2), expectedMapByteSize, mh.magic)
+			plane2MapData, err := readPlaneData(gameMapsData, lh.offPlane2, lh.lenPlane2, expectedMapByteSize, mh.magic)
 			if err != nil {
 				return levelMaps, err
 			}
@@ -150,25 +181,138 @@ func Wolfenstein3DMap() ([]LevelMap, error) {
 	return levelMaps, nil
 }
 
-func readPlaneData(startOffset int32, endOffset int32, expectedMapByteSize uint16, rleFlag uint16) ([]uint16, error) {
-	var levelMapData []uint16
-	compressedLevelData := wolfenstein3DMapData[startOffset:endOffset]
+func readPlaneData(gameMapsData io.ReaderAt, offset int32, length uint16, expectedMapByteSize int, rleFlag uint16) ([]uint16, error) {
+	compressedLevelData := make([]byte, length)
+	if _, err := gameMapsData.ReadAt(compressedLevelData, int64(offset)); err != nil {
+		return nil, fmt.Errorf("could not read plane data (offset %d, length %d): %w", offset, length, err)
+	}
 
 	rlew, carmackAndRLEW, err := checkCompressionMethods(compressedLevelData, expectedMapByteSize)
 	if err != nil {
 		return nil, err
 	}
 
-	if rlew {
-		_, levelData := RLEWDecodeWithLengthPrefixAndRLEFlag(compressedLevelData, rleFlag)
-		levelMapData = toUint16(levelData)
-	} else if carmackAndRLEW {
-		_, compressedLevelData = CarmackDecodeWithLengthPrefix(compressedLevelData)
-		_, levelData := RLEWDecodeWithLengthPrefixAndRLEFlag(compressedLevelData, rleFlag)
-		levelMapData = toUint16(levelData)
+	if !rlew && !carmackAndRLEW {
+		return nil, fmt.Errorf("plane data (offset %d) is neither RLEW nor Carmack and RLEW compressed to %d bytes", offset, expectedMapByteSize)
+	}
+
+	// Both can match for small planes, but Carmack and RLEW is what the game data uses
+	if carmackAndRLEW {
+		compressedLevelData, err = CarmackDecodeWithLengthPrefixChecked(compressedLevelData)
+		if err != nil {
+			return nil, fmt.Errorf("plane data (offset %d): %w", offset, err)
+		}
+	}
+
+	levelData, err := RLEWDecodeWithLengthPrefixAndRLEFlagChecked(compressedLevelData, rleFlag)
+	if err != nil {
+		return nil, fmt.Errorf("plane data (offset %d): %w", offset, err)
+	}
+
+	if len(levelData) != expectedMapByteSize {
+		return nil, fmt.Errorf("plane data (offset %d) decoded to %d bytes, expected %d", offset, len(levelData), expectedMapByteSize)
+	}
+
+	return toUint16(levelData), nil
+}
+
+// SaveGameMapsToFiles writes levels to a map header file (MAPHEAD) and a game maps file (GAMEMAPS), see SaveGameMaps.
+func SaveGameMapsToFiles(mapHeaderFilename string, gameMapsFilename string, levelMaps []LevelMap) error {
+	mapHeaderData := bytes.Buffer{}
+	gameMapsData := bytes.Buffer{}
+	if err := SaveGameMaps(&mapHeaderData, &gameMapsData, levelMaps); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(mapHeaderFilename, mapHeaderData.Bytes(), 0644); err != nil {
+		return err
 	}
 
-	return levelMapData, nil
+	return os.WriteFile(gameMapsFilename, gameMapsData.Bytes(), 0644)
+}
+
+// SaveGameMaps writes levels as map header data (MAPHEAD) and game maps data (GAMEMAPS), in the format used by
+// Wolfenstein 3D and the TED5 map editor.
+//
+// The game maps data starts with the "TED5v1.0" signature, followed by each level as its Carmack and RLEW compressed
+// planes, the level header, and the "!ID!" signature.
+// The map header data has the RLEW flag (0xABCD) and the offsets to the level headers in the game maps data.
+func SaveGameMaps(mapHeaderData io.Writer, gameMapsData io.Writer, levelMaps []LevelMap) error {
+	const rleFlag = 0xABCD
+	const maxLevelCount = 100
+	const maxNameLength = 15 // The name is stored in 16 bytes, null-terminated
+
+	if len(levelMaps) > maxLevelCount {
+		return fmt.Errorf("too many levels (%d), at most %d levels can be saved", len(levelMaps), maxLevelCount)
+	}
+
+	gameMapsBuffer := bytes.Buffer{}
+	gameMapsBuffer.WriteString("TED5v1.0")
+
+	levelOffsets := make([]int32, maxLevelCount)
+
+	for levelIndex, levelMap := range levelMaps {
+		if levelMap.Width <= 0 || levelMap.Height <= 0 || levelMap.Width*levelMap.Height*2 > 0xffff {
+			return fmt.Errorf("level %d (%s) has unsupported size %dx%d", levelIndex, levelMap.Name, levelMap.Width, levelMap.Height)
+		}
+		if len(levelMap.Name) > maxNameLength {
+			return fmt.Errorf("level %d name %q is too long, at most %d characters are allowed", levelIndex, levelMap.Name, maxNameLength)
+		}
+
+		lh := levelHeader{width: uint16(levelMap.Width), height: uint16(levelMap.Height), name: levelMap.Name}
+		planeOffsets := []*int32{&lh.offPlane0, &lh.offPlane1, &lh.offPlane2}
+		planeLengths := []*uint16{&lh.lenPlane0, &lh.lenPlane1, &lh.lenPlane2}
+
+		for plane := 0; plane < 3; plane++ {
+			if !levelMap.planeExist(plane) {
+				continue
+			}
+
+			planeData := levelMap.plane(plane)
+			if len(planeData) != levelMap.Width*levelMap.Height {
+				return fmt.Errorf("level %d (%s) plane %d has %d values, expected %d", levelIndex, levelMap.Name, plane, len(planeData), levelMap.Width*levelMap.Height)
+			}
+
+			compressedPlaneData := CarmackEncodeWithLengthPrefix(RLEWEncodeWithLengthPrefixAndRLEFlag(fromUint16(planeData), rleFlag))
+			if len(compressedPlaneData) > 0xffff {
+				return fmt.Errorf("level %d (%s) plane %d is too large (%d bytes compressed)", levelIndex, levelMap.Name, plane, len(compressedPlaneData))
+			}
+
+			*planeOffsets[plane] = int32(gameMapsBuffer.Len())
+			*planeLengths[plane] = uint16(len(compressedPlaneData))
+			gameMapsBuffer.Write(compressedPlaneData)
+		}
+
+		levelOffsets[levelIndex] = int32(gameMapsBuffer.Len())
+		writeLevelHeader(&gameMapsBuffer, lh)
+		gameMapsBuffer.WriteString("!ID!")
+	}
+
+	mapHeaderBuffer := bytes.Buffer{}
+	_ = binary.Write(&mapHeaderBuffer, binary.LittleEndian, uint16(rleFlag))
+	_ = binary.Write(&mapHeaderBuffer, binary.LittleEndian, levelOffsets)
+
+	if _, err := mapHeaderData.Write(mapHeaderBuffer.Bytes()); err != nil {
+		return err
+	}
+
+	_, err := gameMapsData.Write(gameMapsBuffer.Bytes())
+	return err
+}
+
+func writeLevelHeader(buffer *bytes.Buffer, lh levelHeader) {
+	_ = binary.Write(buffer, binary.LittleEndian, lh.offPlane0)
+	_ = binary.Write(buffer, binary.LittleEndian, lh.offPlane1)
+	_ = binary.Write(buffer, binary.LittleEndian, lh.offPlane2)
+	_ = binary.Write(buffer, binary.LittleEndian, lh.lenPlane0)
+	_ = binary.Write(buffer, binary.LittleEndian, lh.lenPlane1)
+	_ = binary.Write(buffer, binary.LittleEndian, lh.lenPlane2)
+	_ = binary.Write(buffer, binary.LittleEndian, lh.width)
+	_ = binary.Write(buffer, binary.LittleEndian, lh.height)
+
+	nameBuffer := make([]byte, 16)
+	copy(nameBuffer, lh.name)
+	buffer.Write(nameBuffer)
 }
 
 func toUint16(data []byte) []uint16 {
@@ -183,26 +327,34 @@ func toUint16(data []byte) []uint16 {
 	return uints
 }
 
-func checkCompressionMethods(compressedLevelData []byte, expectedMapByteSize uint16) (compressionRLEW bool, compressionCarmackAndRLEW bool, err error) {
-	var mapSize1 uint16
-	var mapSize2 uint16
+func fromUint16(uints []uint16) []byte {
+	data := make([]byte, len(uints)*2)
+	for i, value := range uints {
+		binary.LittleEndian.PutUint16(data[i*2:], value)
+	}
 
-	mapSizeReader := bytes.NewBuffer(compressedLevelData)
-	if err = binary.Read(mapSizeReader, binary.LittleEndian, &mapSize1); err != nil {
+	return data
+}
+
+func checkCompressionMethods(compressedLevelData []byte, expectedMapByteSize int) (compressionRLEW bool, compressionCarmackAndRLEW bool, err error) {
+	mapSize1, err := readUint16(compressedLevelData, 0)
+	if err != nil {
 		return false, false, err
 	}
-	if err = binary.Read(mapSizeReader, binary.LittleEndian, &mapSize2); err != nil {
+	mapSize2, err := readUint16(compressedLevelData, 2)
+	if err != nil {
 		return false, false, err
 	}
 
-	compressionRLEW = mapSize1 == expectedMapByteSize
-	compressionCarmackAndRLEW = mapSize2 == expectedMapByteSize
+	compressionRLEW = int(mapSize1) == expectedMapByteSize
+	compressionCarmackAndRLEW = int(mapSize2) == expectedMapByteSize
 
 	return compressionRLEW, compressionCarmackAndRLEW, nil
 }
 
-func readMapHeader(mapHeaderData []byte) (*mapHeader, error) {
-	mapHeaderDataBuffer := bytes.NewBuffer(mapHeaderData)
+func readMapHeader(mapHeaderData io.ReaderAt) (*mapHeader, error) {
+	const mapHeaderSize = 2 + 100*4 // Magic word and 100 level pointers (any tileinfo data after that is ignored)
+	mapHeaderDataBuffer := io.NewSectionReader(mapHeaderData, 0, mapHeaderSize)
 
 	var magic uint16
 	err := binary.Read(mapHeaderDataBuffer, binary.LittleEndian, &magic)
@@ -221,13 +373,14 @@ func readMapHeader(mapHeaderData []byte) (*mapHeader, error) {
 	return &mapHeader{magic: magic, levelOffset: levelPtr}, nil
 }
 
-func readLevelHeaders(mh *mapHeader, mapData []byte) ([]levelHeader, error) {
+func readLevelHeaders(mh *mapHeader, mapData io.ReaderAt) ([]levelHeader, error) {
+	const levelHeaderSize = 38
 	var levelHeaders []levelHeader
 
 	for levelIndex := 0; levelIndex < mh.LevelCount(); levelIndex++ {
 		levelOffset := mh.LevelOffset(levelIndex)
 		if levelOffset > 0 {
-			levelDataDataBuffer := bytes.NewBuffer(mapData[levelOffset:])
+			levelDataDataBuffer := io.NewSectionReader(mapData, int64(levelOffset), levelHeaderSize)
 
 			lh := levelHeader{}
 
@@ -257,7 +410,7 @@ func readLevelHeaders(mh *mapHeader, mapData []byte) ([]levelHeader, error) {
 				return nil, err
 			}
 			nameBuffer := make([]byte, 16)
-			if _, err := levelDataDataBuffer.Read(nameBuffer); err != nil {
+			if _, err := io.ReadFull(levelDataDataBuffer, nameBuffer); err != nil {
 				return nil, err
 			}
 			lh.name = nullTerminatedBytesToString(nameBuffer)
@@ -1,12 +1,14 @@
 package wolf3d
 
 import (
+	"bytes"
+	"encoding/binary"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
 func TestWolfenstein3DMapHeader(t *testing.T) {
-	mapHeader, err := readMapHeader(wolfenstein3DMapHeaderData)
+	mapHeader, err := readMapHeader(bytes.NewReader(wolfenstein3DMapHeaderData))
 	assert.NoError(t, err)
 
 	expectedLevelCount := 10
@@ -19,3 +21,146 @@ func TestWolfenstein3DMapHeader(t *testing.T) {
 	assert.Greater(t, mapHeader.LevelOffset(expectedLevelCount-1), int32(0))
 	assert.Equal(t, int32(-1), mapHeader.LevelOffset(expectedLevelCount))
 }
+
+func TestLoadGameMaps(t *testing.T) {
+	levelMaps, err := LoadGameMaps(bytes.NewReader(wolfenstein3DMapHeaderData), bytes.NewReader(wolfenstein3DMapData))
+	assert.NoError(t, err)
+
+	assert.Len(t, levelMaps, 10)
+	assert.Equal(t, "Wolf1 Map1", levelMaps[0].Name)
+	assert.Equal(t, 64, levelMaps[0].Width)
+	assert.Equal(t, 64, levelMaps[0].Height)
+	assert.Len(t, levelMaps[0].plane0, 64*64)
+	assert.Len(t, levelMaps[0].plane1, 64*64)
+
+	embeddedLevelMaps, err := Wolfenstein3DMap()
+	assert.NoError(t, err)
+	assert.Equal(t, embeddedLevelMaps, levelMaps)
+}
+
+func TestLoadGameMapsFromFiles(t *testing.T) {
+	levelMaps, err := LoadGameMapsFromFiles("resources/MAPHEAD.WL1", "resources/GAMEMAPS.WL1")
+	assert.NoError(t, err)
+
+	embeddedLevelMaps, err := Wolfenstein3DMap()
+	assert.NoError(t, err)
+	assert.Equal(t, embeddedLevelMaps, levelMaps)
+
+	_, err = LoadGameMapsFromFiles("resources/MAPHEAD.WL1", "resources/GAMEMAPS.missing")
+	assert.Error(t, err)
+}
+
+func TestLoadGameMapsTruncatedData(t *testing.T) {
+	_, err := LoadGameMaps(bytes.NewReader(wolfenstein3DMapHeaderData[:100]), bytes.NewReader(wolfenstein3DMapData))
+	assert.Error(t, err)
+
+	_, err = LoadGameMaps(bytes.NewReader(wolfenstein3DMapHeaderData), bytes.NewReader(wolfenstein3DMapData[:len(wolfenstein3DMapData)/2]))
+	assert.Error(t, err)
+}
+
+func TestSaveGameMaps(t *testing.T) {
+	levelMaps, err := Wolfenstein3DMap()
+	assert.NoError(t, err)
+
+	mapHeaderData := bytes.Buffer{}
+	gameMapsData := bytes.Buffer{}
+	err = SaveGameMaps(&mapHeaderData, &gameMapsData, levelMaps)
+	assert.NoError(t, err)
+
+	assert.Len(t, mapHeaderData.Bytes(), len(wolfenstein3DMapHeaderData))
+	assert.Equal(t, []byte("TED5v1.0"), gameMapsData.Bytes()[:8])
+
+	savedLevelMaps, err := LoadGameMaps(bytes.NewReader(mapHeaderData.Bytes()), bytes.NewReader(gameMapsData.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, levelMaps, savedLevelMaps)
+}
+
+func TestSaveGameMapsToFiles(t *testing.T) {
+	levelMaps, err := Wolfenstein3DMap()
+	assert.NoError(t, err)
+
+	mapHeaderFilename := t.TempDir() + "/MAPHEAD.WL1"
+	gameMapsFilename := t.TempDir() + "/GAMEMAPS.WL1"
+	err = SaveGameMapsToFiles(mapHeaderFilename, gameMapsFilename, levelMaps[:2])
+	assert.NoError(t, err)
+
+	savedLevelMaps, err := LoadGameMapsFromFiles(mapHeaderFilename, gameMapsFilename)
+	assert.NoError(t, err)
+	assert.Equal(t, levelMaps[:2], savedLevelMaps)
+}
+
+func TestSaveGameMapsErrors(t *testing.T) {
+	t.Run("name too long", func(t *testing.T) {
+		levelMaps := []LevelMap{NewLevelMap("A very long level name", 2, 2, []uint16{1, 1, 1, 1}, nil, nil)}
+		assert.Error(t, SaveGameMaps(&bytes.Buffer{}, &bytes.Buffer{}, levelMaps))
+	})
+
+	t.Run("plane of wrong size", func(t *testing.T) {
+		levelMaps := []LevelMap{NewLevelMap("Level", 2, 2, []uint16{1, 1, 1}, nil, nil)}
+		assert.Error(t, SaveGameMaps(&bytes.Buffer{}, &bytes.Buffer{}, levelMaps))
+	})
+
+	t.Run("too many levels", func(t *testing.T) {
+		levelMaps := make([]LevelMap, 101)
+		assert.Error(t, SaveGameMaps(&bytes.Buffer{}, &bytes.Buffer{}, levelMaps))
+	})
+}
+
+func TestLoadGameMapsInvalidPlaneData(t *testing.T) {
+	levelMaps := []LevelMap{NewLevelMap("Level", 2, 2, []uint16{1, 2, 3, 4}, []uint16{0, 0, 0, 0}, nil)}
+
+	mapHeaderData := bytes.Buffer{}
+	gameMapsData := bytes.Buffer{}
+	assert.NoError(t, SaveGameMaps(&mapHeaderData, &gameMapsData, levelMaps))
+
+	t.Run("valid", func(t *testing.T) {
+		loadedLevelMaps, err := LoadGameMaps(bytes.NewReader(mapHeaderData.Bytes()), bytes.NewReader(gameMapsData.Bytes()))
+		assert.NoError(t, err)
+		assert.Equal(t, levelMaps, loadedLevelMaps)
+	})
+
+	t.Run("plane smaller than level", func(t *testing.T) {
+		corruptGameMapsData := bytes.Clone(gameMapsData.Bytes())
+		// Make the level 3 wide. Plane 0 chunk starts after "TED5v1.0": [carmack length][RLEW length][RLEW data].
+		// Let the RLEW length match the new level size, but the RLEW data still only has 2x2 values.
+		levelHeaderOffset := int(binary.LittleEndian.Uint32(mapHeaderData.Bytes()[2:]))
+		corruptGameMapsData[levelHeaderOffset+18] = 3
+		corruptGameMapsData[10] = 3 * 2 * 2
+
+		_, err := LoadGameMaps(bytes.NewReader(mapHeaderData.Bytes()), bytes.NewReader(corruptGameMapsData))
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown compression", func(t *testing.T) {
+		corruptGameMapsData := bytes.Clone(gameMapsData.Bytes())
+		corruptGameMapsData[10] = 0xff
+
+		_, err := LoadGameMaps(bytes.NewReader(mapHeaderData.Bytes()), bytes.NewReader(corruptGameMapsData))
+		assert.Error(t, err)
+	})
+}
+
+func FuzzLoadGameMaps(f *testing.F) {
+	f.Add(wolfenstein3DMapData[:4000])
+
+	levelMaps := []LevelMap{NewLevelMap("Level", 2, 2, []uint16{1, 2, 3, 4}, []uint16{0, 0, 0, 0}, nil)}
+	mapHeaderData := bytes.Buffer{}
+	gameMapsData := bytes.Buffer{}
+	assert.NoError(f, SaveGameMaps(&mapHeaderData, &gameMapsData, levelMaps))
+	f.Add(gameMapsData.Bytes())
+
+	f.Fuzz(func(t *testing.T, gameMaps []byte) {
+		loadedLevelMaps, err := LoadGameMaps(bytes.NewReader(mapHeaderData.Bytes()), bytes.NewReader(gameMaps))
+		if err != nil {
+			return
+		}
+
+		for _, levelMap := range loadedLevelMaps {
+			for plane := 0; plane < 3; plane++ {
+				if levelMap.planeExist(plane) {
+					assert.Len(t, levelMap.plane(plane), levelMap.Width*levelMap.Height)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,365 @@
+package wolf3d
+
+import (
+	"iter"
+	"math"
+)
+
+// https://moddingwiki.shikadi.net/wiki/Wolfenstein_3-D_Map_Format
+
+const (
+	firstStartPointValue   = 19  // Start point values are 19-22, facing north, east, south and west
+	firstStaticValue       = 23  // Static object values are 23-72, each static number is the value minus 23
+	lastStaticValue        = 72  //
+	firstTurningPointValue = 90  // Patrol turning point values are 90-97, one for each direction
+	lastTurningPointValue  = 97  //
+	pushwallValue          = 98  // Secret pushwall, the wall in the same cell moves when pushed
+	endGameValue           = 99  // Triggers the end of the episode (the victory run)
+	deadGuardValue         = 124 // Dead guard (a corpse)
+	firstSpriteOfStatic    = 2   // The sprite number of a static is the static number plus 2
+)
+
+type ObjectKind int
+
+const (
+	ObjectNone         ObjectKind = iota // No object (value 0), or a position outside the level
+	ObjectStartPoint                     // Where the player starts, with the start facing direction
+	ObjectStatic                         // Static decoration, like barrels, lamps and plants
+	ObjectPickup                         // Static you can pick up, like treasures, food, ammo and keys
+	ObjectEnemy                          // Enemy with kind, facing direction, difficulty and if patrolling
+	ObjectCorpse                         // Dead enemy
+	ObjectTurningPoint                   // Patrol turning point, a patrolling enemy turns to its direction
+	ObjectPushwall                       // Secret pushwall
+	ObjectEndGame                        // End of episode trigger
+	ObjectUnknown                        // Value not used by Wolfenstein 3D
+)
+
+func (k ObjectKind) String() string {
+	switch k {
+	case ObjectNone:
+		return "none"
+	case ObjectStartPoint:
+		return "start point"
+	case ObjectStatic:
+		return "static"
+	case ObjectPickup:
+		return "pickup"
+	case ObjectEnemy:
+		return "enemy"
+	case ObjectCorpse:
+		return "corpse"
+	case ObjectTurningPoint:
+		return "turning point"
+	case ObjectPushwall:
+		return "pushwall"
+	case ObjectEndGame:
+		return "end game"
+	default:
+		return "unknown"
+	}
+}
+
+// Direction is one of eight directions, in the same order as in Wolfenstein 3D (counterclockwise starting at east).
+// North is up in the level map (lower y values).
+type Direction int
+
+const (
+	DirectionEast Direction = iota
+	DirectionNorthEast
+	DirectionNorth
+	DirectionNorthWest
+	DirectionWest
+	DirectionSouthWest
+	DirectionSouth
+	DirectionSouthEast
+)
+
+// Angle gives the direction angle in radians, where east is 0 and north is π/2.
+func (d Direction) Angle() float64 {
+	return float64(d) * math.Pi / 4.0
+}
+
+func (d Direction) String() string {
+	return [...]string{"east", "north-east", "north", "north-west", "west", "south-west", "south", "south-east"}[d&7]
+}
+
+// Difficulty is the lowest difficulty (skill level) an enemy is present in.
+type Difficulty int
+
+const (
+	DifficultyEasy   Difficulty = iota // Present in all difficulties
+	DifficultyMedium                   // Present in medium and hard
+	DifficultyHard                     // Present in hard only
+)
+
+type EnemyKind int
+
+const (
+	EnemyGuard EnemyKind = iota
+	EnemyOfficer
+	EnemySS
+	EnemyDog
+	EnemyMutant
+	EnemyHans        // Boss: Hans Grösse
+	EnemySchabbs     // Boss: Dr. Schabbs
+	EnemyFakeHitler  // Boss: Fake Hitler
+	EnemyHitler      // Boss: Mecha Hitler
+	EnemyGiftmacher  // Boss: Otto Giftmacher
+	EnemyGretel      // Boss: Gretel Grösse
+	EnemyFettgesicht // Boss: General Fettgesicht
+	EnemyGhost       // Pac-Man ghost (secret level)
+)
+
+func (k EnemyKind) String() string {
+	return [...]string{"guard", "officer", "SS", "dog", "mutant", "Hans Grösse", "Dr. Schabbs", "fake Hitler", "Hitler",
+		"Otto Giftmacher", "Gretel Grösse", "General Fettgesicht", "ghost"}[k]
+}
+
+// IsBoss reports if the enemy is a boss.
+func (k EnemyKind) IsBoss() bool {
+	return k >= EnemyHans && k <= EnemyFettgesicht
+}
+
+type Pickup int
+
+const (
+	PickupNone       Pickup = iota
+	PickupDogFood           // Dog food, restores a little health
+	PickupFood              // Food, restores health
+	PickupFirstAid          // First aid kit, restores much health
+	PickupClip              // Ammo clip
+	PickupMachineGun        // Machine gun
+	PickupChainGun          // Chain gun
+	PickupCross             // Treasure: cross
+	PickupChalice           // Treasure: chalice
+	PickupChest             // Treasure: chest of jewels
+	PickupCrown             // Treasure: crown
+	PickupExtraLife         // Extra life (and full health and ammo)
+	PickupGoldKey           // Gold key
+	PickupSilverKey         // Silver key
+	PickupGibs              // Gibs, restores a tiny bit of health when really hurt
+)
+
+// IsTreasure reports if the pickup is a treasure.
+func (p Pickup) IsTreasure() bool {
+	return p >= PickupCross && p <= PickupCrown
+}
+
+// staticInfo describes a static object: if it blocks movement and what you pick up (if anything).
+type staticInfo struct {
+	blocking bool
+	pickup   Pickup
+}
+
+// statics holds information on each static, indexed by static number (value minus 23).
+var statics = []staticInfo{
+	{},                         // Puddle
+	{blocking: true},           // Green barrel
+	{blocking: true},           // Table with chairs
+	{blocking: true},           // Floor lamp
+	{},                         // Chandelier
+	{blocking: true},           // Hanged man
+	{pickup: PickupDogFood},    // Dog food
+	{blocking: true},           // Red pillar
+	{blocking: true},           // Tree
+	{},                         // Skeleton lying flat
+	{blocking: true},           // Sink
+	{blocking: true},           // Potted plant
+	{blocking: true},           // Urn
+	{blocking: true},           // Bare table
+	{},                         // Ceiling light
+	{},                         // Kitchen stuff
+	{blocking: true},           // Suit of armor
+	{blocking: true},           // Hanging cage
+	{blocking: true},           // Skeleton in cage
+	{},                         // Skeleton relaxing
+	{pickup: PickupGoldKey},    // Gold key
+	{pickup: PickupSilverKey},  // Silver key
+	{blocking: true},           // Bed
+	{},                         // Basket
+	{pickup: PickupFood},       // Food
+	{pickup: PickupFirstAid},   // First aid
+	{pickup: PickupClip},       // Ammo clip
+	{pickup: PickupMachineGun}, // Machine gun
+	{pickup: PickupChainGun},   // Chain gun
+	{pickup: PickupCross},      // Cross
+	{pickup: PickupChalice},    // Chalice
+	{pickup: PickupChest},      // Chest of jewels
+	{pickup: PickupCrown},      // Crown
+	{pickup: PickupExtraLife},  // Extra life
+	{pickup: PickupGibs},       // Gibs (bloody bones)
+	{blocking: true},           // Barrel
+	{blocking: true},           // Well with water
+	{blocking: true},           // Empty well
+	{pickup: PickupGibs},       // Gibs (pool of blood)
+	{blocking: true},           // Flag
+	{blocking: true},           // Call Apogee sign
+	{},                         // Bones
+	{},                         // Bones
+	{},                         // Bones
+	{},                         // Pots and pans
+	{blocking: true},           // Stove
+	{blocking: true},           // Spears
+	{},                         // Vines
+	{},                         // Unused (Spear of Destiny)
+	{},                         // Unused (Spear of Destiny)
+}
+
+// enemyRange is a range of 4 values (one for each facing direction east, north, west and south) for an enemy.
+type enemyRange struct {
+	first      int
+	kind       EnemyKind
+	difficulty Difficulty
+	patrol     bool
+}
+
+var enemyRanges = func() []enemyRange {
+	var ranges []enemyRange
+
+	// Guards, officers, SS, and dogs come in 36 value steps per difficulty
+	for difficulty, offset := range []int{0, 36, 72} {
+		for _, er := range []enemyRange{
+			{first: 108, kind: EnemyGuard},
+			{first: 112, kind: EnemyGuard, patrol: true},
+			{first: 116, kind: EnemyOfficer},
+			{first: 120, kind: EnemyOfficer, patrol: true},
+			{first: 126, kind: EnemySS},
+			{first: 130, kind: EnemySS, patrol: true},
+			{first: 134, kind: EnemyDog},
+			{first: 138, kind: EnemyDog, patrol: true},
+		} {
+			er.first += offset
+			er.difficulty = Difficulty(difficulty)
+			ranges = append(ranges, er)
+		}
+	}
+
+	// Mutants come in 18 value steps per difficulty
+	for difficulty, offset := range []int{0, 18, 36} {
+		ranges = append(ranges,
+			enemyRange{first: 216 + offset, kind: EnemyMutant, difficulty: Difficulty(difficulty)},
+			enemyRange{first: 220 + offset, kind: EnemyMutant, difficulty: Difficulty(difficulty), patrol: true},
+		)
+	}
+
+	return ranges
+}()
+
+// bosses are the single value enemies. They have no facing direction in the level map, so they are facing south.
+var bosses = map[int]EnemyKind{
+	160: EnemyFakeHitler,
+	178: EnemyHitler,
+	179: EnemyFettgesicht,
+	196: EnemySchabbs,
+	197: EnemyGretel,
+	214: EnemyHans,
+	215: EnemyGiftmacher,
+}
+
+const firstGhostValue = 224 // Ghost values are 224-227
+
+// enemyFacing is the facing direction of enemy value offsets 0-3 in an enemy range.
+var enemyFacing = []Direction{DirectionEast, DirectionNorth, DirectionWest, DirectionSouth}
+
+// startPointFacing is the facing direction of the start point values 19-22.
+var startPointFacing = []Direction{DirectionNorth, DirectionEast, DirectionSouth, DirectionWest}
+
+// Object is a classified plane 1 (object plane) value.
+type Object struct {
+	X, Y  int
+	Value int
+	Kind  ObjectKind
+
+	Direction Direction // For start points, enemies and turning points, the facing direction
+
+	Static   int    // For statics and pickups, the static number
+	Sprite   int    // For statics and pickups, the sprite number
+	Blocking bool   // For statics, if the static blocks movement
+	Pickup   Pickup // For pickups, what is picked up
+
+	Enemy      EnemyKind  // For enemies and corpses, the kind of enemy
+	Difficulty Difficulty // For enemies, the lowest difficulty the enemy is present in
+	Patrol     bool       // For enemies, if the enemy is patrolling (instead of standing still)
+}
+
+// ClassifyObject classifies a plane 1 (object plane) value at x, y.
+func ClassifyObject(x, y int, value int) Object {
+	object := Object{X: x, Y: y, Value: value}
+
+	switch {
+	case value <= 0:
+		object.Kind = ObjectNone
+	case value >= firstStartPointValue && value < firstStaticValue:
+		object.Kind = ObjectStartPoint
+		object.Direction = startPointFacing[value-firstStartPointValue]
+	case value >= firstStaticValue && value <= lastStaticValue:
+		object.Static = value - firstStaticValue
+		object.Sprite = object.Static + firstSpriteOfStatic
+		info := statics[object.Static]
+		object.Blocking = info.blocking
+		object.Pickup = info.pickup
+		if info.pickup != PickupNone {
+			object.Kind = ObjectPickup
+		} else {
+			object.Kind = ObjectStatic
+		}
+	case value >= firstTurningPointValue && value <= lastTurningPointValue:
+		object.Kind = ObjectTurningPoint
+		object.Direction = Direction(value - firstTurningPointValue)
+	case value == pushwallValue:
+		object.Kind = ObjectPushwall
+	case value == endGameValue:
+		object.Kind = ObjectEndGame
+	case value == deadGuardValue:
+		object.Kind = ObjectCorpse
+		object.Enemy = EnemyGuard
+	case value >= firstGhostValue && value < firstGhostValue+4:
+		object.Kind = ObjectEnemy
+		object.Enemy = EnemyGhost
+		object.Direction = DirectionEast
+	default:
+		if boss, ok := bosses[value]; ok {
+			object.Kind = ObjectEnemy
+			object.Enemy = boss
+			object.Direction = DirectionSouth
+			return object
+		}
+
+		object.Kind = ObjectUnknown
+		for _, er := range enemyRanges {
+			if value >= er.first && value < er.first+len(enemyFacing) {
+				object.Kind = ObjectEnemy
+				object.Enemy = er.kind
+				object.Difficulty = er.difficulty
+				object.Patrol = er.patrol
+				object.Direction = enemyFacing[value-er.first]
+				break
+			}
+		}
+	}
+
+	return object
+}
+
+// Object gives the classified plane 1 (object plane) object at x, y.
+// An object of kind ObjectNone is returned if x or y is out of bounds.
+func (lm *LevelMap) Object(x, y int) Object {
+	return ClassifyObject(x, y, lm.Value(objectPlane, x, y))
+}
+
+// Objects iterates over all objects in the level (skipping cells without an object), row by row.
+func (lm *LevelMap) Objects() iter.Seq[Object] {
+	return func(yield func(Object) bool) {
+		for y := 0; y < lm.Height; y++ {
+			for x := 0; x < lm.Width; x++ {
+				object := lm.Object(x, y)
+				if object.Kind == ObjectNone {
+					continue
+				}
+				if !yield(object) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package wolf3d
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestClassifyObject(t *testing.T) {
+	type testCase struct {
+		name   string
+		value  int
+		object Object
+	}
+
+	tests := []testCase{
+		{name: "none", value: 0, object: Object{Kind: ObjectNone}},
+		{name: "start point facing north", value: 19, object: Object{Value: 19, Kind: ObjectStartPoint, Direction: DirectionNorth}},
+		{name: "start point facing west", value: 22, object: Object{Value: 22, Kind: ObjectStartPoint, Direction: DirectionWest}},
+		{name: "puddle", value: 23, object: Object{Value: 23, Kind: ObjectStatic, Static: 0, Sprite: 2}},
+		{name: "green barrel", value: 24, object: Object{Value: 24, Kind: ObjectStatic, Static: 1, Sprite: 3, Blocking: true}},
+		{name: "ammo clip", value: 49, object: Object{Value: 49, Kind: ObjectPickup, Static: 26, Sprite: 28, Pickup: PickupClip}},
+		{name: "gold key", value: 43, object: Object{Value: 43, Kind: ObjectPickup, Static: 20, Sprite: 22, Pickup: PickupGoldKey}},
+		{name: "turning point north-west", value: 93, object: Object{Value: 93, Kind: ObjectTurningPoint, Direction: DirectionNorthWest}},
+		{name: "pushwall", value: 98, object: Object{Value: 98, Kind: ObjectPushwall}},
+		{name: "end game", value: 99, object: Object{Value: 99, Kind: ObjectEndGame}},
+		{name: "dead guard", value: 124, object: Object{Value: 124, Kind: ObjectCorpse, Enemy: EnemyGuard}},
+		{name: "standing guard facing east", value: 108, object: Object{Value: 108, Kind: ObjectEnemy, Enemy: EnemyGuard, Direction: DirectionEast}},
+		{name: "patrolling guard facing south", value: 115, object: Object{Value: 115, Kind: ObjectEnemy, Enemy: EnemyGuard, Direction: DirectionSouth, Patrol: true}},
+		{name: "standing officer medium facing north", value: 153, object: Object{Value: 153, Kind: ObjectEnemy, Enemy: EnemyOfficer, Direction: DirectionNorth, Difficulty: DifficultyMedium}},
+		{name: "patrolling SS hard facing west", value: 204, object: Object{Value: 204, Kind: ObjectEnemy, Enemy: EnemySS, Direction: DirectionWest, Difficulty: DifficultyHard, Patrol: true}},
+		{name: "standing dog facing east", value: 134, object: Object{Value: 134, Kind: ObjectEnemy, Enemy: EnemyDog, Direction: DirectionEast}},
+		{name: "patrolling dog hard facing north", value: 211, object: Object{Value: 211, Kind: ObjectEnemy, Enemy: EnemyDog, Direction: DirectionNorth, Difficulty: DifficultyHard, Patrol: true}},
+		{name: "patrolling mutant medium facing east", value: 238, object: Object{Value: 238, Kind: ObjectEnemy, Enemy: EnemyMutant, Direction: DirectionEast, Difficulty: DifficultyMedium, Patrol: true}},
+		{name: "Hans Grösse", value: 214, object: Object{Value: 214, Kind: ObjectEnemy, Enemy: EnemyHans, Direction: DirectionSouth}},
+		{name: "ghost", value: 225, object: Object{Value: 225, Kind: ObjectEnemy, Enemy: EnemyGhost, Direction: DirectionEast}},
+		{name: "unknown", value: 0x1234, object: Object{Value: 0x1234, Kind: ObjectUnknown}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.object, ClassifyObject(0, 0, tt.value))
+		})
+	}
+}
+
+func TestStaticsCoverAllStaticValues(t *testing.T) {
+	assert.Len(t, statics, lastStaticValue-firstStaticValue+1)
+}
+
+func TestDirectionAngle(t *testing.T) {
+	assert.InDelta(t, 0.0, DirectionEast.Angle(), 0.000001)
+	assert.InDelta(t, math.Pi/2.0, DirectionNorth.Angle(), 0.000001)
+	assert.InDelta(t, math.Pi, DirectionWest.Angle(), 0.000001)
+	assert.InDelta(t, math.Pi*3.0/2.0, DirectionSouth.Angle(), 0.000001)
+}
+
+func TestLevelMapObjects(t *testing.T) {
+	levelMaps, err := Wolfenstein3DMap()
+	assert.NoError(t, err)
+
+	level := levelMaps[0]
+
+	var startPoints []Object
+	kinds := make(map[ObjectKind]int)
+	for object := range level.Objects() {
+		kinds[object.Kind]++
+		assert.Equal(t, level.Object(object.X, object.Y), object)
+		if object.Kind == ObjectStartPoint {
+			startPoints = append(startPoints, object)
+		}
+	}
+
+	assert.NotContains(t, kinds, ObjectNone)
+	assert.NotContains(t, kinds, ObjectUnknown)
+	assert.Greater(t, kinds[ObjectEnemy], 0)
+	assert.Greater(t, kinds[ObjectPickup], 0)
+	assert.Greater(t, kinds[ObjectStatic], 0)
+	assert.Greater(t, kinds[ObjectTurningPoint], 0)
+
+	assert.Len(t, startPoints, 1)
+	assert.Equal(t, 29, startPoints[0].X)
+	assert.Equal(t, 57, startPoints[0].Y)
+	assert.Equal(t, DirectionEast, startPoints[0].Direction)
+
+	t.Run("stop iterating", func(t *testing.T) {
+		count := 0
+		for range level.Objects() {
+			count++
+			if count == 3 {
+				break
+			}
+		}
+		assert.Equal(t, 3, count)
+	})
+}
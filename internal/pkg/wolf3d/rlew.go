@@ -2,6 +2,7 @@ package wolf3d
 
 import (
 	"bytes"
+	"fmt"
 )
 
 // https://moddingwiki.shikadi.net/wiki/Id_Software_RLEW_compression
@@ -13,41 +14,152 @@ func RLEWDecode(source []byte) []byte {
 
 // RLEWDecodeWithLengthPrefix decodes an RLE
 func RLEWDecodeWithLengthPrefix(source []byte) (expectedSize int, data []byte) {
-	size := readUint16(source, 0)
-	return int(size), RLEWDecode(source[2:])
+	return RLEWDecodeWithLengthPrefixAndRLEFlag(source, 0xFEFE)
 }
 
 // RLEWDecodeWithLengthPrefixAndRLEFlag decodes (decompresses) an RLE compressed uint16 stream.
 // This function should be used if the compressed stream is prefixed with uint16, little endian (two bytes) with the expected decompressed length.
 // This function does not perform any consistency checks that the decompressed is of expected length.
+// Invalid (like truncated) data is decoded as far as possible, use RLEWDecodeWithLengthPrefixAndRLEFlagChecked to detect invalid data.
 func RLEWDecodeWithLengthPrefixAndRLEFlag(source []byte, rleFlag uint16) (expectedSize int, data []byte) {
-	size := readUint16(source, 0)
+	size, err := readUint16(source, 0)
+	if err != nil {
+		return 0, nil
+	}
 	return int(size), RLEWDecodeWithRLEFlag(source[2:], rleFlag)
 }
 
 // RLEWDecodeWithRLEFlag decodes (decompresses) an RLE compressed uint16 stream.
 // This function should be used if the compressed stream is prefixed with uint16, little endian (two bytes) with the expected decompressed length.
 // This function does not perform any consistency checks that the decompressed is of expected length.
+// Invalid (like truncated) data is decoded as far as possible, use RLEWDecodeWithRLEFlagChecked to detect invalid data.
 func RLEWDecodeWithRLEFlag(source []byte, rleFlag uint16) []byte {
+	data, _ := rlewDecode(source, rleFlag, -1)
+	return data
+}
+
+// RLEWDecodeWithLengthPrefixAndRLEFlagChecked decodes (decompresses) an RLE compressed uint16 stream, prefixed with
+// uint16, little endian (two bytes) with the expected decompressed length.
+// An error is returned if the compressed stream is truncated, or if the decompressed length is not the expected length.
+func RLEWDecodeWithLengthPrefixAndRLEFlagChecked(source []byte, rleFlag uint16) ([]byte, error) {
+	size, err := readUint16(source, 0)
+	if err != nil {
+		return nil, fmt.Errorf("RLEW length prefix: %w", err)
+	}
+
+	data, err := rlewDecode(source[2:], rleFlag, int(size))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) != int(size) {
+		return nil, fmt.Errorf("RLEW decoded %d bytes, expected %d", len(data), size)
+	}
+
+	return data, nil
+}
+
+// RLEWDecodeWithRLEFlagChecked decodes (decompresses) an RLE compressed uint16 stream.
+// An error is returned if the compressed stream is truncated.
+func RLEWDecodeWithRLEFlagChecked(source []byte, rleFlag uint16) ([]byte, error) {
+	return rlewDecode(source, rleFlag, -1)
+}
+
+// rlewDecode decodes an RLE compressed uint16 stream. The data decoded so far is returned together with any error.
+// If maxSize is zero or positive, decoding stops when maxSize bytes are decoded (like the original decoder, any
+// data after that is ignored), and fails if more than maxSize bytes would be decoded.
+func rlewDecode(source []byte, rleFlag uint16, maxSize int) ([]byte, error) {
 	outputBuffer := bytes.Buffer{}
 
 	var rleFlagBytes = []byte{byte(rleFlag & 0xff), byte(rleFlag >> 8)}
 
 	var inOffset = 0
 
-	for inOffset < len(source) {
+	for inOffset < len(source) && (maxSize < 0 || outputBuffer.Len() < maxSize) {
+		if inOffset+2 > len(source) {
+			return outputBuffer.Bytes(), fmt.Errorf("RLEW data truncated at offset %d: half a word", inOffset)
+		}
+
 		var word = source[inOffset : inOffset+2]
 		inOffset += 2
 		if bytes.Equal(word, rleFlagBytes) {
+			if inOffset+4 > len(source) {
+				return outputBuffer.Bytes(), fmt.Errorf("RLEW data truncated at offset %d: incomplete run", inOffset-2)
+			}
+
 			var length = int(source[inOffset]) | int(source[inOffset+1])<<8
 			var value = source[inOffset+2 : inOffset+2+2]
 			inOffset += 4
+
+			if maxSize >= 0 && outputBuffer.Len()+length*2 > maxSize {
+				return outputBuffer.Bytes(), fmt.Errorf("RLEW data at offset %d decodes to more than the expected %d bytes", inOffset-6, maxSize)
+			}
+
 			for index := 0; index < length; index++ {
 				outputBuffer.Write(value)
 			}
 		} else {
+			if maxSize >= 0 && outputBuffer.Len()+2 > maxSize {
+				return outputBuffer.Bytes(), fmt.Errorf("RLEW data at offset %d decodes to more than the expected %d bytes", inOffset-2, maxSize)
+			}
+
+			outputBuffer.Write(word)
+		}
+	}
+
+	return outputBuffer.Bytes(), nil
+}
+
+// RLEWEncode encodes (compresses) a uint16 stream with RLE compression, using the default RLE flag 0xFEFE.
+func RLEWEncode(source []byte) []byte {
+	return RLEWEncodeWithRLEFlag(source, 0xFEFE)
+}
+
+// RLEWEncodeWithLengthPrefixAndRLEFlag encodes (compresses) a uint16 stream with RLE compression.
+// The compressed stream is prefixed with uint16, little endian (two bytes) with the length of the uncompressed stream,
+// and can be decoded by RLEWDecodeWithLengthPrefixAndRLEFlag.
+func RLEWEncodeWithLengthPrefixAndRLEFlag(source []byte, rleFlag uint16) []byte {
+	outputBuffer := bytes.Buffer{}
+	outputBuffer.Write([]byte{byte(len(source) & 0xff), byte(len(source) >> 8)})
+	outputBuffer.Write(RLEWEncodeWithRLEFlag(source, rleFlag))
+
+	return outputBuffer.Bytes()
+}
+
+// RLEWEncodeWithRLEFlag encodes (compresses) a uint16 stream with RLE compression.
+// The source is expected to have an even length (a whole number of uint16 words).
+//
+// Runs of more than three equal words are written as the RLE flag followed by the run length and the word.
+// A word equal to the RLE flag is always written as a run (even of length one), so it can not be mistaken for the flag.
+func RLEWEncodeWithRLEFlag(source []byte, rleFlag uint16) []byte {
+	const maxRunLength = 0xffff
+	const minRunLength = 4
+
+	outputBuffer := bytes.Buffer{}
+
+	var rleFlagBytes = []byte{byte(rleFlag & 0xff), byte(rleFlag >> 8)}
+
+	var inOffset = 0
+
+	for inOffset+1 < len(source) {
+		var word = source[inOffset : inOffset+2]
+
+		length := 1
+		for length < maxRunLength && inOffset+length*2+1 < len(source) && bytes.Equal(source[inOffset+length*2:inOffset+length*2+2], word) {
+			length++
+		}
+
+		if length >= minRunLength || bytes.Equal(word, rleFlagBytes) {
+			outputBuffer.Write(rleFlagBytes)
+			outputBuffer.Write([]byte{byte(length & 0xff), byte(length >> 8)})
 			outputBuffer.Write(word)
+		} else {
+			for index := 0; index < length; index++ {
+				outputBuffer.Write(word)
+			}
 		}
+
+		inOffset += length * 2
 	}
 
 	return outputBuffer.Bytes()
@@ -1,6 +1,7 @@
 package wolf3d
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -17,3 +18,121 @@ func TestRLEWDecodeWolf3dLevel1Plane0(t *testing.T) {
 	assert.Equal(t, size, len(decompressedData))
 	assert.Equal(t, expectedData, decompressedData)
 }
+
+func TestRLEWEncode(t *testing.T) {
+	type testCase struct {
+		name         string
+		decompressed []byte
+		compressed   []byte
+	}
+
+	tests := []testCase{
+		{name: "short runs are not compressed", decompressed: []byte{0x01, 0x00, 0x01, 0x00, 0x01, 0x00, 0x02, 0x00}, compressed: []byte{0x01, 0x00, 0x01, 0x00, 0x01, 0x00, 0x02, 0x00}},
+		{name: "long run is compressed", decompressed: []byte{0x01, 0x00, 0x01, 0x00, 0x01, 0x00, 0x01, 0x00, 0x02, 0x00}, compressed: []byte{0xCD, 0xAB, 0x04, 0x00, 0x01, 0x00, 0x02, 0x00}},
+		{name: "RLE flag is always compressed", decompressed: []byte{0x01, 0x00, 0xCD, 0xAB, 0x02, 0x00}, compressed: []byte{0x01, 0x00, 0xCD, 0xAB, 0x01, 0x00, 0xCD, 0xAB, 0x02, 0x00}},
+		{name: "empty", decompressed: []byte{}, compressed: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed := RLEWEncodeWithRLEFlag(tt.decompressed, 0xABCD)
+
+			assert.Equal(t, tt.compressed, compressed)
+			assert.Equal(t, len(tt.decompressed), len(RLEWDecodeWithRLEFlag(compressed, 0xABCD)))
+		})
+	}
+}
+
+func TestRLEWEncodeWolf3dLevel1Plane0(t *testing.T) {
+	decompressedData, err := readFile("testdata/wolfenstein_level1_plane0_level_data.bin")
+	assert.NoError(t, err)
+
+	const rleFlag = 0xABCD
+	compressedData := RLEWEncodeWithLengthPrefixAndRLEFlag(decompressedData, rleFlag)
+
+	size, roundTripData := RLEWDecodeWithLengthPrefixAndRLEFlag(compressedData, rleFlag)
+	assert.Equal(t, len(decompressedData), size)
+	assert.Equal(t, decompressedData, roundTripData)
+
+	// Same compression as the original game data
+	originalCompressedData, err := readFile("testdata/wolfenstein_level1_plane0_RLEW_compressed.bin")
+	assert.NoError(t, err)
+	assert.Equal(t, originalCompressedData, compressedData)
+}
+
+func TestRLEWDecodeChecked(t *testing.T) {
+	type testCase struct {
+		name         string
+		compressed   []byte
+		decompressed []byte
+		wantErr      bool
+	}
+
+	tests := []testCase{
+		{name: "valid", compressed: []byte{0x08, 0x00, 0x01, 0x00, 0xCD, 0xAB, 0x03, 0x00, 0x02, 0x00}, decompressed: []byte{0x01, 0x00, 0x02, 0x00, 0x02, 0x00, 0x02, 0x00}},
+		{name: "data after expected length is ignored", compressed: []byte{0x02, 0x00, 0x01, 0x00, 0x02, 0x00}, decompressed: []byte{0x01, 0x00}},
+		{name: "missing length prefix", compressed: []byte{0x08}, wantErr: true},
+		{name: "half a word", compressed: []byte{0x04, 0x00, 0x01, 0x00, 0x02}, wantErr: true},
+		{name: "truncated run", compressed: []byte{0x08, 0x00, 0xCD, 0xAB, 0x04, 0x00}, wantErr: true},
+		{name: "run longer than expected length", compressed: []byte{0x08, 0x00, 0xCD, 0xAB, 0xFF, 0xFF, 0x01, 0x00}, wantErr: true},
+		{name: "shorter than expected length", compressed: []byte{0x08, 0x00, 0x01, 0x00}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decompressed, err := RLEWDecodeWithLengthPrefixAndRLEFlagChecked(tt.compressed, 0xABCD)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.decompressed, decompressed)
+			}
+		})
+	}
+}
+
+func FuzzRLEWDecode(f *testing.F) {
+	compressedData, err := readFile("testdata/wolfenstein_level1_plane0_RLEW_compressed.bin")
+	assert.NoError(f, err)
+
+	f.Add(compressedData)
+	f.Add([]byte{0x08, 0x00, 0xCD, 0xAB, 0x04, 0x00, 0x01, 0x00})
+	f.Add([]byte{0x08, 0x00, 0xCD, 0xAB})
+
+	f.Fuzz(func(t *testing.T, compressed []byte) {
+		decompressed, err := RLEWDecodeWithLengthPrefixAndRLEFlagChecked(compressed, 0xABCD)
+		if err == nil {
+			size, _ := readUint16(compressed, 0)
+			assert.Len(t, decompressed, int(size))
+		}
+
+		// Without an expected length, every run can expand to 64k words, so only decode small inputs
+		if len(compressed) <= 64 {
+			_, _ = RLEWDecodeWithRLEFlagChecked(compressed, 0xABCD)
+			_, _ = RLEWDecodeWithLengthPrefixAndRLEFlag(compressed, 0xABCD)
+		}
+	})
+}
+
+func FuzzRLEWEncodeDecode(f *testing.F) {
+	decompressedData, err := readFile("testdata/wolfenstein_level1_plane0_level_data.bin")
+	assert.NoError(f, err)
+
+	f.Add(decompressedData)
+	f.Add([]byte{0xCD, 0xAB, 0xCD, 0xAB})
+	f.Add([]byte{0x01, 0x00, 0x01, 0x00, 0x01, 0x00, 0x01, 0x00})
+
+	f.Fuzz(func(t *testing.T, decompressed []byte) {
+		decompressed = decompressed[:len(decompressed)/2*2] // Whole words only
+		if len(decompressed) > 0xffff {
+			return
+		}
+
+		compressed := RLEWEncodeWithLengthPrefixAndRLEFlag(decompressed, 0xABCD)
+
+		roundTrip, err := RLEWDecodeWithLengthPrefixAndRLEFlagChecked(compressed, 0xABCD)
+		assert.NoError(t, err)
+		assert.True(t, bytes.Equal(decompressed, roundTrip))
+	})
+}
@@ -0,0 +1,157 @@
+package wolf3d
+
+import "iter"
+
+// https://moddingwiki.shikadi.net/wiki/Wolfenstein_3-D_Map_Format
+
+const (
+	wallPlane   = 0 // Plane with walls, doors and floor areas
+	objectPlane = 1 // Plane with start point, enemies, decorations, pickups and patrol turning points
+
+	firstDoorValue           = 90  // Door values are 90-101, even values are vertical doors and odd values horizontal doors
+	lastDoorValue            = 101 //
+	elevatorSwitchValue      = 21  // Wall with the elevator switch. Using it ends the level.
+	ambushFloorValue         = 106 // Floor where enemies do not react to noise ("deaf" enemies)
+	secretElevatorFloorValue = 107 // Floor in front of the secret elevator switch. Also the first floor area value.
+	firstAreaValue           = 107 // Floor area values are 107 and up, each area number is the value minus 107.
+	elevatorDoorLock         = 5   // Doors 100 and 101 are elevator doors
+)
+
+type TileKind int
+
+const (
+	TileEmpty          TileKind = iota // No tile (value 0), or a position outside the level
+	TileWall                           // Solid wall
+	TileElevatorSwitch                 // Wall with the elevator switch
+	TileDoor                           // Door anyone can open
+	TileLockedDoor                     // Door that needs a key to open
+	TileElevatorDoor                   // Door into the elevator
+	TileAmbushFloor                    // Floor where enemies are ambushing (do not react to noise)
+	TileFloorArea                      // Floor belonging to an area. Noise alerts enemies in the same area.
+	TileUnknown                        // Value not used by Wolfenstein 3D
+)
+
+func (k TileKind) String() string {
+	switch k {
+	case TileEmpty:
+		return "empty"
+	case TileWall:
+		return "wall"
+	case TileElevatorSwitch:
+		return "elevator switch"
+	case TileDoor:
+		return "door"
+	case TileLockedDoor:
+		return "locked door"
+	case TileElevatorDoor:
+		return "elevator door"
+	case TileAmbushFloor:
+		return "ambush floor"
+	case TileFloorArea:
+		return "floor area"
+	default:
+		return "unknown"
+	}
+}
+
+// Key is the key needed to open a locked door.
+type Key int
+
+const (
+	KeyNone   Key = iota
+	KeyGold       // Gold key
+	KeySilver     // Silver key
+	KeyThree      // Unused key in Wolfenstein 3D
+	KeyFour       // Unused key in Wolfenstein 3D
+)
+
+// Tile is a classified plane 0 (wall plane) value.
+type Tile struct {
+	X, Y  int
+	Value int
+	Kind  TileKind
+
+	Wall     int  // Wall number for walls, the wall images are 2*(Wall-1) (light side) and 2*(Wall-1)+1 (dark side)
+	Vertical bool // For doors, if the door plane runs north-south (you pass through it moving east or west)
+	Key      Key  // For locked doors, the key needed
+	Area     int  // For floor areas, the area number
+}
+
+// IsWall reports if the tile is a solid wall (including the elevator switch).
+func (t Tile) IsWall() bool {
+	return t.Kind == TileWall || t.Kind == TileElevatorSwitch
+}
+
+// IsDoor reports if the tile is a door of any kind.
+func (t Tile) IsDoor() bool {
+	return t.Kind == TileDoor || t.Kind == TileLockedDoor || t.Kind == TileElevatorDoor
+}
+
+// IsFloor reports if the tile is a floor you can walk on.
+func (t Tile) IsFloor() bool {
+	return t.Kind == TileAmbushFloor || t.Kind == TileFloorArea
+}
+
+// IsSecretElevatorFloor reports if the tile is the floor in front of a secret elevator switch.
+func (t Tile) IsSecretElevatorFloor() bool {
+	return t.Value == secretElevatorFloorValue
+}
+
+// ClassifyTile classifies a plane 0 (wall plane) value at x, y.
+func ClassifyTile(x, y int, value int) Tile {
+	tile := Tile{X: x, Y: y, Value: value}
+
+	switch {
+	case value <= 0:
+		tile.Kind = TileEmpty
+	case value == elevatorSwitchValue:
+		tile.Kind = TileElevatorSwitch
+		tile.Wall = value
+	case value < firstDoorValue:
+		tile.Kind = TileWall
+		tile.Wall = value
+	case value <= lastDoorValue:
+		tile.Vertical = value%2 == 0
+		lock := (value - firstDoorValue) / 2
+		switch {
+		case lock == 0:
+			tile.Kind = TileDoor
+		case lock == elevatorDoorLock:
+			tile.Kind = TileElevatorDoor
+		default:
+			tile.Kind = TileLockedDoor
+			tile.Key = Key(lock)
+		}
+	case value < ambushFloorValue:
+		tile.Kind = TileWall
+		tile.Wall = value
+	case value == ambushFloorValue:
+		tile.Kind = TileAmbushFloor
+	case value >= firstAreaValue && value <= 0xff:
+		tile.Kind = TileFloorArea
+		tile.Area = value - firstAreaValue
+	default:
+		tile.Kind = TileUnknown
+	}
+
+	return tile
+}
+
+// Tile gives the classified plane 0 (wall plane) tile at x, y.
+// An empty tile is returned if x or y is out of bounds.
+func (lm *LevelMap) Tile(x, y int) Tile {
+	return ClassifyTile(x, y, lm.Value(wallPlane, x, y))
+}
+
+// Tiles iterates over all tiles in the level, row by row.
+func (lm *LevelMap) Tiles() iter.Seq[Tile] {
+	return func(yield func(Tile) bool) {
+		for y := 0; y < lm.Height; y++ {
+			for x := 0; x < lm.Width; x++ {
+				if !yield(lm.Tile(x, y)) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package wolf3d
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestClassifyTile(t *testing.T) {
+	type testCase struct {
+		name  string
+		value int
+		tile  Tile
+	}
+
+	tests := []testCase{
+		{name: "empty", value: 0, tile: Tile{Kind: TileEmpty}},
+		{name: "grey stone wall", value: 1, tile: Tile{Value: 1, Kind: TileWall, Wall: 1}},
+		{name: "elevator switch", value: 21, tile: Tile{Value: 21, Kind: TileElevatorSwitch, Wall: 21}},
+		{name: "vertical door", value: 90, tile: Tile{Value: 90, Kind: TileDoor, Vertical: true}},
+		{name: "horizontal door", value: 91, tile: Tile{Value: 91, Kind: TileDoor}},
+		{name: "gold locked door", value: 92, tile: Tile{Value: 92, Kind: TileLockedDoor, Vertical: true, Key: KeyGold}},
+		{name: "silver locked door", value: 95, tile: Tile{Value: 95, Kind: TileLockedDoor, Key: KeySilver}},
+		{name: "elevator door", value: 100, tile: Tile{Value: 100, Kind: TileElevatorDoor, Vertical: true}},
+		{name: "elevator wall", value: 102, tile: Tile{Value: 102, Kind: TileWall, Wall: 102}},
+		{name: "ambush floor", value: 106, tile: Tile{Value: 106, Kind: TileAmbushFloor}},
+		{name: "secret elevator floor", value: 107, tile: Tile{Value: 107, Kind: TileFloorArea, Area: 0}},
+		{name: "floor area", value: 112, tile: Tile{Value: 112, Kind: TileFloorArea, Area: 5}},
+		{name: "unknown", value: 0x1234, tile: Tile{Value: 0x1234, Kind: TileUnknown}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.tile, ClassifyTile(0, 0, tt.value))
+		})
+	}
+
+	assert.True(t, ClassifyTile(0, 0, 107).IsSecretElevatorFloor())
+	assert.True(t, ClassifyTile(0, 0, 21).IsWall())
+	assert.True(t, ClassifyTile(0, 0, 100).IsDoor())
+	assert.True(t, ClassifyTile(0, 0, 106).IsFloor())
+}
+
+func TestLevelMapTiles(t *testing.T) {
+	levelMaps, err := Wolfenstein3DMap()
+	assert.NoError(t, err)
+
+	level := levelMaps[0]
+
+	tileCount := 0
+	kinds := make(map[TileKind]int)
+	for tile := range level.Tiles() {
+		tileCount++
+		kinds[tile.Kind]++
+		assert.Equal(t, level.Tile(tile.X, tile.Y), tile)
+	}
+
+	assert.Equal(t, level.Width*level.Height, tileCount)
+	assert.NotContains(t, kinds, TileUnknown)
+	assert.Greater(t, kinds[TileWall], 0)
+	assert.Greater(t, kinds[TileDoor], 0)
+	assert.Greater(t, kinds[TileElevatorSwitch], 0)
+	assert.Greater(t, kinds[TileFloorArea], 0)
+
+	assert.Equal(t, TileEmpty, level.Tile(-1, 0).Kind)
+}
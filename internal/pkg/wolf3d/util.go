@@ -2,19 +2,19 @@ package wolf3d
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
 	"os"
 )
 
-func readUint16(data []byte, offset int) uint16 {
-	var value uint16
-	if err := binary.Read(bytes.NewBuffer(data[offset:offset+2]), binary.LittleEndian, &value); err != nil {
-		panic(1)
+// readUint16 reads an uint16, little endian, at offset in data.
+func readUint16(data []byte, offset int) (uint16, error) {
+	if offset < 0 || offset+2 > len(data) {
+		return 0, fmt.Errorf("can not read uint16 at offset %d, data is only %d bytes", offset, len(data))
 	}
 
-	return value
+	return binary.LittleEndian.Uint16(data[offset:]), nil
 }
 
 func writeToFile(data []byte, filename string) {
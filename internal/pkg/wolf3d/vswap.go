@@ -0,0 +1,302 @@
+package wolf3d
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// https://moddingwiki.shikadi.net/wiki/VSWAP_Format
+
+const (
+	wallSize         = 64   // Walls are 64x64 pixels
+	spriteSize       = 64   // Sprites are (at most) 64x64 pixels
+	soundSampleRate  = 7042 // Digitised sounds are 8-bit unsigned mono PCM, played at 7042 Hz
+	paletteColorSize = 3    // Palette colors are stored as R, G, B
+	vgaMaxValue      = 63   // VGA palette values have 6 bits
+)
+
+// Sound is a digitised sound as 8-bit unsigned mono PCM.
+type Sound struct {
+	SampleRate int
+	Samples    []byte
+}
+
+// VSwap holds the chunks (pages) of a VSWAP file: the wall textures, the sprites and the digitised sounds.
+//
+// The file starts with a header of three uint16: the chunk count, the first sprite chunk and the first sound chunk.
+// Then follows the chunk table, an uint32 offset and an uint16 length for each chunk.
+// Wall chunks are 64x64 palette indexes stored column by column.
+// Sprite chunks are compressed as posts (vertical runs of pixels) per column, see Sprite.
+// Sound chunks are 4096 byte pages of PCM samples, and the last chunk is a table of where each sound starts and its length.
+type VSwap struct {
+	palette     color.Palette
+	spriteStart int
+	soundStart  int
+	chunks      [][]byte
+}
+
+// LoadVSwapFromFile reads a VSWAP file, like VSWAP.WL1. The palette is used to convert the walls and sprites to images.
+func LoadVSwapFromFile(filename string, palette color.Palette) (*VSwap, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadVSwap(file, palette)
+}
+
+// LoadVSwap reads VSWAP data. The palette is used to convert the walls and sprites to images.
+func LoadVSwap(vswapData io.ReaderAt, palette color.Palette) (*VSwap, error) {
+	header := io.NewSectionReader(vswapData, 0, 6)
+
+	var chunkCount, spriteStart, soundStart uint16
+	for _, value := range []*uint16{&chunkCount, &spriteStart, &soundStart} {
+		if err := binary.Read(header, binary.LittleEndian, value); err != nil {
+			return nil, fmt.Errorf("could not read VSWAP header: %w", err)
+		}
+	}
+
+	if spriteStart > soundStart || soundStart > chunkCount {
+		return nil, fmt.Errorf("invalid VSWAP header (chunks %d, sprite start %d, sound start %d)", chunkCount, spriteStart, soundStart)
+	}
+
+	chunkTable := io.NewSectionReader(vswapData, 6, int64(chunkCount)*(4+2))
+	offsets := make([]uint32, chunkCount)
+	lengths := make([]uint16, chunkCount)
+	if err := binary.Read(chunkTable, binary.LittleEndian, offsets); err != nil {
+		return nil, fmt.Errorf("could not read VSWAP chunk offsets: %w", err)
+	}
+	if err := binary.Read(chunkTable, binary.LittleEndian, lengths); err != nil {
+		return nil, fmt.Errorf("could not read VSWAP chunk lengths: %w", err)
+	}
+
+	chunks := make([][]byte, chunkCount)
+	for i := range chunks {
+		if offsets[i] == 0 {
+			continue // Sparse chunk, not present in the file
+		}
+
+		chunks[i] = make([]byte, lengths[i])
+		if _, err := vswapData.ReadAt(chunks[i], int64(offsets[i])); err != nil {
+			return nil, fmt.Errorf("could not read VSWAP chunk %d (offset %d, length %d): %w", i, offsets[i], lengths[i], err)
+		}
+	}
+
+	return &VSwap{palette: palette, spriteStart: int(spriteStart), soundStart: int(soundStart), chunks: chunks}, nil
+}
+
+// NewPalette creates a palette from 256 colors stored as R, G, B bytes (768 bytes). The palette of the original game
+// (GAMEPAL) has 6-bit VGA values (0-63): when no byte is over 63, the values are scaled up to 8 bits (0-255).
+func NewPalette(rgb []byte) (color.Palette, error) {
+	const colorCount = 256
+	if len(rgb) != colorCount*paletteColorSize {
+		return nil, fmt.Errorf("palette data has %d bytes, expected %d", len(rgb), colorCount*paletteColorSize)
+	}
+
+	scale := func(value byte) byte { return value }
+	if slices.Max(rgb) <= vgaMaxValue {
+		scale = func(value byte) byte { return value<<2 | value>>4 }
+	}
+
+	palette := make(color.Palette, colorCount)
+	for i := range palette {
+		palette[i] = color.RGBA{R: scale(rgb[i*3]), G: scale(rgb[i*3+1]), B: scale(rgb[i*3+2]), A: 0xff}
+	}
+
+	return palette, nil
+}
+
+// WallCount gives the number of wall chunks.
+func (v *VSwap) WallCount() int {
+	return v.spriteStart
+}
+
+// SpriteCount gives the number of sprite chunks.
+func (v *VSwap) SpriteCount() int {
+	return v.soundStart - v.spriteStart
+}
+
+// SoundCount gives the number of digitised sounds.
+func (v *VSwap) SoundCount() int {
+	return len(v.soundInfo()) / 2
+}
+
+// Wall gives wall number index as a 64x64 image.
+func (v *VSwap) Wall(index int) (image.Image, error) {
+	if index < 0 || index >= v.WallCount() {
+		return nil, fmt.Errorf("wall %d does not exist, valid walls are 0 to %d", index, v.WallCount()-1)
+	}
+
+	chunk := v.chunks[index]
+	if len(chunk) < wallSize*wallSize {
+		return nil, fmt.Errorf("wall %d has %d bytes, expected %d", index, len(chunk), wallSize*wallSize)
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, wallSize, wallSize), v.palette)
+	for x := 0; x < wallSize; x++ {
+		for y := 0; y < wallSize; y++ {
+			img.SetColorIndex(x, y, chunk[x*wallSize+y])
+		}
+	}
+
+	return img, nil
+}
+
+// Sprite gives sprite number index as a 64x64 image, transparent where the sprite has no pixels.
+//
+// A sprite chunk starts with the leftmost and rightmost columns with pixels (uint16 each), followed by an uint16
+// offset (in the chunk) for each of those columns to a list of posts. A post is three uint16:
+// the end row * 2, the offset of the post pixels (minus the start row), and the start row * 2.
+// The list of posts ends with an end row of 0.
+func (v *VSwap) Sprite(index int) (image.Image, error) {
+	if index < 0 || index >= v.SpriteCount() {
+		return nil, fmt.Errorf("sprite %d does not exist, valid sprites are 0 to %d", index, v.SpriteCount()-1)
+	}
+
+	chunk := v.chunks[v.spriteStart+index]
+	if len(chunk) < 4 {
+		return nil, fmt.Errorf("sprite %d has %d bytes, which is too short", index, len(chunk))
+	}
+
+	invalid := func() error {
+		return fmt.Errorf("sprite %d has invalid data", index)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, spriteSize, spriteSize))
+
+	leftPix := int(binary.LittleEndian.Uint16(chunk[0:]))
+	rightPix := int(binary.LittleEndian.Uint16(chunk[2:]))
+	if leftPix > rightPix || rightPix >= spriteSize || 4+(rightPix-leftPix+1)*2 > len(chunk) {
+		return nil, invalid()
+	}
+
+	for x := leftPix; x <= rightPix; x++ {
+		postOffset := int(binary.LittleEndian.Uint16(chunk[4+(x-leftPix)*2:]))
+
+		for {
+			if postOffset+2 > len(chunk) {
+				return nil, invalid()
+			}
+
+			endY := int(binary.LittleEndian.Uint16(chunk[postOffset:])) / 2
+			if endY == 0 {
+				break
+			}
+			if postOffset+6 > len(chunk) {
+				return nil, invalid()
+			}
+
+			pixelOffset := int(int16(binary.LittleEndian.Uint16(chunk[postOffset+2:])))
+			startY := int(binary.LittleEndian.Uint16(chunk[postOffset+4:])) / 2
+			if startY > endY || endY > spriteSize || pixelOffset+startY < 0 || pixelOffset+endY > len(chunk) {
+				return nil, invalid()
+			}
+
+			for y := startY; y < endY; y++ {
+				img.Set(x, y, v.color(chunk[pixelOffset+y]))
+			}
+
+			postOffset += 6
+		}
+	}
+
+	return img, nil
+}
+
+// Sound gives digitised sound number index.
+func (v *VSwap) Sound(index int) (*Sound, error) {
+	soundInfo := v.soundInfo()
+	if index < 0 || index >= len(soundInfo)/2 {
+		return nil, fmt.Errorf("sound %d does not exist, valid sounds are 0 to %d", index, len(soundInfo)/2-1)
+	}
+
+	startChunk := v.soundStart + int(soundInfo[index*2])
+	length := int(soundInfo[index*2+1])
+
+	samples := bytes.Buffer{}
+	for chunkIndex := startChunk; samples.Len() < length; chunkIndex++ {
+		if chunkIndex >= len(v.chunks)-1 {
+			return nil, fmt.Errorf("sound %d (length %d) continues past the last sound chunk", index, length)
+		}
+		samples.Write(v.chunks[chunkIndex])
+	}
+
+	return &Sound{SampleRate: soundSampleRate, Samples: samples.Bytes()[:length]}, nil
+}
+
+// Image gives a wall or sprite image by name, using the same names as the extracted resources:
+// "WAL" or "SPR" followed by the (five digit) wall or sprite number, like "WAL00012" or "SPR00003".
+func (v *VSwap) Image(name string) (image.Image, error) {
+	if len(name) <= 3 {
+		return nil, fmt.Errorf("invalid image name %q", name)
+	}
+
+	index, err := strconv.Atoi(name[3:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid image name %q: %w", name, err)
+	}
+
+	switch strings.ToUpper(name[:3]) {
+	case "WAL":
+		return v.Wall(index)
+	case "SPR":
+		return v.Sprite(index)
+	default:
+		return nil, fmt.Errorf("invalid image name %q", name)
+	}
+}
+
+// soundInfo gives the sound table from the last chunk, pairs of the first chunk (relative to the first sound chunk) and
+// the length (in bytes) of each sound.
+func (v *VSwap) soundInfo() []uint16 {
+	if len(v.chunks) == 0 || v.soundStart >= len(v.chunks) {
+		return nil
+	}
+
+	return toUint16(v.chunks[len(v.chunks)-1])
+}
+
+func (v *VSwap) color(index byte) color.Color {
+	if int(index) >= len(v.palette) {
+		return color.Black
+	}
+
+	return v.palette[index]
+}
+
+// WriteWAV writes the sound as a WAV file (RIFF, PCM, 8-bit, mono).
+func (s *Sound) WriteWAV(w io.Writer) error {
+	const headerSize = 44
+	const formatChunkSize = 16
+	const pcmFormat = 1
+	const channels = 1
+	const bitsPerSample = 8
+
+	buffer := bytes.Buffer{}
+	buffer.WriteString("RIFF")
+	_ = binary.Write(&buffer, binary.LittleEndian, uint32(headerSize-8+len(s.Samples)))
+	buffer.WriteString("WAVE")
+	buffer.WriteString("fmt ")
+	_ = binary.Write(&buffer, binary.LittleEndian, uint32(formatChunkSize))
+	_ = binary.Write(&buffer, binary.LittleEndian, uint16(pcmFormat))
+	_ = binary.Write(&buffer, binary.LittleEndian, uint16(channels))
+	_ = binary.Write(&buffer, binary.LittleEndian, uint32(s.SampleRate))
+	_ = binary.Write(&buffer, binary.LittleEndian, uint32(s.SampleRate*channels*bitsPerSample/8))
+	_ = binary.Write(&buffer, binary.LittleEndian, uint16(channels*bitsPerSample/8))
+	_ = binary.Write(&buffer, binary.LittleEndian, uint16(bitsPerSample))
+	buffer.WriteString("data")
+	_ = binary.Write(&buffer, binary.LittleEndian, uint32(len(s.Samples)))
+	buffer.Write(s.Samples)
+
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
@@ -0,0 +1,165 @@
+package wolf3d
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"testing"
+)
+
+// testVSwapData creates VSWAP data with one wall, one sprite and one sound split over two sound chunks.
+func testVSwapData() []byte {
+	wall := make([]byte, wallSize*wallSize)
+	for x := 0; x < wallSize; x++ {
+		for y := 0; y < wallSize; y++ {
+			wall[x*wallSize+y] = byte(x) // Column-major, each column has its own color
+		}
+	}
+
+	// Sprite with pixels in columns 31 and 32. Column 31 has rows 10-11 (color 1), column 32 has rows 20-22 (color 2) and row 40 (color 3).
+	sprite := bytes.Buffer{}
+	_ = binary.Write(&sprite, binary.LittleEndian, []uint16{31, 32, 8, 16})
+	pixelStart := uint16(8 + (6 + 2) + (6 + 6 + 2))                                                  // Header, column 31 posts, column 32 posts
+	_ = binary.Write(&sprite, binary.LittleEndian, []uint16{12 * 2, pixelStart - 10, 10 * 2})        // Column 31, post rows 10-11
+	_ = binary.Write(&sprite, binary.LittleEndian, []uint16{0})                                      // Column 31, end of posts
+	_ = binary.Write(&sprite, binary.LittleEndian, []uint16{23 * 2, pixelStart + 2 - 20, 20 * 2})    // Column 32, post rows 20-22
+	_ = binary.Write(&sprite, binary.LittleEndian, []uint16{41 * 2, pixelStart + 5 - 40, 40 * 2, 0}) // Column 32, post row 40 and end of posts
+	sprite.Write([]byte{1, 1, 2, 2, 2, 3})
+
+	soundChunk1 := []byte{0x80, 0x90, 0xa0, 0xb0}
+	soundChunk2 := []byte{0xc0, 0xd0, 0x00, 0x00}
+	soundInfo := bytes.Buffer{}
+	_ = binary.Write(&soundInfo, binary.LittleEndian, []uint16{0, 6})
+
+	chunks := [][]byte{wall, sprite.Bytes(), soundChunk1, soundChunk2, soundInfo.Bytes()}
+
+	data := bytes.Buffer{}
+	_ = binary.Write(&data, binary.LittleEndian, []uint16{uint16(len(chunks)), 1, 2})
+	offset := uint32(6 + len(chunks)*6)
+	for _, chunk := range chunks {
+		_ = binary.Write(&data, binary.LittleEndian, offset)
+		offset += uint32(len(chunk))
+	}
+	for _, chunk := range chunks {
+		_ = binary.Write(&data, binary.LittleEndian, uint16(len(chunk)))
+	}
+	for _, chunk := range chunks {
+		data.Write(chunk)
+	}
+
+	return data.Bytes()
+}
+
+func testPalette() color.Palette {
+	rgb := make([]byte, 256*3)
+	for i := 0; i < 256; i++ {
+		rgb[i*3] = byte(i)
+		rgb[i*3+1] = byte(255 - i)
+		rgb[i*3+2] = 0x10
+	}
+
+	palette, _ := NewPalette(rgb)
+	return palette
+}
+
+func TestNewPalette(t *testing.T) {
+	rgb := make([]byte, 256*3)
+	rgb[0], rgb[1], rgb[2] = 63, 32, 1
+	rgb[3], rgb[4], rgb[5] = 64, 128, 255
+
+	palette, err := NewPalette(rgb)
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBA{R: 63, G: 32, B: 1, A: 0xff}, palette[0], "8-bit values are used as they are")
+	assert.Equal(t, color.RGBA{R: 64, G: 128, B: 255, A: 0xff}, palette[1])
+
+	rgb[3], rgb[4], rgb[5] = 0, 0, 0
+	palette, err = NewPalette(rgb)
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBA{R: 255, G: 130, B: 4, A: 0xff}, palette[0], "6-bit VGA values are scaled up")
+	assert.Equal(t, color.RGBA{R: 0, G: 0, B: 0, A: 0xff}, palette[1])
+
+	_, err = NewPalette(rgb[:765])
+	assert.Error(t, err)
+}
+
+func TestLoadVSwap(t *testing.T) {
+	vswap, err := LoadVSwap(bytes.NewReader(testVSwapData()), testPalette())
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, vswap.WallCount())
+	assert.Equal(t, 1, vswap.SpriteCount())
+	assert.Equal(t, 1, vswap.SoundCount())
+
+	t.Run("wall is column-major", func(t *testing.T) {
+		wall, err := vswap.Wall(0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 64, wall.Bounds().Dx())
+		assert.Equal(t, 64, wall.Bounds().Dy())
+		assert.Equal(t, color.RGBA{R: 5, G: 250, B: 0x10, A: 0xff}, wall.At(5, 0))
+		assert.Equal(t, color.RGBA{R: 5, G: 250, B: 0x10, A: 0xff}, wall.At(5, 63))
+		assert.Equal(t, color.RGBA{R: 63, G: 192, B: 0x10, A: 0xff}, wall.At(63, 10))
+	})
+
+	t.Run("sprite posts", func(t *testing.T) {
+		sprite, err := vswap.Sprite(0)
+		assert.NoError(t, err)
+
+		transparent := color.NRGBA{}
+		assert.Equal(t, transparent, sprite.At(31, 9))
+		assert.Equal(t, color.NRGBA{R: 1, G: 254, B: 0x10, A: 0xff}, sprite.At(31, 10))
+		assert.Equal(t, color.NRGBA{R: 1, G: 254, B: 0x10, A: 0xff}, sprite.At(31, 11))
+		assert.Equal(t, transparent, sprite.At(31, 12))
+		assert.Equal(t, color.NRGBA{R: 2, G: 253, B: 0x10, A: 0xff}, sprite.At(32, 20))
+		assert.Equal(t, color.NRGBA{R: 2, G: 253, B: 0x10, A: 0xff}, sprite.At(32, 22))
+		assert.Equal(t, transparent, sprite.At(32, 23))
+		assert.Equal(t, color.NRGBA{R: 3, G: 252, B: 0x10, A: 0xff}, sprite.At(32, 40))
+		assert.Equal(t, transparent, sprite.At(0, 0))
+	})
+
+	t.Run("sound spans sound chunks", func(t *testing.T) {
+		sound, err := vswap.Sound(0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 7042, sound.SampleRate)
+		assert.Equal(t, []byte{0x80, 0x90, 0xa0, 0xb0, 0xc0, 0xd0}, sound.Samples)
+	})
+
+	t.Run("image by name", func(t *testing.T) {
+		wall, err := vswap.Image("WAL00000")
+		assert.NoError(t, err)
+		assert.Equal(t, 64, wall.Bounds().Dx())
+
+		_, err = vswap.Image("SPR00000")
+		assert.NoError(t, err)
+
+		_, err = vswap.Image("WAL00001")
+		assert.Error(t, err)
+
+		_, err = vswap.Image("XYZ00000")
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadVSwapInvalidData(t *testing.T) {
+	data := testVSwapData()
+
+	_, err := LoadVSwap(bytes.NewReader(data[:4]), testPalette())
+	assert.Error(t, err)
+
+	_, err = LoadVSwap(bytes.NewReader(data[:len(data)-10]), testPalette())
+	assert.Error(t, err)
+}
+
+func TestSoundWriteWAV(t *testing.T) {
+	wavData, err := readFile("resources/extracted/SND00000.wav")
+	assert.NoError(t, err)
+
+	const wavHeaderSize = 44
+	sound := &Sound{SampleRate: 7042, Samples: wavData[wavHeaderSize:]}
+
+	writtenWAVData := bytes.Buffer{}
+	assert.NoError(t, sound.WriteWAV(&writtenWAVData))
+	assert.Equal(t, wavData, writtenWAVData.Bytes())
+}